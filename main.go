@@ -118,6 +118,11 @@ func main() {
 				os.Exit(1)
 			}
 			return
+		case "evr-mm-sim":
+			if err := server.MatchmakerSimulatorCmd(ctx, tmpLogger, os.Args[2:]); err != nil {
+				tmpLogger.Fatal("Matchmaker simulation failed", zap.Error(err))
+			}
+			return
 		case "healthcheck":
 			port := "7350"
 			if len(os.Args) > 2 {
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2025 The Nakama Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS match_data_journal (
+    PRIMARY KEY (match_id, create_time),
+
+    create_time TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    events      JSONB        NOT NULL DEFAULT '[]',
+    match_id    VARCHAR(128) NOT NULL CHECK (length(match_id) > 0),
+    update_time TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS match_data_journal_update_time_idx
+    ON match_data_journal (update_time);
+
+CREATE INDEX IF NOT EXISTS match_data_journal_create_time_idx
+    ON match_data_journal (create_time);
+
+-- Scans match the journals on the types of their events.
+CREATE INDEX IF NOT EXISTS match_data_journal_events_idx
+    ON match_data_journal USING GIN (events jsonb_path_ops);
+
+-- Reads match all of the journals of a match on the match ID prefix.
+CREATE INDEX IF NOT EXISTS match_data_journal_match_id_pattern_idx
+    ON match_data_journal (match_id text_pattern_ops);
+
+-- +migrate Down
+DROP TABLE IF EXISTS match_data_journal;
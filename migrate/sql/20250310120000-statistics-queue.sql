@@ -0,0 +1,44 @@
+/*
+ * Copyright 2025 The Nakama Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS statistics_queue (
+    PRIMARY KEY (idempotency_key),
+
+    applied_time      TIMESTAMPTZ,
+    attempts          INTEGER      NOT NULL DEFAULT 0,
+    create_time       TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    dead_time         TIMESTAMPTZ,
+    entry             JSONB        NOT NULL,
+    idempotency_key   VARCHAR(512) NOT NULL CHECK (length(idempotency_key) > 0),
+    last_error        TEXT         NOT NULL DEFAULT '',
+    next_attempt_time TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS statistics_queue_pending_idx
+    ON statistics_queue (next_attempt_time)
+    WHERE applied_time IS NULL AND dead_time IS NULL;
+
+CREATE INDEX IF NOT EXISTS statistics_queue_applied_time_idx
+    ON statistics_queue (applied_time)
+    WHERE applied_time IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS statistics_queue_dead_time_idx
+    ON statistics_queue (dead_time)
+    WHERE dead_time IS NOT NULL;
+
+-- +migrate Down
+DROP TABLE IF EXISTS statistics_queue;
@@ -6,15 +6,15 @@ import (
 	"net/http"
 )
 
-// SNSLobbySmiteEntrant represents a message from server to client indicating a failure in OtherUserProfileRequest.
+// SNSLobbySmiteEntrant represents a message from server to game server, instructing it to forcibly remove an entrant.
 type SNSLobbySmiteEntrant struct {
-	EvrId      EvrId  // The identifier of the associated user.
-	StatusCode uint64 // The status code returned with the failure. (These are http status codes)
-	Message    string // The message returned with the failure.
+	EvrId      EvrId  // The identifier of the entrant to remove.
+	StatusCode uint64 // The reason for the removal. (These are http status codes)
+	Message    string // The message displayed to the entrant.
 }
 
 func (m *SNSLobbySmiteEntrant) Token() string {
-	return "SNSSNSLobbySmiteEntrant"
+	return "SNSLobbySmiteEntrant"
 }
 
 func (m *SNSLobbySmiteEntrant) Symbol() Symbol {
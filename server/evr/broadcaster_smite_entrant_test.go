@@ -0,0 +1,45 @@
+package evr
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSNSLobbySmiteEntrantEncodeDecode(t *testing.T) {
+	packet := []byte{
+		0xf6, 0x40, 0xbb, 0x78, 0xa2, 0xe7, 0x8c, 0xbb, // Magic Marker
+		0x64, 0x6c, 0xd9, 0xb4, 0x6c, 0xed, 0x1f, 0x4c, // Symbol
+		0x1c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Length (28)
+		0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // XPI PlatformID
+		0x16, 0xa9, 0x53, 0x29, 0xef, 0x14, 0x0e, 0x00, // XPI AccountID
+		0x93, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Status Code (403)
+		0x42, 0x79, 0x65, 0x00, // Message ("Bye")
+	}
+
+	want := NewSNSLobbySmiteEntrant(EvrId{PlatformCode: 4, AccountId: 0x0e14ef2953a916}, http.StatusForbidden, "Bye")
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+
+	if !bytes.Equal(data, packet) {
+		t.Errorf("unexpected packet (-want +got):\n%s", cmp.Diff(packet, data))
+	}
+
+	msgs, err := ParsePacket(packet)
+	if err != nil {
+		t.Fatalf("ParsePacket returned an error: %v", err)
+	}
+
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+
+	if diff := cmp.Diff(want, msgs[0]); diff != "" {
+		t.Errorf("unexpected SNSLobbySmiteEntrant (-want +got):\n%s", diff)
+	}
+}
@@ -51,6 +51,9 @@ type LegalConsents struct {
 	GameAdminVersion    int64 `json:"game_admin_version,omitempty"`
 	SplashScreenVersion int64 `json:"splash_screen_version,omitempty"`
 	GroupsLegalVersion  int64 `json:"groups_legal_version,omitempty"`
+
+	PrivacyPolicyVersion int64 `json:"privacy_policy_version,omitempty"`
+	PatchNotesVersion    int64 `json:"patch_notes_version,omitempty"`
 }
 
 type NewPlayerProgress struct {
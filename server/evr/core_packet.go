@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"slices"
 	"strconv"
 )
 
@@ -20,12 +19,10 @@ var (
 
 	SymbolTypes = map[uint64]Message{
 		// This is the complete list of implemented message types.
-		/*
-			0x4c1fed6cb4d96c64: (*SNSLobbySmiteEntrant)(nil),
-			0x013e99cb47eb3669: (*GenericMessage)(nil),
-			0x35d810572a230837: (*GenericMessageNotify)(nil),
-			0x80119c19ac72d695: (*MatchEnded)(nil),
-		*/
+		0x4c1fed6cb4d96c64: (*SNSLobbySmiteEntrant)(nil),
+		0x013e99cb47eb3669: (*GenericMessage)(nil),
+		0x35d810572a230837: (*GenericMessageNotify)(nil),
+		0x80119c19ac72d695: (*MatchEnded)(nil),
 		0x0dabc24265508a82: (*ReconcileIAPResult)(nil),
 		0x1225133828150da3: (*OtherUserProfileFailure)(nil),
 		0x1230073227050cb5: (*OtherUserProfileSuccess)(nil),
@@ -250,13 +247,6 @@ func SplitPacket(data []byte) [][]byte {
 	return bytes.Split(data, MessageMarker)
 }
 
-var ignoredSymbols = []uint64{
-	0x4c1fed6cb4d96c64,
-	0x013e99cb47eb3669,
-	0x35d810572a230837,
-	0x80119c19ac72d695,
-}
-
 // ParsePacket parses the wire-format packet in data and places the result in m.
 // The provided message must be mutable (e.g., a non-nil pointer to a slice).
 func ParsePacket(data []byte) ([]Message, error) {
@@ -280,11 +270,6 @@ func ParsePacket(data []byte) ([]Message, error) {
 		// Read the message type and data length.
 		sym := dUint64(buf.Next(8))
 
-		// Ignore specific messages
-		if slices.Contains(ignoredSymbols, sym) {
-			continue
-		}
-
 		l := int(dUint64(buf.Next(8)))
 		// Verify the message data can be read from the rest of the packet.
 		if buf.Len() != l {
@@ -12,6 +12,7 @@ type ReconcileIAPResult struct {
 type IAPData struct {
 	Balance       IAPBalance `json:"balance"`
 	TransactionId int64      `json:"transactionid"`
+	SKUs          []string   `json:"skus,omitempty"` // The store items owned by the user
 }
 
 type IAPBalance struct {
@@ -10,7 +10,10 @@ type Document interface {
 	Symbol() Symbol
 }
 
-var _ = Document(&EULADocument{})
+var (
+	_ = Document(&EULADocument{})
+	_ = Document(&TextDocument{})
+)
 
 type EULADocument struct {
 	Type                          string `json:"type"`
@@ -64,6 +67,24 @@ func NewEULADocument(version, versionGa int, language, linkURL, text string) EUL
 	}
 }
 
+// TextDocument is a document other than the EULA, such as the privacy policy or patch notes.
+type TextDocument struct {
+	Type                 string `json:"type"`
+	Lang                 string `json:"lang"`
+	Version              int64  `json:"version"`
+	Text                 string `json:"text"`
+	MarkAsReadProfileKey string `json:"mark_as_read_profile_key"`
+	Link                 string `json:"link,omitempty"`
+}
+
+func (d TextDocument) Symbol() Symbol {
+	return ToSymbol(d.Type)
+}
+
+func (d TextDocument) String() string {
+	return fmt.Sprintf("%T(lang=%v, type=%v)", d, d.Lang, d.Type)
+}
+
 type DocumentSuccess struct {
 	Document Document
 }
@@ -85,7 +106,7 @@ func (m *DocumentSuccess) Stream(s *EasyStream) error {
 				case 0xc8c33e483f6612b1: // eula
 					m.Document = &EULADocument{}
 				default:
-					return fmt.Errorf("unknown document type: `%s`", documentSymbol.Token())
+					m.Document = &TextDocument{}
 				}
 			}
 			return s.StreamJson(m.Document, true, ZstdCompression)
@@ -0,0 +1,57 @@
+package evr
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenericMessageNotifyEncodeDecode(t *testing.T) {
+	want := NewGenericMessageNotify(
+		ToSymbol("ovr_social_member_data_nack"),
+		uuid.Must(uuid.FromString("00112233-4455-6677-8899-aabbccddeeff")),
+		0x03742bbd733916,
+		*NewGenericMessageData(ModeSocialPublic, 2, "accepted", PrivateLobby, uuid.Must(uuid.NewV4()).String(), 1, 5678),
+	)
+
+	// The party data is compressed, so only the fixed-size prefix is compared.
+	header := []byte{
+		0xf6, 0x40, 0xbb, 0x78, 0xa2, 0xe7, 0x8c, 0xbb, // Magic Marker
+		0x37, 0x08, 0x23, 0x2a, 0x57, 0x10, 0xd8, 0x35, // Symbol
+	}
+	body := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Padding
+		0x16, 0x39, 0x73, 0xbd, 0x2b, 0x74, 0x03, 0x00, // Room ID
+		0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, // Session GUID (mixed endian)
+		0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		0x15, 0xe6, 0x48, 0x84, 0xff, 0x35, 0xea, 0xb9, // Message Type (ovr_social_member_data_nack)
+	}
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+
+	if !bytes.HasPrefix(data, header) {
+		t.Errorf("unexpected packet header (-want +got):\n%s", cmp.Diff(header, data[:len(header)]))
+	}
+
+	if got := data[len(header)+8:]; !bytes.HasPrefix(got, body) {
+		t.Errorf("unexpected packet body (-want +got):\n%s", cmp.Diff(body, got[:min(len(body), len(got))]))
+	}
+
+	msgs, err := ParsePacket(data)
+	if err != nil {
+		t.Fatalf("ParsePacket returned an error: %v", err)
+	}
+
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+
+	if diff := cmp.Diff(want, msgs[0]); diff != "" {
+		t.Errorf("unexpected GenericMessageNotify (-want +got):\n%s", diff)
+	}
+}
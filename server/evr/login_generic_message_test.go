@@ -1,7 +1,11 @@
 package evr
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestGenericMessageEncodeDecode(t *testing.T) {
@@ -9,20 +13,77 @@ func TestGenericMessageEncodeDecode(t *testing.T) {
 		0xf6, 0x40, 0xbb, 0x78, 0xa2, 0xe7, 0x8c, 0xbb, // Magic Marker
 		0x69, 0x36, 0xeb, 0x47, 0xcb, 0x99, 0x3e, 0x01, // Symbol
 		0x38, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Length (56)
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // {Session uuid.UUID (16bytes)
-		0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // XPI Platform ID
-		0xc4, 0x89, 0xa5, 0xfa, 0x4a, 0x2e, 0x07, 0x00, // XPI Account ID
-		0x15, 0xe6, 0x48, 0x84, 0xff, 0x35, 0xea, 0xb9,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Session uuid.UUID (16bytes)
+		0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xc4, 0x89, 0xa5, 0xfa, 0x4a, 0x2e, 0x07, 0x00, // Account ID
+		0x15, 0xe6, 0x48, 0x84, 0xff, 0x35, 0xea, 0xb9, // Message Type (ovr_social_member_data_nack)
 		0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // XPI PlatformID
 		0x16, 0xa9, 0x53, 0x29, 0xef, 0x14, 0x0e, 0x00, // XPI AccountID
-		0x16, 0x39, 0x73, 0xbd, 0x2b, 0x74, 0x03, 0x00, // Room ID?
+		0x16, 0x39, 0x73, 0xbd, 0x2b, 0x74, 0x03, 0x00, // Room ID
 	}
 
 	// Unmarshal test packet
 	msgs, err := ParsePacket(packet)
 	if err != nil {
-		t.Errorf("Unmarshal returned an error: %v", err)
+		t.Fatalf("Unmarshal returned an error: %v", err)
+	}
+
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+
+	msg, ok := msgs[0].(*GenericMessage)
+	if !ok {
+		t.Fatalf("expected *GenericMessage, got %T", msgs[0])
+	}
+
+	if msg.MessageType != ToSymbol("ovr_social_member_data_nack") {
+		t.Errorf("unexpected message type: %s", msg.MessageType)
+	}
+
+	if msg.OtherEvrID.PlatformCode != 4 || msg.OtherEvrID.AccountId != 0x0e14ef2953a916 {
+		t.Errorf("unexpected other evr id: %s", msg.OtherEvrID)
+	}
+
+	if msg.RoomID != 0x03742bbd733916 {
+		t.Errorf("unexpected room id: %d", msg.RoomID)
+	}
+
+	// Marshal it back
+	data, err := Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
 	}
-	t.Errorf("%s", msgs)
 
+	if !bytes.Equal(data, packet) {
+		t.Errorf("unexpected packet (-want +got):\n%s", cmp.Diff(packet, data))
+	}
+}
+
+func TestGenericMessagePartyDataEncodeDecode(t *testing.T) {
+	want := NewGenericMessage(
+		uuid.Must(uuid.NewV4()),
+		0x072e4afaa589c4,
+		ToSymbol("ovr_social_member_data"),
+		EvrId{PlatformCode: 4, AccountId: 0x0e14ef2953a916},
+		*NewGenericMessageData(ModeArenaPublic, 1, "invite", PublicLobby, uuid.Must(uuid.NewV4()).String(), 0, 1234),
+	)
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+
+	msgs, err := ParsePacket(data)
+	if err != nil {
+		t.Fatalf("ParsePacket returned an error: %v", err)
+	}
+
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+
+	if diff := cmp.Diff(want, msgs[0]); diff != "" {
+		t.Errorf("unexpected GenericMessage (-want +got):\n%s", diff)
+	}
 }
@@ -4,8 +4,12 @@ import (
 	"fmt"
 )
 
-type MatchEnded struct {
-	// TODO
+// MatchEnded is a message from server to client, indicating the lobby session the client is in has ended.
+// It has no payload; the payload of the client's message is not known.
+type MatchEnded struct{}
+
+func NewMatchEnded() *MatchEnded {
+	return &MatchEnded{}
 }
 
 func (m MatchEnded) Token() string {
@@ -0,0 +1,40 @@
+package evr
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchEndedEncodeDecode(t *testing.T) {
+	packet := []byte{
+		0xf6, 0x40, 0xbb, 0x78, 0xa2, 0xe7, 0x8c, 0xbb, // Magic Marker
+		0x95, 0xd6, 0x72, 0xac, 0x19, 0x9c, 0x11, 0x80, // Symbol
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Length (0)
+	}
+
+	want := NewMatchEnded()
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+
+	if !bytes.Equal(data, packet) {
+		t.Errorf("unexpected packet (-want +got):\n%s", cmp.Diff(packet, data))
+	}
+
+	msgs, err := ParsePacket(packet)
+	if err != nil {
+		t.Fatalf("ParsePacket returned an error: %v", err)
+	}
+
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+
+	if diff := cmp.Diff(want, msgs[0]); diff != "" {
+		t.Errorf("unexpected MatchEnded (-want +got):\n%s", diff)
+	}
+}
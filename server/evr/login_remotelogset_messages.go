@@ -64,6 +64,8 @@ func RemoteLogMessageFromLogString(log []byte) (RemoteLog, error) {
 		m = &RemoteLogServerConnectionFailed{}
 	case "Disconnected from server due to timeout":
 		m = &RemoteLogDisconnectedDueToTimeout{}
+	case "r15 net game error message":
+		m = &RemoteLogR15NetGameErrorMessage{}
 
 	// `message_type` property
 	case "VOIP_LOUDNESS":
@@ -282,6 +284,7 @@ type RemoteLogFindNewLobby struct {
 }
 
 type RemoteLogR15NetGameErrorMessage struct {
+	GenericRemoteLog
 	ErrorMessage string `json:"error_message"`
 }
 
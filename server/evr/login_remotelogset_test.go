@@ -119,6 +119,18 @@ func TestParseRemoteLog(t *testing.T) {
 			wantError: false,
 		},
 
+		{
+			name:    "Test with valid r15 net game error message",
+			message: `{"message":"r15 net game error message","error_message":"failed to load level"}`,
+			want: &RemoteLogR15NetGameErrorMessage{
+				GenericRemoteLog: GenericRemoteLog{
+					Message: "r15 net game error message",
+				},
+				ErrorMessage: "failed to load level",
+			},
+			wantError: false,
+		},
+
 		{
 			name:    "Test with valid GAME_SETTINGS message",
 			message: `{"message":"Game Pause Settings","message_type":"GAME_SETTINGS","game_settings":{"announcer":1,"music":0,"sfx":8,"voip":10,"wristangleoffset":21.000000,"smoothrotationspeed":10.000000,"personalbubbleradius":2.000000,"grabdeadzone":7.000000,"releasedistance":7.500000,"personalbubblemode":0,"personalspacemode":2,"voipmode":3,"voipmodeffect":0,"voiploudnesslevel":0,"dynamicmusicmode":0,"HUD":false,"EnableYaw":true,"EnablePitch":true,"EnableRoll":false,"EnableSmoothRotation":true,"EnablePersonalBubble":false,"EnablePersonalSpace":true,"EnableNetStatusHUD":false,"EnableNetStatusPause":true,"EnableAPIAccess":true,"EnableGhostAll":false,"EnableMuteAll":false,"EnableMuteEnemyTeam":false,"MatchTagDisplay":true,"EnableVoipLoudness":true,"EnableMaxLoudness":false,"EnableStreamerMode":false}}`,
@@ -17,6 +17,8 @@ type Chunk struct {
 	Len   uint64
 	Data  struct {
 		GenericMessage                     *GenericMessage                    `struct-case:"0x013e99cb47eb3669" json:",omitempty"`
+		GenericMessageNotify               *GenericMessageNotify              `struct-case:"0x35d810572a230837" json:",omitempty"`
+		SNSLobbySmiteEntrant               *SNSLobbySmiteEntrant              `struct-case:"0x4c1fed6cb4d96c64" json:",omitempty"`
 		MatchEnded                         *MatchEnded                        `struct-case:"0x80119c19ac72d695" json:",omitempty"`
 		ReconcileIAPResult                 *ReconcileIAPResult                `struct-case:"0x0dabc24265508a82" json:",omitempty"`
 		OtherUserProfileFailure            *OtherUserProfileFailure           `struct-case:"0x1225133828150da3" json:",omitempty"`
@@ -150,6 +150,7 @@ type AccountMetadata struct {
 	NewUnlocks                 []int64                `json:"new_unlocks"`                  // The new unlocks
 	GamePauseSettings          *evr.GamePauseSettings `json:"game_pause_settings"`          // The game settings
 	LegalConsents              evr.LegalConsents      `json:"legal_consents"`               // The legal consents
+	CommunityValuesAccepted    map[string]int64       `json:"community_values_accepted"`    // The version of each guild's community values that was last accepted, by group ID
 	CustomizationPOIs          *evr.Customization     `json:"customization_pois"`           // The customization POIs
 	MatchmakingDivision        string                 `json:"matchmaking_division"`         // The matchmaking division (e.g. bronze, silver, gold, etc.)
 	sessionDisplayNameOverride string                 // The display name override for this session
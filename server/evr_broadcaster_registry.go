@@ -1,53 +1,496 @@
 package server
 
 import (
+	"context"
+	"math"
 	"sync"
 	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const (
+	broadcasterHealthWindow        = 30 * time.Minute // How long samples and events count towards the score
+	broadcasterHealthBucketWidth   = time.Minute      // The samples and events are aggregated in buckets of this width
+	broadcasterSessionStartTimeout = 30 * time.Second // How long a server has to start a session
+	broadcasterMinimumPingSamples  = 10               // The samples needed before loss and jitter are scored
+	broadcasterMinimumQuarantine   = 5 * time.Minute
+	broadcasterRecentFailures      = 5 // The failures listed in a server's health
+
+	// The score below which a server is quarantined, and above which it recovers.
+	broadcasterQuarantineThreshold = 0.5
+	broadcasterRecoveryThreshold   = 0.8
+
+	broadcasterHealthBuckets = int(broadcasterHealthWindow / broadcasterHealthBucketWidth)
+)
+
+// The game servers by endpoint, shared by the pipeline, the matches and the lobby builder.
+var globalBroadcasterRegistry = NewBroadcasterRegistry()
+
+type BroadcasterHealthEvent string
+
+const (
+	BroadcasterHealthEventSessionStartFailed BroadcasterHealthEvent = "session_start_failed" // The server did not start a lobby session
+	BroadcasterHealthEventCrash              BroadcasterHealthEvent = "crash"                // The server disconnected during a lobby session
+	BroadcasterHealthEventConnectionFailed   BroadcasterHealthEvent = "connection_failed"    // A player reported failing to connect
 )
 
+// The score penalty of each event in the window. Connection failures are counted once for each player that reported them.
+var broadcasterHealthEventPenalties = map[BroadcasterHealthEvent]float64{
+	BroadcasterHealthEventSessionStartFailed: 0.25,
+	BroadcasterHealthEventCrash:              0.35,
+	BroadcasterHealthEventConnectionFailed:   0.05,
+}
+
+// broadcasterBucket aggregates the pings and events of one bucket width.
+type broadcasterBucket struct {
+	start         time.Time // The start of the bucket, or zero if it is unused
+	pings         int
+	lost          int
+	rttTotal      time.Duration
+	jitterTotal   time.Duration
+	jitterSamples int
+	events        map[BroadcasterHealthEvent]int
+}
+
+// Broadcaster is the recent health of a game server. It is kept by endpoint, so it survives reconnections.
 type Broadcaster struct {
-	IsInMatch bool
-	LastPing  time.Time
+	sync.Mutex
+	Endpoint      string
+	OperatorID    uuid.UUID
+	SessionID     uuid.UUID // Nil while disconnected
+	IsInMatch     bool
+	LastPing      time.Time
+	Quarantined   bool
+	QuarantinedAt time.Time
+	ConnectedAt   time.Time
+	PlayerTime    time.Duration // The time players have spent in the server's matches
+
+	buckets   [broadcasterHealthBuckets]broadcasterBucket // A ring buffer, indexed by the bucket's start time
+	lastRTT   time.Duration                               // The last received ping, or -1 if there is none
+	reporters map[string]time.Time                        // The last connection failure reported by each player, by user ID
+	failures  []BroadcasterFailure                        // The latest events, oldest first
+}
+
+func NewBroadcaster(endpoint string) *Broadcaster {
+	return &Broadcaster{
+		Endpoint:  endpoint,
+		lastRTT:   -1,
+		reporters: make(map[string]time.Time),
+		failures:  make([]BroadcasterFailure, 0, broadcasterRecentFailures),
+	}
+}
+
+// BroadcasterHealth is a snapshot of a game server's health.
+type BroadcasterHealth struct {
+	Endpoint      string                         `json:"endpoint"`
+	OperatorID    string                         `json:"operator_id"`
+	SessionID     string                         `json:"session_id,omitempty"`
+	Score         float64                        `json:"score"`
+	MeanRTTMs     float64                        `json:"mean_rtt_ms"`
+	JitterMs      float64                        `json:"jitter_ms"`
+	Loss          float64                        `json:"loss"`
+	Samples       int                            `json:"samples"`
+	Events        map[BroadcasterHealthEvent]int `json:"events,omitempty"`
+	Quarantined   bool                           `json:"quarantined"`
+	QuarantinedAt time.Time                      `json:"quarantined_at,omitempty"`
+	ConnectedAt   time.Time                      `json:"connected_at,omitempty"`
+	PlayerMinutes float64                        `json:"player_minutes"`
+	Failures      []BroadcasterFailure           `json:"recent_failures,omitempty"` // The latest events, newest first
+}
+
+type BroadcasterFailure struct {
+	Time  time.Time              `json:"time"`
+	Event BroadcasterHealthEvent `json:"event"`
+}
+
+// bucket returns the bucket of the time, resetting it if it was last used a window ago.
+func (b *Broadcaster) bucket(t time.Time) *broadcasterBucket {
+	start := t.Truncate(broadcasterHealthBucketWidth)
+	bucket := &b.buckets[int(start.UnixNano()/int64(broadcasterHealthBucketWidth))%broadcasterHealthBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = broadcasterBucket{start: start}
+	}
+	return bucket
+}
+
+// inWindow returns true if the bucket holds samples from the window ending at now.
+func (bucket *broadcasterBucket) inWindow(now time.Time) bool {
+	return !bucket.start.IsZero() && now.Sub(bucket.start) < broadcasterHealthWindow
+}
+
+func (b *Broadcaster) recordPing(now time.Time, rtt time.Duration, lost bool) {
+	bucket := b.bucket(now)
+	bucket.pings++
+	if lost {
+		bucket.lost++
+		return
+	}
+	bucket.rttTotal += rtt
+	if b.lastRTT >= 0 {
+		bucket.jitterTotal += (rtt - b.lastRTT).Abs()
+		bucket.jitterSamples++
+	}
+	b.lastRTT = rtt
+	b.LastPing = now
+}
+
+func (b *Broadcaster) recordFailure(now time.Time, event BroadcasterHealthEvent) {
+	if len(b.failures) == broadcasterRecentFailures {
+		b.failures = append(b.failures[:0], b.failures[1:]...)
+	}
+	b.failures = append(b.failures, BroadcasterFailure{now, event})
+}
+
+func (b *Broadcaster) recordEvent(now time.Time, event BroadcasterHealthEvent) {
+	bucket := b.bucket(now)
+	if bucket.events == nil {
+		bucket.events = make(map[BroadcasterHealthEvent]int)
+	}
+	bucket.events[event]++
+	b.recordFailure(now, event)
+}
+
+// recordConnectionFailed records a player's report. A player counts once in the window, however often they report.
+func (b *Broadcaster) recordConnectionFailed(now time.Time, reporterID string) {
+	if last, ok := b.reporters[reporterID]; !ok || now.Sub(last) >= broadcasterHealthWindow {
+		b.recordFailure(now, BroadcasterHealthEventConnectionFailed)
+	}
+	b.reporters[reporterID] = now
+}
+
+// isIdle returns true if nothing about the server is in the window.
+func (b *Broadcaster) isIdle(now time.Time) bool {
+	if !b.SessionID.IsNil() || b.Quarantined || len(b.reporters) > 0 {
+		return false
+	}
+	for i := range b.buckets {
+		if b.buckets[i].inWindow(now) {
+			return false
+		}
+	}
+	return true
 }
 
+// Health scores the server from 0 to 1, by its ping loss and jitter, and the events in the window.
+func (b *Broadcaster) Health(now time.Time) BroadcasterHealth {
+	h := BroadcasterHealth{
+		Endpoint:      b.Endpoint,
+		OperatorID:    b.OperatorID.String(),
+		Events:        make(map[BroadcasterHealthEvent]int),
+		Quarantined:   b.Quarantined,
+		QuarantinedAt: b.QuarantinedAt,
+		ConnectedAt:   b.ConnectedAt,
+		PlayerMinutes: b.PlayerTime.Minutes(),
+	}
+	if !b.SessionID.IsNil() {
+		h.SessionID = b.SessionID.String()
+	}
+
+	var (
+		lost          int
+		rttTotal      time.Duration
+		jitterTotal   time.Duration
+		jitterSamples int
+	)
+	for i := range b.buckets {
+		bucket := &b.buckets[i]
+		if !bucket.inWindow(now) {
+			continue
+		}
+		h.Samples += bucket.pings
+		lost += bucket.lost
+		rttTotal += bucket.rttTotal
+		jitterTotal += bucket.jitterTotal
+		jitterSamples += bucket.jitterSamples
+		for event, n := range bucket.events {
+			h.Events[event] += n
+		}
+	}
+
+	for reporterID, last := range b.reporters {
+		if now.Sub(last) >= broadcasterHealthWindow {
+			delete(b.reporters, reporterID)
+			continue
+		}
+		h.Events[BroadcasterHealthEventConnectionFailed]++
+	}
+
+	if received := h.Samples - lost; received > 0 {
+		h.MeanRTTMs = float64(rttTotal.Milliseconds()) / float64(received)
+	}
+	if jitterSamples > 0 {
+		h.JitterMs = float64(jitterTotal.Milliseconds()) / float64(jitterSamples)
+	}
+	if h.Samples > 0 {
+		h.Loss = float64(lost) / float64(h.Samples)
+	}
+
+	penalty := 0.0
+	if h.Samples >= broadcasterMinimumPingSamples {
+		penalty += h.Loss * 2
+		penalty += math.Min(h.JitterMs/50, 1) * 0.25
+	}
+	for event, n := range h.Events {
+		penalty += broadcasterHealthEventPenalties[event] * float64(n)
+	}
+
+	for i := len(b.failures) - 1; i >= 0; i-- {
+		if now.Sub(b.failures[i].Time) < broadcasterHealthWindow {
+			h.Failures = append(h.Failures, b.failures[i])
+		}
+	}
+	h.Score = math.Max(0, 1-penalty)
+	return h
+}
+
+// evaluate quarantines or releases the server by its score, returning true if it changed.
+func (b *Broadcaster) evaluate(now time.Time) (BroadcasterHealth, bool) {
+	h := b.Health(now)
+	switch {
+	case !b.Quarantined && h.Score < broadcasterQuarantineThreshold:
+		b.Quarantined, b.QuarantinedAt = true, now
+	case b.Quarantined && h.Score >= broadcasterRecoveryThreshold && now.Sub(b.QuarantinedAt) >= broadcasterMinimumQuarantine:
+		b.Quarantined, b.QuarantinedAt = false, time.Time{}
+	default:
+		return h, false
+	}
+	h.Quarantined, h.QuarantinedAt = b.Quarantined, b.QuarantinedAt
+	return h, true
+}
+
+type pendingSessionStart struct {
+	endpoint string
+	time     time.Time
+}
+
+// BroadcasterRegistry tracks the health of the game servers, and quarantines the unhealthy ones.
+// The registry's lock guards its maps; each server's health is guarded by the server's own lock.
 type BroadcasterRegistry struct {
-	Broadcasters map[string]*Broadcaster
-	mutex        sync.RWMutex
+	sync.RWMutex
+	broadcasters  map[string]*Broadcaster
+	sessions      map[uuid.UUID]string // The endpoints of the connected servers, by session ID
+	pendingStarts map[uuid.UUID]pendingSessionStart
+
+	// Called when a server is quarantined or released.
+	notify func(h BroadcasterHealth)
 }
 
 func NewBroadcasterRegistry() *BroadcasterRegistry {
 	return &BroadcasterRegistry{
-		Broadcasters: make(map[string]*Broadcaster),
+		broadcasters:  make(map[string]*Broadcaster),
+		sessions:      make(map[uuid.UUID]string),
+		pendingStarts: make(map[uuid.UUID]pendingSessionStart),
+	}
+}
+
+func (br *BroadcasterRegistry) SetNotifier(fn func(h BroadcasterHealth)) {
+	br.Lock()
+	defer br.Unlock()
+	br.notify = fn
+}
+
+func (br *BroadcasterRegistry) get(endpoint string) *Broadcaster {
+	br.RLock()
+	defer br.RUnlock()
+	return br.broadcasters[endpoint]
+}
+
+// update applies fn to the server, and re-evaluates its quarantine.
+func (br *BroadcasterRegistry) update(endpoint string, fn func(b *Broadcaster, now time.Time)) {
+	br.RLock()
+	b := br.broadcasters[endpoint]
+	notify := br.notify
+	br.RUnlock()
+	if b == nil {
+		return
+	}
+
+	now := time.Now()
+	b.Lock()
+	fn(b, now)
+	h, changed := b.evaluate(now)
+	b.Unlock()
+
+	if changed && notify != nil {
+		notify(h)
+	}
+}
+
+func (br *BroadcasterRegistry) Register(endpoint string, operatorID, sessionID uuid.UUID) {
+	br.Lock()
+	defer br.Unlock()
+	b, ok := br.broadcasters[endpoint]
+	if !ok {
+		b = NewBroadcaster(endpoint)
+		br.broadcasters[endpoint] = b
+	}
+	br.sessions[sessionID] = endpoint
+
+	b.Lock()
+	defer b.Unlock()
+	b.OperatorID = operatorID
+	b.SessionID = sessionID
+	b.IsInMatch = false
+	b.ConnectedAt = time.Now()
+}
+
+// Disconnected records a crash if the server disconnected during a lobby session.
+func (br *BroadcasterRegistry) Disconnected(sessionID uuid.UUID) {
+	br.Lock()
+	endpoint, ok := br.sessions[sessionID]
+	delete(br.sessions, sessionID)
+	br.Unlock()
+	if !ok {
+		return
 	}
+
+	br.update(endpoint, func(b *Broadcaster, now time.Time) {
+		if b.SessionID != sessionID {
+			return
+		}
+		if b.IsInMatch {
+			b.recordEvent(now, BroadcasterHealthEventCrash)
+		}
+		b.SessionID = uuid.Nil
+		b.IsInMatch = false
+	})
+}
+
+func (br *BroadcasterRegistry) RecordPing(endpoint string, rtt time.Duration, lost bool) {
+	br.update(endpoint, func(b *Broadcaster, now time.Time) {
+		b.recordPing(now, rtt, lost)
+	})
 }
 
-func (br *BroadcasterRegistry) AddBroadcaster(id string, b *Broadcaster) {
-	br.mutex.Lock()
-	defer br.mutex.Unlock()
-	br.Broadcasters[id] = b
+// SessionStarting records that the server has been asked to start a lobby session.
+func (br *BroadcasterRegistry) SessionStarting(matchID uuid.UUID, endpoint string) {
+	br.Lock()
+	defer br.Unlock()
+	br.pendingStarts[matchID] = pendingSessionStart{endpoint, time.Now()}
 }
 
-func (br *BroadcasterRegistry) RemoveBroadcaster(id string) {
-	br.mutex.Lock()
-	defer br.mutex.Unlock()
-	delete(br.Broadcasters, id)
+func (br *BroadcasterRegistry) SessionStarted(sessionID, matchID uuid.UUID) {
+	br.Lock()
+	delete(br.pendingStarts, matchID)
+	endpoint := br.sessions[sessionID]
+	br.Unlock()
+
+	br.update(endpoint, func(b *Broadcaster, now time.Time) { b.IsInMatch = true })
 }
 
-func (br *BroadcasterRegistry) HealthCheck() {
+func (br *BroadcasterRegistry) SessionEnded(sessionID uuid.UUID) {
+	br.RLock()
+	endpoint := br.sessions[sessionID]
+	br.RUnlock()
+
+	br.update(endpoint, func(b *Broadcaster, now time.Time) { b.IsInMatch = false })
+}
+
+// ConnectionFailed records a player's report that they could not connect to the server.
+// Each player counts once in the window, so that one player can not quarantine a server.
+func (br *BroadcasterRegistry) ConnectionFailed(endpoint string, reporterID string) {
+	br.update(endpoint, func(b *Broadcaster, now time.Time) {
+		b.recordConnectionFailed(now, reporterID)
+	})
+}
+
+// RecordPlayerTime adds a player's time in one of the server's matches.
+func (br *BroadcasterRegistry) RecordPlayerTime(endpoint string, d time.Duration) {
+	if b := br.get(endpoint); b != nil {
+		b.Lock()
+		b.PlayerTime += d
+		b.Unlock()
+	}
+}
+
+func (br *BroadcasterRegistry) IsQuarantined(endpoint string) bool {
+	b := br.get(endpoint)
+	if b == nil {
+		return false
+	}
+	b.Lock()
+	defer b.Unlock()
+	return b.Quarantined
+}
+
+// Get returns the health of the server.
+func (br *BroadcasterRegistry) Get(endpoint string) (BroadcasterHealth, bool) {
+	b := br.get(endpoint)
+	if b == nil {
+		return BroadcasterHealth{}, false
+	}
+	b.Lock()
+	defer b.Unlock()
+	return b.Health(time.Now()), true
+}
+
+// List returns the health of every known server.
+func (br *BroadcasterRegistry) List() []BroadcasterHealth {
+	br.RLock()
+	broadcasters := make([]*Broadcaster, 0, len(br.broadcasters))
+	for _, b := range br.broadcasters {
+		broadcasters = append(broadcasters, b)
+	}
+	br.RUnlock()
+
+	now := time.Now()
+	health := make([]BroadcasterHealth, 0, len(broadcasters))
+	for _, b := range broadcasters {
+		b.Lock()
+		health = append(health, b.Health(now))
+		b.Unlock()
+	}
+	return health
+}
+
+// check records the session starts that have timed out, and re-evaluates every server so that they recover
+// as their events leave the window. Disconnected servers are forgotten once their window is empty.
+func (br *BroadcasterRegistry) check(now time.Time) {
+	br.Lock()
+
+	timedOut := make(map[string]int)
+	for matchID, p := range br.pendingStarts {
+		if now.Sub(p.time) < broadcasterSessionStartTimeout {
+			continue
+		}
+		delete(br.pendingStarts, matchID)
+		timedOut[p.endpoint]++
+	}
+
+	changed := make([]BroadcasterHealth, 0)
+	for endpoint, b := range br.broadcasters {
+		b.Lock()
+		for range timedOut[endpoint] {
+			b.recordEvent(now, BroadcasterHealthEventSessionStartFailed)
+		}
+		if h, ok := b.evaluate(now); ok {
+			changed = append(changed, h)
+		}
+		if b.isIdle(now) {
+			delete(br.broadcasters, endpoint)
+		}
+		b.Unlock()
+	}
+	notify := br.notify
+	br.Unlock()
+
+	if notify != nil {
+		for _, h := range changed {
+			notify(h)
+		}
+	}
+}
+
+func (br *BroadcasterRegistry) Start(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
 	for {
-		br.mutex.RLock()
-		for id, b := range br.Broadcasters {
-			if time.Since(b.LastPing) > 10*time.Second {
-				if b.IsInMatch {
-					if time.Since(b.LastPing) > 30*time.Second {
-						br.RemoveBroadcaster(id)
-					}
-				} else {
-					br.RemoveBroadcaster(id)
-				}
-			}
-		}
-		br.mutex.RUnlock()
-		time.Sleep(10 * time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			br.check(time.Now())
+		}
 	}
 }
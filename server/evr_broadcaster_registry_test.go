@@ -0,0 +1,117 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+func TestBroadcasterHealthScore(t *testing.T) {
+	now := time.Now()
+	b := NewBroadcaster("127.0.0.1:6792")
+	for i := range 20 {
+		b.recordPing(now, 20*time.Millisecond, i%10 == 0)
+	}
+
+	h := b.Health(now)
+	if h.Loss != 0.1 || h.JitterMs != 0 || h.MeanRTTMs != 20 || h.Samples != 20 {
+		t.Errorf("unexpected health %+v", h)
+	}
+	if h.Score < 0.79 || h.Score > 0.81 {
+		t.Errorf("expected a score of 0.8, got %.2f", h.Score)
+	}
+
+	b.recordEvent(now.Add(-2*broadcasterHealthWindow), BroadcasterHealthEventCrash)
+	if got := b.Health(now); len(got.Events) != 0 || len(got.Failures) != 0 {
+		t.Errorf("expected events outside the window to be ignored, got %v", got.Events)
+	}
+
+	// A bucket is reset when it is reused a window later.
+	later := now.Add(broadcasterHealthWindow)
+	b.recordPing(later, 20*time.Millisecond, false)
+	if got := b.Health(later); got.Samples != 1 || got.Loss != 0 {
+		t.Errorf("expected only the new sample, got %+v", got)
+	}
+}
+
+func TestBroadcasterConnectionFailedReporters(t *testing.T) {
+	now := time.Now()
+	b := NewBroadcaster("127.0.0.1:6792")
+
+	for range 20 {
+		b.recordConnectionFailed(now, "a")
+	}
+	b.recordConnectionFailed(now, "b")
+
+	h := b.Health(now)
+	if h.Events[BroadcasterHealthEventConnectionFailed] != 2 || len(h.Failures) != 2 {
+		t.Errorf("expected each reporter to be counted once, got %+v", h)
+	}
+	if h.Score < 0.89 || h.Score > 0.91 {
+		t.Errorf("expected a score of 0.9, got %.2f", h.Score)
+	}
+
+	if got := b.Health(now.Add(broadcasterHealthWindow)); got.Events[BroadcasterHealthEventConnectionFailed] != 0 {
+		t.Errorf("expected the reports to leave the window, got %v", got.Events)
+	}
+}
+
+func TestBroadcasterRegistryQuarantine(t *testing.T) {
+	var notified []BroadcasterHealth
+	br := NewBroadcasterRegistry()
+	br.SetNotifier(func(h BroadcasterHealth) { notified = append(notified, h) })
+
+	endpoint := "127.0.0.1:6792"
+	sessionID := uuid.Must(uuid.NewV4())
+	br.Register(endpoint, uuid.Must(uuid.NewV4()), sessionID)
+
+	// A lobby session that never starts, and a crash during another.
+	br.SessionStarting(uuid.Must(uuid.NewV4()), endpoint)
+	br.check(time.Now().Add(broadcasterSessionStartTimeout))
+	br.SessionStarted(sessionID, uuid.Must(uuid.NewV4()))
+	br.Disconnected(sessionID)
+
+	if !br.IsQuarantined(endpoint) {
+		t.Fatal("expected the server to be quarantined")
+	}
+	if len(notified) != 1 || !notified[0].Quarantined {
+		t.Fatalf("expected one quarantine notification, got %+v", notified)
+	}
+
+	// The server recovers once the events leave the window.
+	br.check(time.Now().Add(broadcasterHealthWindow + time.Minute))
+	if br.IsQuarantined(endpoint) {
+		t.Error("expected the server to recover")
+	}
+	if len(notified) != 2 || notified[1].Quarantined {
+		t.Errorf("expected a recovery notification, got %+v", notified)
+	}
+}
+
+func TestBroadcasterRegistryDashboard(t *testing.T) {
+	br := NewBroadcasterRegistry()
+	endpoint := "127.0.0.1:6792"
+	br.Register(endpoint, uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()))
+
+	for i := range broadcasterRecentFailures + 2 {
+		br.ConnectionFailed(endpoint, uuid.NewV5(uuid.Nil, string(rune('a'+i))).String())
+	}
+	br.RecordPlayerTime(endpoint, 90*time.Second)
+	br.RecordPlayerTime(endpoint, 30*time.Second)
+
+	h, ok := br.Get(endpoint)
+	if !ok {
+		t.Fatal("expected the server to be found")
+	}
+	if h.PlayerMinutes != 2 || h.ConnectedAt.IsZero() {
+		t.Errorf("unexpected health %+v", h)
+	}
+	if len(h.Failures) != broadcasterRecentFailures || h.Events[BroadcasterHealthEventConnectionFailed] != broadcasterRecentFailures+2 {
+		t.Errorf("expected the %d most recent failures, got %+v", broadcasterRecentFailures, h.Failures)
+	}
+
+	if _, ok := br.Get("127.0.0.1:6793"); ok {
+		t.Error("expected an unknown server not to be found")
+	}
+}
@@ -0,0 +1,465 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+var globalClientHealthRegistry = NewClientHealthRegistry()
+
+const (
+	// Each node's buckets are stored in this collection, keyed by the node's name.
+	StorageCollectionClientHealth = "ClientHealth"
+	// How often a node stores its buckets.
+	clientHealthPersistInterval = time.Minute
+	// Buckets that have not been updated for this long are dropped.
+	clientHealthRetention = 14 * 24 * time.Hour
+	// The fewest loads a build needs before it is compared with another build.
+	clientHealthMinLoads = 30
+	// The relative increase of a metric, from the previous build, that is reported as a regression.
+	clientHealthRegressionThreshold = 0.2
+)
+
+type ClientHealthEvent string
+
+const (
+	ClientHealthEventLoad             ClientHealthEvent = "load"
+	ClientHealthEventTimeout          ClientHealthEvent = "timeout"
+	ClientHealthEventConnectionFailed ClientHealthEvent = "connection_failed"
+	ClientHealthEventNetGameError     ClientHealthEvent = "net_game_error"
+)
+
+// ClientHealthKey is the bucket that a client's load stats and disconnects are aggregated into. The game server is
+// only kept in the report, and is not a metrics tag.
+type ClientHealthKey struct {
+	BuildNumber evr.BuildNumber `json:"build_number"`
+	HeadsetType string          `json:"headset_type"` // The normalized headset type
+	Region      string          `json:"region"`       // The client's region code
+	GameServer  string          `json:"game_server"`  // The external address of the game server, if known
+}
+
+func (k ClientHealthKey) AsMap() map[string]string {
+	return map[string]string{
+		"build_version": fmt.Sprintf("%d", k.BuildNumber),
+		"headset_type":  k.HeadsetType,
+		"region":        k.Region,
+	}
+}
+
+type ClientHealthStats struct {
+	Loads              int       `json:"loads"`
+	LoadTime           float64   `json:"load_time"` // The total load times, in seconds
+	ClientLoadTime     float64   `json:"client_load_time"`
+	ServerLoadTime     float64   `json:"server_load_time"`
+	Timeouts           int       `json:"timeouts"`
+	ConnectionFailures int       `json:"connection_failures"`
+	NetGameErrors      int       `json:"net_game_errors"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func (s *ClientHealthStats) merge(o *ClientHealthStats) {
+	s.Loads += o.Loads
+	s.LoadTime += o.LoadTime
+	s.ClientLoadTime += o.ClientLoadTime
+	s.ServerLoadTime += o.ServerLoadTime
+	s.Timeouts += o.Timeouts
+	s.ConnectionFailures += o.ConnectionFailures
+	s.NetGameErrors += o.NetGameErrors
+	if o.UpdatedAt.After(s.UpdatedAt) {
+		s.UpdatedAt = o.UpdatedAt
+	}
+}
+
+// ClientHealthBucket is a bucket, as it is stored.
+type ClientHealthBucket struct {
+	Key   ClientHealthKey   `json:"key"`
+	Stats ClientHealthStats `json:"stats"`
+}
+
+// ClientHealthNodeBuckets are the buckets of a node, as they are stored.
+type ClientHealthNodeBuckets struct {
+	Node    string                `json:"node"`
+	Buckets []*ClientHealthBucket `json:"buckets"`
+}
+
+// mergeClientHealthBuckets adds the buckets that are within the retention to the aggregates.
+func mergeClientHealthBuckets(dst map[ClientHealthKey]ClientHealthStats, buckets []*ClientHealthBucket) {
+	for _, b := range buckets {
+		if time.Since(b.Stats.UpdatedAt) > clientHealthRetention {
+			continue
+		}
+		s := dst[b.Key]
+		s.merge(&b.Stats)
+		dst[b.Key] = s
+	}
+}
+
+// ClientHealthRegistry aggregates the clients' load stats, timeouts, connection failures and net game errors. Each
+// node stores its buckets, so that the report includes every node, and survives restarts.
+type ClientHealthRegistry struct {
+	sync.Mutex
+	buckets map[ClientHealthKey]*ClientHealthStats
+	metrics *EVRMetrics
+
+	nk   runtime.NakamaModule
+	node string
+}
+
+func NewClientHealthRegistry() *ClientHealthRegistry {
+	return &ClientHealthRegistry{
+		buckets: make(map[ClientHealthKey]*ClientHealthStats),
+	}
+}
+
+func (r *ClientHealthRegistry) SetMetrics(metrics *EVRMetrics) {
+	r.Lock()
+	defer r.Unlock()
+	r.metrics = metrics
+}
+
+// Start restores the node's stored buckets, and stores them periodically until the context is done.
+func (r *ClientHealthRegistry) Start(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, node string) {
+	stored := &ClientHealthNodeBuckets{}
+	if objs, err := nk.StorageRead(ctx, []*runtime.StorageRead{{Collection: StorageCollectionClientHealth, Key: node, UserID: SystemUserID}}); err != nil {
+		logger.WithField("error", err).Warn("Failed to read client health")
+	} else if len(objs) > 0 {
+		if err := json.Unmarshal([]byte(objs[0].Value), stored); err != nil {
+			logger.WithField("error", err).Warn("Failed to unmarshal client health")
+		}
+	}
+
+	r.Lock()
+	r.nk = nk
+	r.node = node
+	for _, b := range stored.Buckets {
+		s, ok := r.buckets[b.Key]
+		if !ok {
+			s = &ClientHealthStats{}
+			r.buckets[b.Key] = s
+		}
+		s.merge(&b.Stats)
+	}
+	r.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(clientHealthPersistInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := r.persist(ctx); err != nil {
+					logger.WithField("error", err).Warn("Failed to store client health")
+				}
+				return
+			case <-ticker.C:
+				if err := r.persist(ctx); err != nil {
+					logger.WithField("error", err).Warn("Failed to store client health")
+				}
+			}
+		}
+	}()
+}
+
+// snapshot returns a copy of the buckets within the retention, dropping the others.
+func (r *ClientHealthRegistry) snapshot() []*ClientHealthBucket {
+	r.Lock()
+	defer r.Unlock()
+	buckets := make([]*ClientHealthBucket, 0, len(r.buckets))
+	for k, s := range r.buckets {
+		if time.Since(s.UpdatedAt) > clientHealthRetention {
+			delete(r.buckets, k)
+			continue
+		}
+		buckets = append(buckets, &ClientHealthBucket{Key: k, Stats: *s})
+	}
+	return buckets
+}
+
+// persist stores the node's buckets.
+func (r *ClientHealthRegistry) persist(ctx context.Context) error {
+	data, err := json.Marshal(ClientHealthNodeBuckets{Node: r.node, Buckets: r.snapshot()})
+	if err != nil {
+		return err
+	}
+	_, err = r.nk.StorageWrite(ctx, []*runtime.StorageWrite{{
+		Collection:      StorageCollectionClientHealth,
+		Key:             r.node,
+		UserID:          SystemUserID,
+		Value:           string(data),
+		PermissionRead:  0,
+		PermissionWrite: 0,
+	}})
+	return err
+}
+
+func (r *ClientHealthRegistry) update(key ClientHealthKey, fn func(s *ClientHealthStats)) *EVRMetrics {
+	r.Lock()
+	defer r.Unlock()
+	s, ok := r.buckets[key]
+	if !ok {
+		s = &ClientHealthStats{}
+		r.buckets[key] = s
+	}
+	fn(s)
+	s.UpdatedAt = time.Now()
+	return r.metrics
+}
+
+// RecordLoad adds a client's load times, in seconds.
+func (r *ClientHealthRegistry) RecordLoad(key ClientHealthKey, loadTime, clientLoadTime, serverLoadTime float64) {
+	metrics := r.update(key, func(s *ClientHealthStats) {
+		s.Loads++
+		s.LoadTime += loadTime
+		s.ClientLoadTime += clientLoadTime
+		s.ServerLoadTime += serverLoadTime
+	})
+	if metrics != nil {
+		metrics.CountClientHealthEvent(key, ClientHealthEventLoad)
+		metrics.RecordClientLoadTime(key, loadTime, clientLoadTime, serverLoadTime)
+	}
+}
+
+// RecordEvent counts a timeout, connection failure or net game error.
+func (r *ClientHealthRegistry) RecordEvent(key ClientHealthKey, event ClientHealthEvent) {
+	metrics := r.update(key, func(s *ClientHealthStats) {
+		switch event {
+		case ClientHealthEventTimeout:
+			s.Timeouts++
+		case ClientHealthEventConnectionFailed:
+			s.ConnectionFailures++
+		case ClientHealthEventNetGameError:
+			s.NetGameErrors++
+		}
+	})
+	if metrics != nil {
+		metrics.CountClientHealthEvent(key, event)
+	}
+}
+
+// Report summarizes the buckets of every node that match the filter, by build, and compares each build with the
+// previous one. This node's buckets are taken from memory; the other nodes' are as they last stored them.
+func (r *ClientHealthRegistry) Report(ctx context.Context, filter ClientHealthFilter) (*ClientHealthReport, error) {
+	buckets := make(map[ClientHealthKey]ClientHealthStats)
+	mergeClientHealthBuckets(buckets, r.snapshot())
+
+	r.Lock()
+	nk, node := r.nk, r.node
+	r.Unlock()
+
+	if nk != nil {
+		cursor := ""
+		for {
+			objs, next, err := nk.StorageList(ctx, "", SystemUserID, StorageCollectionClientHealth, 100, cursor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list client health: %w", err)
+			}
+			for _, obj := range objs {
+				if obj.Key == node {
+					continue
+				}
+				stored := &ClientHealthNodeBuckets{}
+				if err := json.Unmarshal([]byte(obj.Value), stored); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal client health of node %s: %w", obj.Key, err)
+				}
+				mergeClientHealthBuckets(buckets, stored.Buckets)
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+	}
+
+	return NewClientHealthReport(buckets, filter), nil
+}
+
+type ClientHealthFilter struct {
+	HeadsetType string `json:"headset_type,omitempty"`
+	Region      string `json:"region,omitempty"`
+	GameServer  string `json:"game_server,omitempty"`
+}
+
+func (f ClientHealthFilter) matches(k ClientHealthKey) bool {
+	return (f.HeadsetType == "" || f.HeadsetType == k.HeadsetType) &&
+		(f.Region == "" || f.Region == k.Region) &&
+		(f.GameServer == "" || f.GameServer == k.GameServer)
+}
+
+// ClientHealthSummary is the health of a build, for all headsets or for one headset type.
+type ClientHealthSummary struct {
+	BuildNumber           evr.BuildNumber `json:"build_number"`
+	HeadsetType           string          `json:"headset_type,omitempty"`
+	Loads                 int             `json:"loads"`
+	Timeouts              int             `json:"timeouts"`
+	ConnectionFailures    int             `json:"connection_failures"`
+	NetGameErrors         int             `json:"net_game_errors"`
+	MeanLoadTime          float64         `json:"mean_load_time"` // Seconds
+	MeanClientLoadTime    float64         `json:"mean_client_load_time"`
+	MeanServerLoadTime    float64         `json:"mean_server_load_time"`
+	TimeoutRate           float64         `json:"timeout_rate"` // Per load
+	ConnectionFailureRate float64         `json:"connection_failure_rate"`
+	NetGameErrorRate      float64         `json:"net_game_error_rate"`
+}
+
+func newClientHealthSummary(buildNumber evr.BuildNumber, headsetType string, s *ClientHealthStats) *ClientHealthSummary {
+	summary := &ClientHealthSummary{
+		BuildNumber:        buildNumber,
+		HeadsetType:        headsetType,
+		Loads:              s.Loads,
+		Timeouts:           s.Timeouts,
+		ConnectionFailures: s.ConnectionFailures,
+		NetGameErrors:      s.NetGameErrors,
+	}
+	if s.Loads > 0 {
+		n := float64(s.Loads)
+		summary.MeanLoadTime = s.LoadTime / n
+		summary.MeanClientLoadTime = s.ClientLoadTime / n
+		summary.MeanServerLoadTime = s.ServerLoadTime / n
+		summary.TimeoutRate = float64(s.Timeouts) / n
+		summary.ConnectionFailureRate = float64(s.ConnectionFailures) / n
+		summary.NetGameErrorRate = float64(s.NetGameErrors) / n
+	}
+	return summary
+}
+
+// The compared metrics, and the smallest absolute increase that is reported as a regression.
+var clientHealthMetrics = []struct {
+	name  string
+	floor float64
+	value func(s *ClientHealthSummary) float64
+}{
+	{"mean_load_time", 1, func(s *ClientHealthSummary) float64 { return s.MeanLoadTime }},
+	{"timeout_rate", 0.01, func(s *ClientHealthSummary) float64 { return s.TimeoutRate }},
+	{"connection_failure_rate", 0.01, func(s *ClientHealthSummary) float64 { return s.ConnectionFailureRate }},
+	{"net_game_error_rate", 0.01, func(s *ClientHealthSummary) float64 { return s.NetGameErrorRate }},
+}
+
+type ClientHealthRegression struct {
+	BuildNumber         evr.BuildNumber `json:"build_number"`
+	PreviousBuildNumber evr.BuildNumber `json:"previous_build_number"`
+	HeadsetType         string          `json:"headset_type,omitempty"`
+	Metric              string          `json:"metric"`
+	Previous            float64         `json:"previous"`
+	Current             float64         `json:"current"`
+	Change              float64         `json:"change,omitempty"` // The relative increase, if the previous value is not zero
+}
+
+type ClientHealthReport struct {
+	Filter      ClientHealthFilter        `json:"filter"`
+	Builds      []*ClientHealthSummary    `json:"builds"`             // All headsets, by build, newest first
+	Headsets    []*ClientHealthSummary    `json:"headsets,omitempty"` // By build and headset type
+	Regressions []*ClientHealthRegression `json:"regressions,omitempty"`
+}
+
+func (r ClientHealthReport) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func NewClientHealthReport(buckets map[ClientHealthKey]ClientHealthStats, filter ClientHealthFilter) *ClientHealthReport {
+	type headsetKey struct {
+		buildNumber evr.BuildNumber
+		headsetType string
+	}
+
+	builds := make(map[evr.BuildNumber]*ClientHealthStats)
+	headsets := make(map[headsetKey]*ClientHealthStats)
+	for k, s := range buckets {
+		if !filter.matches(k) {
+			continue
+		}
+		if _, ok := builds[k.BuildNumber]; !ok {
+			builds[k.BuildNumber] = &ClientHealthStats{}
+		}
+		builds[k.BuildNumber].merge(&s)
+
+		hk := headsetKey{k.BuildNumber, k.HeadsetType}
+		if _, ok := headsets[hk]; !ok {
+			headsets[hk] = &ClientHealthStats{}
+		}
+		headsets[hk].merge(&s)
+	}
+
+	report := &ClientHealthReport{
+		Filter:   filter,
+		Builds:   make([]*ClientHealthSummary, 0, len(builds)),
+		Headsets: make([]*ClientHealthSummary, 0, len(headsets)),
+	}
+	for b, s := range builds {
+		report.Builds = append(report.Builds, newClientHealthSummary(b, "", s))
+	}
+	for k, s := range headsets {
+		report.Headsets = append(report.Headsets, newClientHealthSummary(k.buildNumber, k.headsetType, s))
+	}
+
+	slices.SortFunc(report.Builds, func(a, b *ClientHealthSummary) int {
+		return int(b.BuildNumber) - int(a.BuildNumber)
+	})
+	slices.SortFunc(report.Headsets, func(a, b *ClientHealthSummary) int {
+		if c := strings.Compare(a.HeadsetType, b.HeadsetType); c != 0 {
+			return c
+		}
+		return int(b.BuildNumber) - int(a.BuildNumber)
+	})
+
+	report.Regressions = append(report.Regressions, clientHealthRegressions(report.Builds)...)
+
+	// Compare each headset type's builds separately.
+	for i := 0; i < len(report.Headsets); {
+		j := i + 1
+		for j < len(report.Headsets) && report.Headsets[j].HeadsetType == report.Headsets[i].HeadsetType {
+			j++
+		}
+		report.Regressions = append(report.Regressions, clientHealthRegressions(report.Headsets[i:j])...)
+		i = j
+	}
+
+	return report
+}
+
+// clientHealthRegressions compares each build with the previous build, of the summaries sorted newest first.
+func clientHealthRegressions(summaries []*ClientHealthSummary) []*ClientHealthRegression {
+	compared := make([]*ClientHealthSummary, 0, len(summaries))
+	for _, s := range summaries {
+		if s.Loads >= clientHealthMinLoads {
+			compared = append(compared, s)
+		}
+	}
+
+	regressions := make([]*ClientHealthRegression, 0)
+	for i := 0; i+1 < len(compared); i++ {
+		current, previous := compared[i], compared[i+1]
+		for _, m := range clientHealthMetrics {
+			c, p := m.value(current), m.value(previous)
+			if c-p < m.floor || c < p*(1+clientHealthRegressionThreshold) {
+				continue
+			}
+			r := &ClientHealthRegression{
+				BuildNumber:         current.BuildNumber,
+				PreviousBuildNumber: previous.BuildNumber,
+				HeadsetType:         current.HeadsetType,
+				Metric:              m.name,
+				Previous:            p,
+				Current:             c,
+			}
+			if p > 0 {
+				r.Change = (c - p) / p
+			}
+			regressions = append(regressions, r)
+		}
+	}
+	return regressions
+}
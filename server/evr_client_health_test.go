@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+func TestNewClientHealthReport(t *testing.T) {
+	const (
+		oldBuild evr.BuildNumber = 630783
+		newBuild evr.BuildNumber = 631547
+		rawBuild evr.BuildNumber = 632000 // Too few loads to be compared
+	)
+
+	r := NewClientHealthRegistry()
+	for i := 0; i < 50; i++ {
+		r.RecordLoad(ClientHealthKey{oldBuild, "Quest 3", "US-CA", "1.2.3.4:6792"}, 10, 6, 4)
+		r.RecordLoad(ClientHealthKey{newBuild, "Quest 3", "US-CA", "1.2.3.4:6792"}, 10, 6, 4)
+		r.RecordLoad(ClientHealthKey{oldBuild, "Index", "US-CA", "1.2.3.4:6792"}, 5, 3, 2)
+		r.RecordLoad(ClientHealthKey{newBuild, "Index", "US-CA", "1.2.3.4:6792"}, 5, 3, 2)
+	}
+	r.RecordLoad(ClientHealthKey{rawBuild, "Quest 3", "US-CA", "1.2.3.4:6792"}, 60, 50, 10)
+
+	// The new build times out more often on Quest 3.
+	for i := 0; i < 10; i++ {
+		r.RecordEvent(ClientHealthKey{newBuild, "Quest 3", "US-CA", "1.2.3.4:6792"}, ClientHealthEventTimeout)
+	}
+	r.RecordEvent(ClientHealthKey{oldBuild, "Quest 3", "US-CA", "1.2.3.4:6792"}, ClientHealthEventTimeout)
+	r.RecordEvent(ClientHealthKey{oldBuild, "Quest 3", "DE-BE", "5.6.7.8:6792"}, ClientHealthEventConnectionFailed)
+
+	report, err := r.Report(context.Background(), ClientHealthFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Builds) != 3 || report.Builds[0].BuildNumber != rawBuild || report.Builds[1].BuildNumber != newBuild {
+		t.Fatalf("unexpected builds: %+v", report.Builds)
+	}
+	if b := report.Builds[1]; b.Loads != 100 || b.Timeouts != 10 || b.MeanLoadTime != 7.5 {
+		t.Errorf("unexpected build summary: %+v", b)
+	}
+
+	type regression struct {
+		headsetType string
+		metric      string
+	}
+	want := map[regression]bool{
+		{"", "timeout_rate"}:        true, // 0.01 -> 0.10 across all headsets
+		{"Quest 3", "timeout_rate"}: true, // 0.02 -> 0.20
+	}
+	for _, reg := range report.Regressions {
+		k := regression{reg.HeadsetType, reg.Metric}
+		if !want[k] {
+			t.Errorf("unexpected regression: %+v", reg)
+			continue
+		}
+		if reg.BuildNumber != newBuild || reg.PreviousBuildNumber != oldBuild {
+			t.Errorf("unexpected builds compared: %+v", reg)
+		}
+		delete(want, k)
+	}
+	for k := range want {
+		t.Errorf("missing regression: %+v", k)
+	}
+
+	// The filter selects the buckets.
+	if report, err = r.Report(context.Background(), ClientHealthFilter{Region: "DE-BE"}); err != nil {
+		t.Fatal(err)
+	} else if len(report.Builds) != 1 || report.Builds[0].ConnectionFailures != 1 || len(report.Regressions) != 0 {
+		t.Errorf("unexpected filtered report: %s", report)
+	}
+}
+
+func TestMergeClientHealthBuckets(t *testing.T) {
+	key := ClientHealthKey{631547, "Quest 3", "US-CA", "1.2.3.4:6792"}
+	now := time.Now()
+
+	// Another node's buckets are added to this node's, except those past the retention.
+	buckets := map[ClientHealthKey]ClientHealthStats{
+		key: {Loads: 2, Timeouts: 1, UpdatedAt: now.Add(-time.Hour)},
+	}
+	mergeClientHealthBuckets(buckets, []*ClientHealthBucket{
+		{Key: key, Stats: ClientHealthStats{Loads: 3, Timeouts: 2, UpdatedAt: now}},
+		{Key: ClientHealthKey{630783, "Index", "US-CA", ""}, Stats: ClientHealthStats{Loads: 1, UpdatedAt: now.Add(-clientHealthRetention - time.Hour)}},
+	})
+
+	if len(buckets) != 1 {
+		t.Fatalf("expected 1 bucket, got %d", len(buckets))
+	}
+	if s := buckets[key]; s.Loads != 5 || s.Timeouts != 3 || !s.UpdatedAt.Equal(now) {
+		t.Errorf("unexpected merged bucket: %+v", s)
+	}
+}
@@ -0,0 +1,414 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageCollectionConfigResource   = "ConfigResource"
+	StorageCollectionConfigExperiment = "ConfigExperiment"
+
+	// The number of revisions kept for each config resource.
+	configResourceMaxRevisions = 25
+
+	// How long a resolved config resource is served from the cache. Writes on this node clear the cache;
+	// writes on other nodes are served once the entries expire.
+	configResourceCacheTTL = time.Minute
+)
+
+var (
+	ErrConfigResourceInvalid         = errors.New("invalid config resource")
+	ErrConfigResourceRevisionMissing = errors.New("revision not found")
+)
+
+// The resolved config resources by scope key, and the experiments by config type.
+var configResourceCache = NewCache()
+
+var (
+	_ = VersionedStorable(&ConfigResource{})
+	_ = VersionedStorable(&ConfigExperiment{})
+)
+
+// ConfigResourceScope is the set of clients that a config resource is served to. Empty fields match every client.
+type ConfigResourceScope struct {
+	GroupID     string          `json:"group_id,omitempty"`
+	BuildNumber evr.BuildNumber `json:"build_number,omitempty"`
+	Bucket      string          `json:"bucket,omitempty"` // The experiment bucket
+}
+
+func (s ConfigResourceScope) key(configType, id string) string {
+	groupID, build, bucket := "*", "*", "*"
+	if s.GroupID != "" {
+		groupID = s.GroupID
+	}
+	if s.BuildNumber != 0 {
+		build = strconv.FormatInt(int64(s.BuildNumber), 10)
+	}
+	if s.Bucket != "" {
+		bucket = s.Bucket
+	}
+	return fmt.Sprintf("%s:%s:%s:%s:%s", configType, id, groupID, build, bucket)
+}
+
+// fallbacks returns the scopes that match a client in this scope, most specific first. The guild takes
+// precedence over the build, and the build over the experiment bucket.
+func (s ConfigResourceScope) fallbacks() []ConfigResourceScope {
+	groupIDs := []string{s.GroupID}
+	if s.GroupID != "" {
+		groupIDs = append(groupIDs, "")
+	}
+	builds := []evr.BuildNumber{s.BuildNumber}
+	if s.BuildNumber != 0 {
+		builds = append(builds, 0)
+	}
+	buckets := []string{s.Bucket}
+	if s.Bucket != "" {
+		buckets = append(buckets, "")
+	}
+
+	scopes := make([]ConfigResourceScope, 0, len(groupIDs)*len(builds)*len(buckets))
+	for _, groupID := range groupIDs {
+		for _, build := range builds {
+			for _, bucket := range buckets {
+				scopes = append(scopes, ConfigResourceScope{GroupID: groupID, BuildNumber: build, Bucket: bucket})
+			}
+		}
+	}
+	return scopes
+}
+
+// ConfigResourceRevision is a version of a config resource.
+type ConfigResourceRevision struct {
+	Version   int                    `json:"version"`
+	Resource  map[string]interface{} `json:"resource"`
+	CreatedAt time.Time              `json:"created_at"`
+	CreatedBy string                 `json:"created_by"`
+	Notes     string                 `json:"notes,omitempty"`
+}
+
+// ConfigResource is a config resource served to the clients in its scope, with its recent revisions.
+type ConfigResource struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	ConfigResourceScope
+	Revisions []*ConfigResourceRevision `json:"revisions"` // Oldest first; the last is served
+
+	version string
+}
+
+func NewConfigResource(configType, id string, scope ConfigResourceScope) *ConfigResource {
+	return &ConfigResource{
+		Type:                configType,
+		ID:                  id,
+		ConfigResourceScope: scope,
+		Revisions:           make([]*ConfigResourceRevision, 0),
+	}
+}
+
+func (r ConfigResource) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionConfigResource,
+		Key:             r.ConfigResourceScope.key(r.Type, r.ID),
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         r.version,
+	}
+}
+
+func (r *ConfigResource) SetStorageVersion(userID, version string) {
+	r.version = version
+}
+
+// Current returns the revision that is served, or nil if there is none.
+func (r *ConfigResource) Current() *ConfigResourceRevision {
+	if len(r.Revisions) == 0 {
+		return nil
+	}
+	return r.Revisions[len(r.Revisions)-1]
+}
+
+func (r *ConfigResource) Revision(version int) *ConfigResourceRevision {
+	for _, rev := range r.Revisions {
+		if rev.Version == version {
+			return rev
+		}
+	}
+	return nil
+}
+
+// AddRevision appends a new revision of the resource, which becomes the current one.
+func (r *ConfigResource) AddRevision(resource map[string]interface{}, userID, notes string) *ConfigResourceRevision {
+	version := 1
+	if current := r.Current(); current != nil {
+		version = current.Version + 1
+	}
+
+	rev := &ConfigResourceRevision{
+		Version:   version,
+		Resource:  resource,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: userID,
+		Notes:     notes,
+	}
+
+	r.Revisions = append(r.Revisions, rev)
+	if len(r.Revisions) > configResourceMaxRevisions {
+		r.Revisions = r.Revisions[len(r.Revisions)-configResourceMaxRevisions:]
+	}
+	return rev
+}
+
+// ConfigExperiment splits the clients requesting a config type into buckets.
+type ConfigExperiment struct {
+	Type    string   `json:"type"`
+	Buckets []string `json:"buckets"`
+
+	version string
+}
+
+func (e ConfigExperiment) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionConfigExperiment,
+		Key:             e.Type,
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         e.version,
+	}
+}
+
+func (e *ConfigExperiment) SetStorageVersion(userID, version string) {
+	e.version = version
+}
+
+// Bucket returns the user's bucket. Users stay in the same bucket while the buckets are unchanged.
+func (e ConfigExperiment) Bucket(userID string) string {
+	if len(e.Buckets) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(userID + ":" + e.Type))
+	return e.Buckets[h.Sum32()%uint32(len(e.Buckets))]
+}
+
+// configValueKind returns the JSON kind of a decoded value.
+func configValueKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// ValidateConfigResource checks the resource against its type and id, and against the top-level fields of the
+// default resource, if there is one.
+func ValidateConfigResource(configType, id string, resource map[string]interface{}) error {
+	if resource["type"] != configType {
+		return fmt.Errorf("%w: type must be %q", ErrConfigResourceInvalid, configType)
+	}
+	if resource["id"] != id {
+		return fmt.Errorf("%w: id must be %q", ErrConfigResourceInvalid, id)
+	}
+
+	defaultJSON := evr.GetDefaultConfigResource(configType, id)
+	if defaultJSON == "" {
+		return nil
+	}
+	defaultResource := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(defaultJSON), &defaultResource); err != nil {
+		return fmt.Errorf("failed to parse the default resource: %w", err)
+	}
+
+	for k, v := range defaultResource {
+		if k == "_ts" {
+			continue
+		}
+		value, ok := resource[k]
+		if !ok {
+			return fmt.Errorf("%w: missing field %q", ErrConfigResourceInvalid, k)
+		}
+		if want, got := configValueKind(v), configValueKind(value); want != got {
+			return fmt.Errorf("%w: field %q must be a %s, not a %s", ErrConfigResourceInvalid, k, want, got)
+		}
+	}
+	return nil
+}
+
+// ConfigResourceChange is a difference between two revisions, at a dotted path.
+type ConfigResourceChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// ConfigResourceDiff returns the changes from one resource to the other, sorted by path. Objects are compared
+// field by field; any other values are compared whole.
+func ConfigResourceDiff(from, to map[string]interface{}) []ConfigResourceChange {
+	changes := make([]ConfigResourceChange, 0)
+	configResourceDiff("", from, to, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func configResourceDiff(prefix string, from, to map[string]interface{}, changes *[]ConfigResourceChange) {
+	keys := make([]string, 0, len(from)+len(to))
+	for k := range from {
+		keys = append(keys, k)
+	}
+	for k := range to {
+		if _, ok := from[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		a, b := from[k], to[k]
+		aMap, aOK := a.(map[string]interface{})
+		bMap, bOK := b.(map[string]interface{})
+		if aOK && bOK {
+			configResourceDiff(path, aMap, bMap, changes)
+		} else if !reflect.DeepEqual(a, b) {
+			*changes = append(*changes, ConfigResourceChange{Path: path, From: a, To: b})
+		}
+	}
+}
+
+// ConfigResourceLoad returns the resource of the scope, or a new one without revisions if there is none.
+func ConfigResourceLoad(ctx context.Context, nk runtime.NakamaModule, configType, id string, scope ConfigResourceScope) (*ConfigResource, error) {
+	r := NewConfigResource(configType, id, scope)
+	if err := StorageRead(ctx, nk, SystemUserID, r, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		r.version = "*"
+	}
+	return r, nil
+}
+
+// ConfigResourcePublish validates the resource, and stores it as the new revision for the scope.
+func ConfigResourcePublish(ctx context.Context, nk runtime.NakamaModule, userID, configType, id string, scope ConfigResourceScope, resource map[string]interface{}, notes string) (*ConfigResource, error) {
+	if err := ValidateConfigResource(configType, id, resource); err != nil {
+		return nil, err
+	}
+
+	var err error
+	for range 3 {
+		var r *ConfigResource
+		if r, err = ConfigResourceLoad(ctx, nk, configType, id, scope); err != nil {
+			return nil, err
+		}
+		r.AddRevision(resource, userID, notes)
+		if _, err = StorageWrite(ctx, nk, SystemUserID, r); err == nil {
+			configResourceCache.Clear()
+			return r, nil
+		}
+	}
+	return nil, errors.Join(errors.New("failed to publish the config resource"), err)
+}
+
+// ConfigResourceRollback publishes a previous revision of the resource as a new revision.
+func ConfigResourceRollback(ctx context.Context, nk runtime.NakamaModule, userID, configType, id string, scope ConfigResourceScope, version int) (*ConfigResource, error) {
+	r, err := ConfigResourceLoad(ctx, nk, configType, id, scope)
+	if err != nil {
+		return nil, err
+	}
+	rev := r.Revision(version)
+	if rev == nil {
+		return nil, fmt.Errorf("%w: %d", ErrConfigResourceRevisionMissing, version)
+	}
+	return ConfigResourcePublish(ctx, nk, userID, configType, id, scope, rev.Resource, fmt.Sprintf("rollback to version %d", version))
+}
+
+// ConfigExperimentLoad returns the experiment for the config type, or one without buckets if there is none.
+func ConfigExperimentLoad(ctx context.Context, nk runtime.NakamaModule, configType string) (*ConfigExperiment, error) {
+	e := &ConfigExperiment{Type: configType}
+	if err := StorageRead(ctx, nk, SystemUserID, e, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		e.version = "*"
+	}
+	return e, nil
+}
+
+// ConfigExperimentLoadCached returns the experiment for the config type, from the cache if it has been loaded recently.
+func ConfigExperimentLoadCached(ctx context.Context, nk runtime.NakamaModule, configType string) (*ConfigExperiment, error) {
+	cacheKey := "experiment:" + configType
+	if v, _, found := configResourceCache.Get(cacheKey); found {
+		return v.(*ConfigExperiment), nil
+	}
+	e, err := ConfigExperimentLoad(ctx, nk, configType)
+	if err != nil {
+		return nil, err
+	}
+	configResourceCache.Set(cacheKey, e, configResourceCacheTTL)
+	return e, nil
+}
+
+// ConfigResourceResolve returns the current revision of the most specific resource for the client's scope,
+// or nil if none has been published.
+func ConfigResourceResolve(ctx context.Context, nk runtime.NakamaModule, configType, id string, scope ConfigResourceScope) (*ConfigResource, error) {
+	scopes := scope.fallbacks()
+	keys := make([]string, 0, len(scopes))
+	reads := make([]*runtime.StorageRead, 0, len(scopes))
+	for _, s := range scopes {
+		key := s.key(configType, id)
+		keys = append(keys, key)
+		reads = append(reads, &runtime.StorageRead{
+			Collection: StorageCollectionConfigResource,
+			Key:        key,
+			UserID:     SystemUserID,
+		})
+	}
+
+	objs, err := nk.StorageRead(ctx, reads)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read config resources: %v", err)
+	}
+
+	for _, key := range keys {
+		i := slices.IndexFunc(objs, func(obj *api.StorageObject) bool { return obj.GetKey() == key })
+		if i == -1 {
+			continue
+		}
+		r := &ConfigResource{}
+		if err := json.Unmarshal([]byte(objs[i].GetValue()), r); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to unmarshal config resource %s: %v", key, err)
+		}
+		if r.Current() == nil {
+			continue
+		}
+		r.version = objs[i].GetVersion()
+		return r, nil
+	}
+	return nil, nil
+}
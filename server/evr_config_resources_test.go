@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+func TestConfigResourceScopeFallbacks(t *testing.T) {
+	scope := ConfigResourceScope{GroupID: "group", BuildNumber: 631547, Bucket: "b"}
+
+	scopes := scope.fallbacks()
+	if len(scopes) != 8 {
+		t.Fatalf("expected 8 scopes, got %d", len(scopes))
+	}
+	if scopes[0] != scope {
+		t.Errorf("expected the most specific scope first, got %+v", scopes[0])
+	}
+	if scopes[len(scopes)-1] != (ConfigResourceScope{}) {
+		t.Errorf("expected the global scope last, got %+v", scopes[len(scopes)-1])
+	}
+	if got := (ConfigResourceScope{}).key("main_menu", "main_menu"); got != "main_menu:main_menu:*:*:*" {
+		t.Errorf("unexpected global key %s", got)
+	}
+}
+
+func TestValidateConfigResource(t *testing.T) {
+	resource := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(evr.DefaultMainMenuConfigResource), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfigResource("main_menu", "main_menu", resource); err != nil {
+		t.Errorf("expected the default resource to be valid, got %v", err)
+	}
+
+	resource["news"] = "none"
+	if err := ValidateConfigResource("main_menu", "main_menu", resource); !errors.Is(err, ErrConfigResourceInvalid) {
+		t.Errorf("expected a field of the wrong kind to be invalid, got %v", err)
+	}
+	if err := ValidateConfigResource("main_menu", "other", resource); !errors.Is(err, ErrConfigResourceInvalid) {
+		t.Errorf("expected a mismatched id to be invalid, got %v", err)
+	}
+}
+
+func TestConfigResourceRevisionsAndDiff(t *testing.T) {
+	r := NewConfigResource("store", "store", ConfigResourceScope{})
+	r.AddRevision(map[string]interface{}{"a": 1.0, "b": map[string]interface{}{"c": "x"}}, "user", "")
+	r.AddRevision(map[string]interface{}{"a": 1.0, "b": map[string]interface{}{"c": "y"}, "d": true}, "user", "")
+
+	if r.Current().Version != 2 {
+		t.Errorf("expected version 2, got %d", r.Current().Version)
+	}
+
+	changes := ConfigResourceDiff(r.Revision(1).Resource, r.Current().Resource)
+	if len(changes) != 2 || changes[0].Path != "b.c" || changes[1].Path != "d" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+
+	for range configResourceMaxRevisions {
+		r.AddRevision(map[string]interface{}{}, "user", "")
+	}
+	if len(r.Revisions) != configResourceMaxRevisions || r.Revision(1) != nil {
+		t.Errorf("expected the oldest revisions to be dropped")
+	}
+}
@@ -360,6 +360,28 @@ var (
 				},
 			},
 		},
+		{
+			Name:        "match-history",
+			Description: "View your recent matches, or the timeline of a match.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "match-id",
+					Description: "Match ID or Spark link",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Another player (enforcers only)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "appeals",
+			Description: "Review the guild's open suspension appeals (enforcers only).",
+		},
 		{
 			Name:        "throw-settings",
 			Description: "See your throw settings.",
@@ -487,6 +509,19 @@ var (
 					Description: "Suspension duration (e.g. 1m, 2h, 3d, 4w)",
 					Required:    false,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "The restriction applied for the duration (default: suspension)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Suspension", Value: string(EnforcementActionSuspension)},
+						{Name: "Voice Mute", Value: string(EnforcementActionVoiceMute)},
+						{Name: "Spectator Only", Value: string(EnforcementActionSpectatorOnly)},
+						{Name: "Private Matches Only", Value: string(EnforcementActionPrivateOnly)},
+						{Name: "Matchmaking Cooldown", Value: string(EnforcementActionMatchmakingCooldown)},
+					},
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionBoolean,
 					Name:        "require_community_values",
@@ -781,6 +816,34 @@ var (
 				},
 			},
 		},
+		{
+			Name:        "my-servers",
+			Description: "List your game servers, and drain them or restart their parking match.",
+		},
+		{
+			Name:        "client-health",
+			Description: "Show the clients' load times and disconnects by build, and the regressions between builds.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "headset-type",
+					Description: "Only the clients with this headset type.",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "region",
+					Description: "Only the clients in this region (e.g. US-CA).",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "game-server",
+					Description: "Only the clients of this game server (ip:port).",
+					Required:    false,
+				},
+			},
+		},
 		{
 			Name:        "party",
 			Description: "Manage EchoVRCE parties.",
@@ -1260,6 +1323,10 @@ func (d *DiscordAppBot) RegisterSlashCommands() error {
 		},
 
 		"igp":            d.handleInGamePanel,
+		"match-history":  d.handleMatchHistory,
+		"appeals":        d.handleAppeals,
+		"my-servers":     d.handleMyServers,
+		"client-health":  d.handleClientHealth,
 		"link":           d.handleLinkHeadset,
 		"unlink":         d.handleUnlinkHeadset,
 		"link-headset":   d.handleLinkHeadset,
@@ -2172,6 +2239,7 @@ func (d *DiscordAppBot) RegisterSlashCommands() error {
 				suspensionExpiry       time.Time
 				notes                  string
 				requireCommunityValues bool
+				actionType             = EnforcementActionSuspension
 			)
 
 			for _, o := range i.ApplicationCommandData().Options {
@@ -2192,6 +2260,11 @@ func (d *DiscordAppBot) RegisterSlashCommands() error {
 					notes = o.StringValue()
 				case "require_community_values":
 					requireCommunityValues = o.BoolValue()
+				case "action":
+					actionType = EnforcementActionType(o.StringValue())
+					if !slices.Contains(EnforcementActionTypes, actionType) {
+						return fmt.Errorf("invalid action: %s", o.StringValue())
+					}
 				case "suspension_duration":
 					duration := o.StringValue()
 
@@ -2248,10 +2321,10 @@ func (d *DiscordAppBot) RegisterSlashCommands() error {
 				if !suspensionExpiry.IsZero() {
 					remove := false
 					if time.Now().After(suspensionExpiry) {
-						actions = append(actions, "suspension removed")
+						actions = append(actions, fmt.Sprintf("%s removed", actionType.String()))
 						remove = true
 					} else {
-						actions = append(actions, fmt.Sprintf("suspension expires <t:%d:R>", suspensionExpiry.UTC().Unix()))
+						actions = append(actions, fmt.Sprintf("%s expires <t:%d:R>", actionType.String(), suspensionExpiry.UTC().Unix()))
 					}
 
 					guildRecords := NewGuildEnforcementRecords(targetUserID, groupID)
@@ -2259,22 +2332,61 @@ func (d *DiscordAppBot) RegisterSlashCommands() error {
 						return fmt.Errorf("failed to read storage: %w", err)
 					}
 
+					var (
+						created *GuildEnforcementRecord
+						voided  []*GuildEnforcementRecord
+					)
 					if remove {
 						for _, record := range guildRecords.Records {
-							if !record.IsSuspended() {
+							if record.Action() != actionType || !record.IsActive() {
 								continue
 							}
 							record.IsVoid = true
+							voided = append(voided, record)
 						}
 					} else {
-						record := NewGuildEnforcementRecord(userID, userNotice, notes, requireCommunityValues, suspensionExpiry)
-						guildRecords.AddRecord(record)
+						created = NewGuildEnforcementRecord(userID, userNotice, notes, requireCommunityValues, suspensionExpiry)
+						if actionType != EnforcementActionSuspension {
+							created.ActionType = actionType
+						}
+						guildRecords.AddRecord(created)
 					}
 
+					guildRecords.UpdateSummary()
 					if _, err := StorageWrite(ctx, nk, targetUserID, guildRecords); err != nil {
 						return fmt.Errorf("failed to write storage: %w", err)
 					}
 
+					if err := EnforcementRecordsApply(ctx, nk, guildRecords); err != nil {
+						logger.WithField("error", err).Warn("Failed to apply the enforcement records to the player's sessions")
+					}
+
+					for _, record := range voided {
+						if err := EnforcementAuditTrailAppend(ctx, nk, targetUserID, groupID, record.ID, EnforcementAuditEntry{
+							Time:        time.Now().UTC(),
+							ActorUserID: userID,
+							Action:      "record_voided",
+						}); err != nil {
+							logger.WithField("error", err).Warn("Failed to append to the enforcement audit trail")
+						}
+					}
+
+					if created != nil {
+						if err := EnforcementAuditTrailAppend(ctx, nk, targetUserID, groupID, created.ID, EnforcementAuditEntry{
+							Time:             created.CreatedAt,
+							ActorUserID:      userID,
+							Action:           "record_created",
+							Notes:            notes,
+							SuspensionExpiry: created.SuspensionExpiry,
+						}); err != nil {
+							logger.WithField("error", err).Warn("Failed to append to the enforcement audit trail")
+						}
+
+						if err := d.SendSuspensionNotice(ctx, target.ID, groupID, created); err != nil {
+							logger.WithField("error", err).Warn("Failed to send suspension notice")
+						}
+					}
+
 				}
 			}
 
@@ -3207,7 +3319,12 @@ func (d *DiscordAppBot) RegisterSlashCommands() error {
 
 		case discordgo.InteractionModalSubmit:
 
-			switch i.ModalSubmitData().CustomID {
+			modalID, value, _ := strings.Cut(i.ModalSubmitData().CustomID, ":")
+			switch modalID {
+			case "appeal_modal", "appeal_reduce":
+				if err := d.handleAppealModalSubmit(logger, s, i, modalID, value); err != nil {
+					logger.WithField("error", err).Error("Failed to handle appeal")
+				}
 			case "linkcode_modal":
 				data := i.ModalSubmitData()
 				member, err := d.dg.GuildMember(i.GuildID, i.Member.User.ID)
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// The most appeals shown by the appeals command; each is a separate embed.
+const appealsCommandMaxAppeals = 5
+
+// handleAppeals shows the guild's unresolved appeals, with the actions to review them.
+func (d *DiscordAppBot) handleAppeals(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, member *discordgo.Member, userID string, groupID string) error {
+	ctx := d.ctx
+
+	if user == nil {
+		return nil
+	}
+
+	gg := d.guildGroupRegistry.Get(groupID)
+	if gg == nil {
+		return simpleInteractionResponse(s, i, "This command must be used in a guild.")
+	}
+	if !gg.IsEnforcer(userID) {
+		return simpleInteractionResponse(s, i, "You must be a guild enforcer to review appeals.")
+	}
+
+	appeals, err := EnforcementAppealsList(ctx, d.nk, groupID, EnforcementAppealStateOpen, EnforcementAppealStateUnderReview)
+	if err != nil {
+		return fmt.Errorf("failed to list appeals: %w", err)
+	}
+
+	if len(appeals) == 0 {
+		return simpleInteractionResponse(s, i, "There are no appeals to review.")
+	}
+
+	content := fmt.Sprintf("%d appeals to review.", len(appeals))
+	if len(appeals) > appealsCommandMaxAppeals {
+		content += fmt.Sprintf(" Showing the oldest %d.", appealsCommandMaxAppeals)
+		appeals = appeals[:appealsCommandMaxAppeals]
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Content: content,
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Each appeal is a follow-up, so each has its own review actions.
+	for _, appeal := range appeals {
+		embed, components := d.enforcementAppealMessage(appeal)
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Flags:      discordgo.MessageFlagsEphemeral,
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enforcementAppealMessage returns the review embed and components of the appeal, with the player's Discord mention.
+func (d *DiscordAppBot) enforcementAppealMessage(appeal *EnforcementAppeal) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var record *GuildEnforcementRecord
+	records := NewGuildEnforcementRecords(appeal.UserID, appeal.GroupID)
+	if err := StorageRead(d.ctx, d.nk, appeal.UserID, records, false); err == nil {
+		record = records.Record(appeal.RecordID)
+	}
+
+	embed := EnforcementAppealEmbed(appeal, record)
+	if discordID := d.cache.UserIDToDiscordID(appeal.UserID); discordID != "" {
+		embed.Fields[0].Value = fmt.Sprintf("<@%s>", discordID)
+	}
+	return embed, EnforcementAppealReviewComponents(appeal)
+}
+
+// handleAppealButton opens the appeal form from the suspension notice.
+func (d *DiscordAppBot) handleAppealButton(s *discordgo.Session, i *discordgo.InteractionCreate, value string) error {
+	groupID, recordID, ok := strings.Cut(value, ":")
+	if !ok {
+		return errors.New("invalid appeal")
+	}
+	return s.InteractionRespond(i.Interaction, EnforcementAppealModal(groupID, recordID))
+}
+
+// handleAppealReview applies the reviewer's selection to the appeal.
+func (d *DiscordAppBot) handleAppealReview(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, reviewerUserID, value string) error {
+	targetUserID, recordID, ok := strings.Cut(value, ":")
+	if !ok {
+		return errors.New("invalid appeal")
+	}
+
+	data := i.MessageComponentData()
+	if len(data.Values) != 1 {
+		return errors.New("invalid selection")
+	}
+	next := EnforcementAppealState(data.Values[0])
+
+	appeal, err := EnforcementAppealLoad(d.ctx, d.nk, targetUserID, recordID)
+	if err != nil {
+		return fmt.Errorf("failed to load appeal: %w", err)
+	}
+
+	if gg := d.guildGroupRegistry.Get(appeal.GroupID); gg == nil || !gg.IsEnforcer(reviewerUserID) {
+		return simpleInteractionResponse(s, i, "You must be a guild enforcer to review appeals.")
+	}
+
+	if next == EnforcementAppealStateReduced {
+		// The reviewer enters the new length of the suspension.
+		return s.InteractionRespond(i.Interaction, EnforcementAppealReduceModal(targetUserID, recordID))
+	}
+
+	return d.enforcementAppealTransition(logger, s, i, reviewerUserID, targetUserID, recordID, next, "", time.Time{})
+}
+
+// handleAppealModalSubmit handles the appeal form, and the reviewer's reduction form.
+func (d *DiscordAppBot) handleAppealModalSubmit(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, modalID, value string) error {
+	user, _ := getScopedUserMember(i)
+	if user == nil {
+		return errors.New("user is nil")
+	}
+	userID := d.cache.DiscordIDToUserID(user.ID)
+	if userID == "" {
+		return simpleInteractionResponse(s, i, "You do not have an account.")
+	}
+
+	inputs := make(map[string]string)
+	for _, row := range i.ModalSubmitData().Components {
+		if r, ok := row.(*discordgo.ActionsRow); ok {
+			for _, c := range r.Components {
+				if input, ok := c.(*discordgo.TextInput); ok {
+					inputs[input.CustomID] = input.Value
+				}
+			}
+		}
+	}
+
+	switch modalID {
+	case "appeal_modal":
+		groupID, recordID, ok := strings.Cut(value, ":")
+		if !ok {
+			return errors.New("invalid appeal")
+		}
+
+		if _, err := EnforcementAppealFile(d.ctx, d.nk, userID, groupID, recordID, inputs["appeal_reason"]); err != nil {
+			if errors.Is(err, ErrEnforcementAppealExists) {
+				return simpleInteractionResponse(s, i, "You have already appealed this suspension.")
+			}
+			return fmt.Errorf("failed to file appeal: %w", err)
+		}
+
+		if _, err := d.LogAuditMessage(d.ctx, groupID, fmt.Sprintf("<@%s> appealed enforcement record `%s`. Use `/appeals` to review it.", user.ID, recordID), false); err != nil {
+			logger.WithField("error", err).Warn("Failed to send audit message")
+		}
+
+		return simpleInteractionResponse(s, i, "Your appeal has been submitted. The guild's enforcers will review it.")
+
+	case "appeal_reduce":
+		targetUserID, recordID, ok := strings.Cut(value, ":")
+		if !ok {
+			return errors.New("invalid appeal")
+		}
+
+		duration, err := parseSuspensionDuration(inputs["appeal_duration"])
+		if err != nil {
+			return simpleInteractionResponse(s, i, err.Error())
+		}
+
+		appeal, err := EnforcementAppealLoad(d.ctx, d.nk, targetUserID, recordID)
+		if err != nil {
+			return fmt.Errorf("failed to load appeal: %w", err)
+		}
+		if gg := d.guildGroupRegistry.Get(appeal.GroupID); gg == nil || !gg.IsEnforcer(userID) {
+			return simpleInteractionResponse(s, i, "You must be a guild enforcer to review appeals.")
+		}
+
+		return d.enforcementAppealTransition(logger, s, i, userID, targetUserID, recordID, EnforcementAppealStateReduced, inputs["appeal_notes"], time.Now().Add(duration))
+	}
+	return nil
+}
+
+// enforcementAppealTransition applies the transition, updates the review message, and notifies the player.
+func (d *DiscordAppBot) enforcementAppealTransition(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, reviewerUserID, targetUserID, recordID string, next EnforcementAppealState, notes string, reducedExpiry time.Time) error {
+	appeal, err := EnforcementAppealTransition(d.ctx, logger, d.nk, reviewerUserID, targetUserID, recordID, next, notes, reducedExpiry)
+	if err != nil {
+		return simpleInteractionResponse(s, i, fmt.Sprintf("Failed to update the appeal: %s", err.Error()))
+	}
+
+	embed, components := d.enforcementAppealMessage(appeal)
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	}); err != nil {
+		return err
+	}
+
+	reviewerDiscordID := d.cache.UserIDToDiscordID(reviewerUserID)
+	targetDiscordID := d.cache.UserIDToDiscordID(targetUserID)
+
+	if _, err := d.LogAuditMessage(d.ctx, appeal.GroupID, fmt.Sprintf("<@%s> marked the appeal of <@%s> for record `%s` as %s.", reviewerDiscordID, targetDiscordID, recordID, appeal.State.String()), false); err != nil {
+		logger.WithField("error", err).Warn("Failed to send audit message")
+	}
+
+	if appeal.State.IsResolved() && targetDiscordID != "" {
+		message := fmt.Sprintf("Your appeal of suspension `%s` has been %s.", recordID, appeal.State.String())
+		if appeal.State == EnforcementAppealStateReduced {
+			message += fmt.Sprintf(" It now expires <t:%d:R>.", reducedExpiry.UTC().Unix())
+		}
+		if _, err := SendUserMessage(d.ctx, d.dg, targetDiscordID, message); err != nil {
+			logger.WithField("error", err).Warn("Failed to notify the player of the appeal outcome")
+		}
+	}
+	return nil
+}
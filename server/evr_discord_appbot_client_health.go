@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// The most builds shown by the client-health command.
+const clientHealthCommandMaxBuilds = 5
+
+// CanViewClientHealth reports whether the user is a global developer or operator.
+func CanViewClientHealth(ctx context.Context, db *sql.DB, userID string) (bool, error) {
+	for _, group := range []string{GroupGlobalDevelopers, GroupGlobalOperators} {
+		if ok, err := CheckSystemGroupMembership(ctx, db, userID, group); err != nil {
+			return false, err
+		} else if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// handleClientHealth shows the clients' load times and disconnect rates by build, and the regressions between builds.
+func (d *DiscordAppBot) handleClientHealth(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, member *discordgo.Member, userID string, groupID string) error {
+	if user == nil {
+		return nil
+	}
+
+	if ok, err := CanViewClientHealth(d.ctx, d.db, userID); err != nil {
+		return fmt.Errorf("failed to check group membership: %w", err)
+	} else if !ok {
+		return simpleInteractionResponse(s, i, "You must be a global developer or operator to use this command.")
+	}
+
+	filter := ClientHealthFilter{}
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "headset-type":
+			filter.HeadsetType = o.StringValue()
+		case "region":
+			filter.Region = o.StringValue()
+		case "game-server":
+			filter.GameServer = o.StringValue()
+		}
+	}
+
+	report, err := globalClientHealthRegistry.Report(d.ctx, filter)
+	if err != nil {
+		return err
+	}
+	if len(report.Builds) == 0 {
+		return simpleInteractionResponse(s, i, "No client health has been recorded.")
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:  discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{ClientHealthEmbed(report)},
+		},
+	})
+}
+
+func ClientHealthEmbed(report *ClientHealthReport) *discordgo.MessageEmbed {
+	filters := make([]string, 0, 3)
+	for _, f := range [][2]string{{"headset", report.Filter.HeadsetType}, {"region", report.Filter.Region}, {"server", report.Filter.GameServer}} {
+		if f[1] != "" {
+			filters = append(filters, fmt.Sprintf("%s `%s`", f[0], f[1]))
+		}
+	}
+	description := "All clients"
+	if len(filters) > 0 {
+		description = strings.Join(filters, ", ")
+	}
+
+	color := 0x00ff00
+	if len(report.Regressions) > 0 {
+		color = 0xff0000
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Client Health",
+		Description: description,
+		Color:       color,
+		Fields:      make([]*discordgo.MessageEmbedField, 0, clientHealthCommandMaxBuilds+1),
+	}
+
+	for i, b := range report.Builds {
+		if i == clientHealthCommandMaxBuilds {
+			break
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("Build %d", b.BuildNumber),
+			Value: fmt.Sprintf("%d loads, %.1fs mean load (client %.1fs, server %.1fs)\nTimeouts %d (%.1f%%), connection failures %d (%.1f%%), net errors %d (%.1f%%)",
+				b.Loads, b.MeanLoadTime, b.MeanClientLoadTime, b.MeanServerLoadTime,
+				b.Timeouts, b.TimeoutRate*100, b.ConnectionFailures, b.ConnectionFailureRate*100, b.NetGameErrors, b.NetGameErrorRate*100),
+		})
+	}
+
+	if len(report.Regressions) > 0 {
+		lines := make([]string, 0, len(report.Regressions))
+		for _, r := range report.Regressions {
+			headset := "all headsets"
+			if r.HeadsetType != "" {
+				headset = r.HeadsetType
+			}
+			lines = append(lines, fmt.Sprintf("Build %d (%s): `%s` %.3f → %.3f from build %d", r.BuildNumber, headset, r.Metric, r.Previous, r.Current, r.PreviousBuildNumber))
+		}
+		value := strings.Join(lines, "\n")
+		if len(value) > 1024 {
+			value = value[:1020] + "\n…"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Regressions",
+			Value: value,
+		})
+	}
+
+	return embed
+}
@@ -315,6 +315,15 @@ func (d *DiscordAppBot) handleInteractionMessageComponent(logger runtime.Logger,
 		}); err != nil {
 			return fmt.Errorf("failed to respond to interaction: %w", err)
 		}
+
+	case "appeal":
+		return d.handleAppealButton(s, i, value)
+
+	case "appeal_review":
+		return d.handleAppealReview(logger, s, i, userID, value)
+
+	case "gameserver_action":
+		return d.handleGameServerAction(logger, s, i, userID, value)
 	}
 
 	return nil
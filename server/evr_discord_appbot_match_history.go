@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+const matchHistoryEmbedMaxLength = 4000
+
+func (d *DiscordAppBot) handleMatchHistory(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, member *discordgo.Member, userID string, groupID string) error {
+	ctx := d.ctx
+
+	if user == nil {
+		return nil
+	}
+
+	var (
+		matchIDStr   string
+		targetUserID = userID
+	)
+
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "match-id":
+			matchIDStr = MatchUUIDPattern.FindString(strings.ToLower(o.StringValue()))
+			if matchIDStr == "" {
+				return simpleInteractionResponse(s, i, "Invalid match ID.")
+			}
+		case "user":
+			target := o.UserValue(s)
+			if target == nil || target.ID == user.ID {
+				continue
+			}
+			gg := d.guildGroupRegistry.Get(groupID)
+			if gg == nil || !gg.IsEnforcer(userID) {
+				return simpleInteractionResponse(s, i, "You must be a guild enforcer to view another player's match history.")
+			}
+			if targetUserID = d.cache.DiscordIDToUserID(target.ID); targetUserID == "" {
+				return simpleInteractionResponse(s, i, "That player does not have an account.")
+			}
+		}
+	}
+
+	var embed *discordgo.MessageEmbed
+
+	if matchIDStr != "" {
+		matchUUID, err := uuid.FromString(matchIDStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse match ID: %w", err)
+		}
+
+		timeline, err := LoadMatchTimeline(ctx, GlobalMatchDataReader(), matchUUID)
+		if err != nil {
+			return simpleInteractionResponse(s, i, fmt.Sprintf("Unable to load the match: %s", err.Error()))
+		}
+
+		if !CanViewMatchTimeline(ctx, d.db, d.nk, userID, timeline) {
+			return simpleInteractionResponse(s, i, "You do not have permission to view this match.")
+		}
+
+		embed = matchTimelineEmbed(timeline)
+
+	} else {
+		history, err := PlayerMatchHistoryLoad(ctx, d.nk, targetUserID)
+		if err != nil {
+			return fmt.Errorf("failed to load match history: %w", err)
+		}
+
+		embed = matchHistoryEmbed(history)
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:  discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+func matchHistoryEmbed(history *PlayerMatchHistory) *discordgo.MessageEmbed {
+	lines := make([]string, 0, len(history.Matches))
+	for _, m := range history.Matches {
+		line := fmt.Sprintf("<t:%d:R> `%s` %s `%s`", m.StartTime.Unix(), m.Mode.String(), m.Team.String(), strings.Split(m.MatchID, ".")[0])
+		if m.EarlyQuit {
+			line += " (early quit)"
+		}
+		lines = append(lines, line)
+	}
+
+	description := "No recent matches."
+	if len(lines) > 0 {
+		description = truncateEmbedLines(lines, matchHistoryEmbedMaxLength)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Recent Matches",
+		Description: description,
+		Color:       5814783,
+	}
+}
+
+func matchTimelineEmbed(timeline *MatchTimeline) *discordgo.MessageEmbed {
+	lines := make([]string, 0, len(timeline.Events))
+	for _, e := range timeline.Events {
+		lines = append(lines, matchTimelineEventLine(e))
+	}
+
+	players := make([]string, 0, len(timeline.Players))
+	for _, p := range timeline.Players {
+		line := fmt.Sprintf("%s (%s)", EscapeDiscordMarkdown(p.DisplayName), p.Team.String())
+		if p.Goals > 0 {
+			line += fmt.Sprintf(" %d pts", p.Points)
+		}
+		if p.EarlyQuit {
+			line += " **early quit**"
+		}
+		players = append(players, line)
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   "Mode",
+			Value:  fmt.Sprintf("`%s`", timeline.Mode.String()),
+			Inline: true,
+		},
+		{
+			Name:   "Score",
+			Value:  fmt.Sprintf("Blue %d - %d Orange", timeline.BlueScore, timeline.OrangeScore),
+			Inline: true,
+		},
+	}
+	if timeline.PredictedDraw > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Predicted Draw",
+			Value:  fmt.Sprintf("%.0f%%", timeline.PredictedDraw*100),
+			Inline: true,
+		})
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:   "Players",
+		Value:  truncateEmbedLines(players, 1024),
+		Inline: false,
+	})
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Match `%s`", strings.Split(timeline.MatchID, ".")[0]),
+		Description: truncateEmbedLines(lines, matchHistoryEmbedMaxLength),
+		Color:       5814783,
+		Fields:      fields,
+	}
+	return embed
+}
+
+// matchTimelineEventLine formats the event as a single line, prefixed with the round clock when it is known.
+func matchTimelineEventLine(e *MatchTimelineEvent) string {
+	clock := fmt.Sprintf("<t:%d:T>", e.Time.Unix())
+	if e.RoundClock > 0 {
+		secs := int(e.RoundClock)
+		clock = fmt.Sprintf("`%02d:%02d`", secs/60, secs%60)
+	}
+
+	name := fmt.Sprintf("**%s**", EscapeDiscordMarkdown(e.DisplayName))
+	team := ""
+	if e.Team != nil {
+		team = " (" + e.Team.String() + ")"
+	}
+
+	switch e.Type {
+	case MatchTimelineEventStarted:
+		return clock + " Match started"
+	case MatchTimelineEventJoin:
+		return fmt.Sprintf("%s %s joined%s", clock, name, team)
+	case MatchTimelineEventLeave:
+		return fmt.Sprintf("%s %s left%s", clock, name, team)
+	case MatchTimelineEventEarlyQuit:
+		return fmt.Sprintf("%s %s **quit early**%s", clock, name, team)
+	case MatchTimelineEventTeamSwitch:
+		return fmt.Sprintf("%s %s switched from %s to %s", clock, name, e.FromTeam.String(), e.Team.String())
+	case MatchTimelineEventGoal:
+		return fmt.Sprintf("%s %s scored%s: %s +%d [%d-%d]", clock, name, team, e.GoalType, e.Points, e.BlueScore, e.OrangeScore)
+	case MatchTimelineEventRoundOver:
+		return fmt.Sprintf("%s Round over [%d-%d]", clock, e.BlueScore, e.OrangeScore)
+	default:
+		return fmt.Sprintf("%s %s", clock, e.Type)
+	}
+}
+
+// truncateEmbedLines joins the lines, dropping the lines that do not fit.
+func truncateEmbedLines(lines []string, maxLength int) string {
+	if len(lines) == 0 {
+		return "None"
+	}
+	const suffixLength = 32 // Room for the count of the dropped lines
+	var b strings.Builder
+	for i, line := range lines {
+		if b.Len()+len(line)+1 > maxLength-suffixLength {
+			fmt.Fprintf(&b, "... and %d more", len(lines)-i)
+			break
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
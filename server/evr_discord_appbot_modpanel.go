@@ -1,9 +1,13 @@
 package server
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/heroiclabs/nakama-common/runtime"
@@ -137,3 +141,207 @@ func (d *DiscordAppBot) ModPanelMessageEmbed(ctx context.Context, logger runtime
 func EnforcementJournalEmbeds() {
 
 }
+
+// SendSuspensionNotice sends the suspended player a DM with the record, and a button to appeal it.
+func (d *DiscordAppBot) SendSuspensionNotice(ctx context.Context, discordID, groupID string, record *GuildEnforcementRecord) error {
+	channel, err := d.dg.UserChannelCreate(discordID)
+	if err != nil {
+		return err
+	}
+
+	guildName := groupID
+	if gg := d.guildGroupRegistry.Get(groupID); gg != nil {
+		guildName = gg.Name()
+	}
+
+	description := fmt.Sprintf("You have been suspended from **%s**.", EscapeDiscordMarkdown(guildName))
+	if action := record.Action(); action != EnforcementActionSuspension {
+		description = fmt.Sprintf("You have been restricted (%s) in **%s**.", action.String(), EscapeDiscordMarkdown(guildName))
+	}
+
+	_, err = d.dg.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Enforcement Notice",
+				Description: description,
+				Color:       0xff0000,
+				Fields: []*discordgo.MessageEmbedField{
+					{
+						Name:  "Reason",
+						Value: record.SuspensionNotice,
+					},
+					{
+						Name:   "Expires",
+						Value:  fmt.Sprintf("<t:%d:R>", record.SuspensionExpiry.UTC().Unix()),
+						Inline: true,
+					},
+					{
+						Name:   "Record",
+						Value:  fmt.Sprintf("`%s`", record.ID),
+						Inline: true,
+					},
+				},
+			},
+		},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Appeal",
+						Style:    discordgo.SecondaryButton,
+						CustomID: fmt.Sprintf("appeal:%s:%s", groupID, record.ID),
+					},
+				},
+			},
+		},
+	})
+	return err
+}
+
+// EnforcementAppealModal asks the player for the reason for their appeal.
+func EnforcementAppealModal(groupID, recordID string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("appeal_modal:%s:%s", groupID, recordID),
+			Title:    "Appeal Suspension",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "appeal_reason",
+							Label:       "Why should this suspension be reconsidered?",
+							Style:       discordgo.TextInputParagraph,
+							Placeholder: "Explain what happened...",
+							Required:    true,
+							MaxLength:   1000,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// EnforcementAppealReduceModal asks the reviewer for the new length of the suspension.
+func EnforcementAppealReduceModal(userID, recordID string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("appeal_reduce:%s:%s", userID, recordID),
+			Title:    "Reduce Suspension",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "appeal_duration",
+							Label:     "Suspension from now (e.g. 30m, 2h, 1d)",
+							Style:     discordgo.TextInputShort,
+							Required:  true,
+							MaxLength: 8,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "appeal_notes",
+							Label:     "Notes",
+							Style:     discordgo.TextInputParagraph,
+							Required:  false,
+							MaxLength: 1000,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// EnforcementAppealEmbed shows the appeal, and the record it appeals, for review.
+func EnforcementAppealEmbed(appeal *EnforcementAppeal, record *GuildEnforcementRecord) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Appeal of `%s`", appeal.RecordID),
+		Description: appeal.Reason,
+		Color:       0xffa500,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Player",
+				Value:  fmt.Sprintf("<@%s>", appeal.UserID),
+				Inline: true,
+			},
+			{
+				Name:   "State",
+				Value:  appeal.State.String(),
+				Inline: true,
+			},
+			{
+				Name:   "Filed",
+				Value:  fmt.Sprintf("<t:%d:R>", appeal.CreatedAt.Unix()),
+				Inline: true,
+			},
+		},
+	}
+	if record != nil {
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{
+				Name:  "Enforcement",
+				Value: fmt.Sprintf("%s: %s (expires <t:%d:R>)", record.Action().String(), record.SuspensionNotice, record.SuspensionExpiry.UTC().Unix()),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Enforcer Notes",
+				Value: cmp.Or(record.Notes, "None"),
+			},
+		)
+	}
+	return embed
+}
+
+// EnforcementAppealReviewComponents are the actions that the reviewer may take on the appeal.
+func EnforcementAppealReviewComponents(appeal *EnforcementAppeal) []discordgo.MessageComponent {
+	options := make([]discordgo.SelectMenuOption, 0, 4)
+	for _, state := range enforcementAppealTransitions[appeal.State] {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: strings.ToUpper(state.String()[:1]) + state.String()[1:],
+			Value: string(state),
+		})
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    fmt.Sprintf("appeal_review:%s:%s", appeal.UserID, appeal.RecordID),
+					Placeholder: "<review the appeal>",
+					Options:     options,
+				},
+			},
+		},
+	}
+}
+
+// parseSuspensionDuration parses a duration in minutes, hours, days or weeks (e.g. 30m, 2h, 1d, 1w).
+func parseSuspensionDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty duration")
+	}
+	unit := time.Minute
+	switch s[len(s)-1] {
+	case 'm':
+		s = s[:len(s)-1]
+	case 'h':
+		unit, s = time.Hour, s[:len(s)-1]
+	case 'd':
+		unit, s = 24*time.Hour, s[:len(s)-1]
+	case 'w':
+		unit, s = 7*24*time.Hour, s[:len(s)-1]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return time.Duration(n) * unit, nil
+}
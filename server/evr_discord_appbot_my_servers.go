@@ -0,0 +1,197 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// The most servers shown by the my-servers command; each is a separate embed.
+const myServersCommandMaxServers = 10
+
+// handleMyServers shows the caller's game servers, with the actions to drain them or restart their parking match.
+func (d *DiscordAppBot) handleMyServers(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, member *discordgo.Member, userID string, groupID string) error {
+	if user == nil {
+		return nil
+	}
+
+	entries, err := GameServerDashboardList(d.ctx, d.nk, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list game servers: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return simpleInteractionResponse(s, i, "You have no game servers connected.")
+	}
+
+	content := fmt.Sprintf("%d game servers connected.", len(entries))
+	if len(entries) > myServersCommandMaxServers {
+		content += fmt.Sprintf(" Showing the first %d.", myServersCommandMaxServers)
+		entries = entries[:myServersCommandMaxServers]
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Content: content,
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Each server is a follow-up, so each has its own actions.
+	for _, entry := range entries {
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Flags:      discordgo.MessageFlagsEphemeral,
+			Embeds:     []*discordgo.MessageEmbed{GameServerDashboardEmbed(entry)},
+			Components: GameServerDashboardComponents(entry),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GameServerDashboardEmbed(entry *GameServerDashboardEntry) *discordgo.MessageEmbed {
+	h := entry.Health
+
+	match := "Parked"
+	if !entry.IsParked() {
+		match = fmt.Sprintf("%s on %s (%s), %d/%d players", entry.Match.Mode.String(), entry.Match.Level.String(), entry.Match.LobbyType.String(), entry.Match.PlayerCount, entry.Match.PlayerLimit)
+	}
+
+	uptime := "unknown"
+	if !h.ConnectedAt.IsZero() {
+		uptime = fmt.Sprintf("<t:%d:R>", h.ConnectedAt.Unix())
+	}
+
+	color := 0x00ff00
+	health := fmt.Sprintf("%.2f (loss %.0f%%, jitter %.0fms, %d samples)", h.Score, h.Loss*100, h.JitterMs, h.Samples)
+	switch {
+	case h.Quarantined:
+		color = 0xff0000
+		health += fmt.Sprintf("\nQuarantined <t:%d:R>", h.QuarantinedAt.Unix())
+	case entry.Draining:
+		color = 0xffa500
+		health += "\nDraining"
+	}
+
+	failures := "None"
+	if len(h.Failures) > 0 {
+		lines := make([]string, 0, len(h.Failures))
+		for _, f := range h.Failures {
+			lines = append(lines, fmt.Sprintf("<t:%d:R> %s", f.Time.Unix(), f.Event))
+		}
+		failures = strings.Join(lines, "\n")
+	}
+
+	region := strings.Join(entry.RegionCodes, ", ")
+	if entry.Region != "" {
+		region = entry.Region + "\n" + region
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       entry.Endpoint,
+		Description: fmt.Sprintf("Server ID `%d`", entry.ServerID),
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Region", Value: region, Inline: true},
+			{Name: "Version Lock", Value: entry.VersionLock, Inline: true},
+			{Name: "Connected", Value: uptime, Inline: true},
+			{Name: "Match", Value: match, Inline: false},
+			{Name: "Health", Value: health, Inline: true},
+			{Name: "Player Minutes", Value: fmt.Sprintf("%.0f", h.PlayerMinutes), Inline: true},
+			{Name: "Recent Failures", Value: failures, Inline: false},
+		},
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// GameServerDashboardComponents returns the actions of the server. The custom IDs are `gameserver_action:<action>:<session_id>`.
+func GameServerDashboardComponents(entry *GameServerDashboardEntry) []discordgo.MessageComponent {
+	drain := discordgo.Button{
+		Label:    "Drain",
+		Style:    discordgo.DangerButton,
+		CustomID: fmt.Sprintf("gameserver_action:%s:%s", GameServerActionDrain, entry.SessionID),
+	}
+	if entry.Draining {
+		drain = discordgo.Button{
+			Label:    "Undrain",
+			Style:    discordgo.SuccessButton,
+			CustomID: fmt.Sprintf("gameserver_action:%s:%s", GameServerActionUndrain, entry.SessionID),
+		}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				drain,
+				discordgo.Button{
+					Label:    "Restart Parking",
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("gameserver_action:%s:%s", GameServerActionRestartParking, entry.SessionID),
+					Disabled: !entry.IsParked(),
+				},
+			},
+		},
+	}
+}
+
+// handleGameServerAction applies an action from the my-servers command, if the user is the server's operator.
+func (d *DiscordAppBot) handleGameServerAction(logger runtime.Logger, s *discordgo.Session, i *discordgo.InteractionCreate, userID, value string) error {
+	actionStr, sessionIDStr, ok := strings.Cut(value, ":")
+	if !ok {
+		return errors.New("invalid game server action")
+	}
+	action := GameServerAction(actionStr)
+	sessionID := uuid.FromStringOrNil(sessionIDStr)
+
+	_, presence, err := GameServerBySessionID(d.nk, sessionID)
+	if err != nil {
+		if errors.Is(err, ErrGameServerPresenceNotFound) {
+			return simpleInteractionResponse(s, i, "This game server is no longer connected.")
+		}
+		return fmt.Errorf("failed to get game server: %w", err)
+	}
+
+	if presence.GetUserId() != userID {
+		if ok, err := CheckSystemGroupMembership(d.ctx, d.db, userID, GroupGlobalOperators); err != nil {
+			return errors.New("failed to check group membership")
+		} else if !ok {
+			return simpleInteractionResponse(s, i, "You are not the operator of this game server.")
+		}
+	}
+
+	if err := GameServerActionApply(d.ctx, d.nk, sessionID, action); err != nil {
+		switch {
+		case errors.Is(err, ErrGameServerPresenceNotFound):
+			return simpleInteractionResponse(s, i, "This game server is no longer connected.")
+		case errors.Is(err, ErrGameServerInMatch):
+			return simpleInteractionResponse(s, i, "This game server is hosting a match.")
+		case errors.Is(err, ErrGameServerDraining):
+			return simpleInteractionResponse(s, i, "This game server is draining.")
+		}
+		return fmt.Errorf("failed to apply game server action: %w", err)
+	}
+
+	logger.WithFields(map[string]any{
+		"operator_id": presence.GetUserId(),
+		"sid":         sessionID.String(),
+		"action":      action,
+	}).Info("Applied game server action")
+
+	switch action {
+	case GameServerActionDrain:
+		return simpleInteractionResponse(s, i, "The game server will finish its current match, and then disconnect.")
+	case GameServerActionUndrain:
+		return simpleInteractionResponse(s, i, "The game server will be allocated new matches.")
+	default:
+		return simpleInteractionResponse(s, i, "The game server's parking match has been restarted.")
+	}
+}
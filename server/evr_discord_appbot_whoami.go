@@ -411,9 +411,12 @@ func (d *DiscordAppBot) handleProfileRequest(ctx context.Context, logger runtime
 					for _, records := range byUserID {
 						for _, r := range records.Records {
 							s += fmt.Sprintf("- <t:%d:R>:  %s", r.CreatedAt.UTC().Unix(), r.SuspensionNotice)
+							if action := r.Action(); action != EnforcementActionSuspension {
+								s += fmt.Sprintf(" (%s)", action.String())
+							}
 							if r.IsVoid {
 								s += " (voided)"
-							} else if r.IsSuspended() {
+							} else if r.IsActive() {
 								s += fmt.Sprintf("  [expires <t:%d:R>]", r.SuspensionExpiry.UTC().Unix())
 							}
 						}
@@ -468,6 +471,32 @@ func (d *DiscordAppBot) handleProfileRequest(ctx context.Context, logger runtime
 		})
 	}
 
+	if includePriviledged {
+		if statuses, err := EarlyQuitPenaltyStatuses(ctx, nk, userID.String()); err != nil {
+			logger.Warn("failed to load early quit statistics", "error", err)
+		} else {
+			lines := make([]string, 0, len(statuses))
+			for _, st := range statuses {
+				if st.EarlyQuits == 0 && !st.IsLockedOut(time.Now()) {
+					continue
+				}
+				line := fmt.Sprintf("%s: level %d (%d early quits, %d completed)", st.Mode.String(), st.Level, st.EarlyQuits, st.CompletedMatches)
+				if st.IsLockedOut(time.Now()) {
+					line += fmt.Sprintf(", matchmaking restricted until <t:%d:R>", st.LockoutExpiry.Unix())
+				}
+				if st.QuitterPool {
+					line += ", early quitter pool"
+				}
+				lines = append(lines, line)
+			}
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "Early Quit Penalty",
+				Value:  strings.Join(lines, "\n"),
+				Inline: false,
+			})
+		}
+	}
+
 	// Remove any blank fields, and truncate to 800 characters
 	fields = lo.Filter(fields, func(f *discordgo.MessageEmbedField, _ int) bool {
 		if f == nil || f.Name == "" || f.Value == "" {
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DocumentTypeEULA            = "eula"
+	DocumentTypeCommunityValues = "community_values"
+	DocumentTypePrivacyPolicy   = "privacy_policy"
+	DocumentTypePatchNotes      = "patch_notes"
+
+	// The language used when a document has not been published in the requested language.
+	DocumentDefaultLanguage = "en"
+)
+
+var (
+	// The documents served from the registry. The EULA is generated separately.
+	DocumentTypes = []string{
+		DocumentTypeCommunityValues,
+		DocumentTypePrivacyPolicy,
+		DocumentTypePatchNotes,
+	}
+
+	// The client profile keys that the client sets to the version of the document once it has been read.
+	documentMarkAsReadProfileKeys = map[string]string{
+		DocumentTypeCommunityValues: "legal|community_values_version",
+		DocumentTypePrivacyPolicy:   "legal|privacy_policy_version",
+		DocumentTypePatchNotes:      "legal|patch_notes_version",
+	}
+)
+
+var _ = VersionedStorable(&RegistryDocument{})
+
+// RegistryDocument is a published document, in one language. Guilds may publish their own community values,
+// which take precedence over the global document.
+type RegistryDocument struct {
+	Type        string    `json:"type"`
+	Language    string    `json:"lang"`
+	GroupID     string    `json:"group_id,omitempty"`
+	Version     int64     `json:"version"` // The time the document was published, in seconds
+	Text        string    `json:"text"`
+	Link        string    `json:"link,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	PublisherID string    `json:"publisher_id,omitempty"`
+
+	version string
+}
+
+func documentStorageKey(docType, language, groupID string) string {
+	if groupID == "" {
+		return docType + "," + language
+	}
+	return docType + "," + language + "," + groupID
+}
+
+// documentStorageKeys returns the keys of the document, in the order they are preferred: the guild's document
+// before the global one, and the requested language before the default language.
+func documentStorageKeys(docType, language, groupID string) []string {
+	languages := []string{language}
+	if language != DocumentDefaultLanguage {
+		languages = append(languages, DocumentDefaultLanguage)
+	}
+
+	groupIDs := []string{""}
+	if groupID != "" {
+		groupIDs = []string{groupID, ""}
+	}
+
+	keys := make([]string, 0, len(languages)*len(groupIDs))
+	for _, g := range groupIDs {
+		for _, l := range languages {
+			keys = append(keys, documentStorageKey(docType, l, g))
+		}
+	}
+	return keys
+}
+
+func (d RegistryDocument) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      DocumentStorageCollection,
+		Key:             documentStorageKey(d.Type, d.Language, d.GroupID),
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         d.version,
+	}
+}
+
+func (d *RegistryDocument) SetStorageVersion(userID, version string) {
+	d.version = version
+}
+
+func (d RegistryDocument) EvrDocument() evr.TextDocument {
+	return evr.TextDocument{
+		Type:                 d.Type,
+		Lang:                 d.Language,
+		Version:              d.Version,
+		Text:                 d.Text,
+		MarkAsReadProfileKey: documentMarkAsReadProfileKeys[d.Type],
+		Link:                 d.Link,
+	}
+}
+
+// DocumentLoad returns the most specific published document for the language and guild.
+func DocumentLoad(ctx context.Context, nk runtime.NakamaModule, docType, language, groupID string) (*RegistryDocument, error) {
+	keys := documentStorageKeys(docType, language, groupID)
+
+	reads := make([]*runtime.StorageRead, 0, len(keys))
+	for _, key := range keys {
+		reads = append(reads, &runtime.StorageRead{
+			Collection: DocumentStorageCollection,
+			Key:        key,
+			UserID:     SystemUserID,
+		})
+	}
+
+	objs, err := nk.StorageRead(ctx, reads)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read documents: %v", err)
+	}
+
+	for _, key := range keys {
+		i := slices.IndexFunc(objs, func(obj *api.StorageObject) bool { return obj.GetKey() == key })
+		if i == -1 {
+			continue
+		}
+		document := &RegistryDocument{}
+		if err := json.Unmarshal([]byte(objs[i].GetValue()), document); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to unmarshal document %s: %v", key, err)
+		}
+		document.version = objs[i].GetVersion()
+		return document, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "document not found: %s", documentStorageKey(docType, language, groupID))
+}
+
+// DocumentPublish stores a new version of the document. The version is always greater than the previous one.
+func DocumentPublish(ctx context.Context, nk runtime.NakamaModule, publisherID string, document *RegistryDocument) error {
+	previous := &RegistryDocument{Type: document.Type, Language: document.Language, GroupID: document.GroupID}
+	if err := StorageRead(ctx, nk, SystemUserID, previous, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+		previous.version = "*"
+	}
+
+	now := time.Now().UTC()
+	document.Version = max(now.Unix(), previous.Version+1)
+	document.PublishedAt = now
+	document.PublisherID = publisherID
+	document.version = previous.version
+
+	_, err := StorageWrite(ctx, nk, SystemUserID, document)
+	return err
+}
+
+// CommunityValuesVersion returns the version of the guild's community values, or 0 if none have been published.
+func CommunityValuesVersion(ctx context.Context, nk runtime.NakamaModule, groupID string) (int64, error) {
+	document, err := DocumentLoad(ctx, nk, DocumentTypeCommunityValues, DocumentDefaultLanguage, groupID)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return document.Version, nil
+}
+
+// CommunityValuesRequired returns true if the guild's community values have been published since the player
+// last accepted them in the guild.
+func CommunityValuesRequired(ctx context.Context, nk runtime.NakamaModule, accepted map[string]int64, groupID string) (bool, error) {
+	version, err := CommunityValuesVersion(ctx, nk, groupID)
+	if err != nil {
+		return false, err
+	}
+	return version > accepted[groupID], nil
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDocumentStorageKeys(t *testing.T) {
+	tests := []struct {
+		language string
+		groupID  string
+		want     []string
+	}{
+		{"en", "", []string{"community_values,en"}},
+		{"de", "", []string{"community_values,de", "community_values,en"}},
+		{"de", "group", []string{"community_values,de,group", "community_values,en,group", "community_values,de", "community_values,en"}},
+	}
+	for _, tt := range tests {
+		if got := documentStorageKeys(DocumentTypeCommunityValues, tt.language, tt.groupID); !slices.Equal(got, tt.want) {
+			t.Errorf("%s/%s: expected %v, got %v", tt.language, tt.groupID, tt.want, got)
+		}
+	}
+}
@@ -1,10 +1,21 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/heroiclabs/nakama-common/runtime"
 	"github.com/heroiclabs/nakama/v3/server/evr"
-	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageCollectionEarlyQuit = "EarlyQuit"
+	StorageKeyEarlyQuit        = "statistics"
 )
 
 var (
@@ -14,67 +25,238 @@ var (
 	}
 )
 
+var _ = VersionedStorable(&EarlyQuitStatistics{})
+
+// EarlyQuitStatistics is the player's recent match history in each mode.
 type EarlyQuitStatistics struct {
-	PenaltyExpiry int64          `json:"penalty_expiry,omitempty"`
-	History       map[int64]bool `json:"history,omitempty"`
+	PenaltyExpiry int64                           `json:"penalty_expiry,omitempty"`
+	Modes         map[evr.Symbol][]EarlyQuitEvent `json:"modes,omitempty"`
+	version       string
+}
+
+// EarlyQuitEvent is a match that the player either completed or quit early.
+type EarlyQuitEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	EarlyQuit bool      `json:"early_quit,omitempty"`
+}
+
+type EarlyQuitPenaltyStatus struct {
+	Mode                 evr.Symbol `json:"mode"`
+	Level                int        `json:"level"`
+	EarlyQuits           int        `json:"early_quits"`
+	CompletedMatches     int        `json:"completed_matches"`
+	UnforgivenEarlyQuits int        `json:"unforgiven_early_quits"`
+	NextLevelEarlyQuits  int        `json:"next_level_early_quits,omitempty"` // The unforgiven early quits that reach the next level
+	LockoutExpiry        time.Time  `json:"lockout_expiry,omitempty"`
+	QuitterPool          bool       `json:"quitter_pool"`
 }
 
-func (s *EarlyQuitStatistics) IncrementEarlyQuits() {
-	if s.History == nil {
-		s.History = make(map[int64]bool)
+func (s EarlyQuitPenaltyStatus) IsLockedOut(now time.Time) bool {
+	return s.LockoutExpiry.After(now)
+}
+
+func (s EarlyQuitStatistics) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionEarlyQuit,
+		Key:             StorageKeyEarlyQuit,
+		PermissionRead:  runtime.STORAGE_PERMISSION_OWNER_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         s.version,
 	}
-	s.History[time.Now().Unix()] = true
 }
 
-func (s *EarlyQuitStatistics) IncrementCompletedMatches() {
-	if s.History == nil {
-		s.History = make(map[int64]bool)
+func (s *EarlyQuitStatistics) SetStorageVersion(userID, version string) {
+	s.version = version
+}
+
+// record inserts the event into the mode's history, which is kept in chronological order.
+func (s *EarlyQuitStatistics) record(mode evr.Symbol, ts time.Time, earlyQuit bool) {
+	if s.Modes == nil {
+		s.Modes = make(map[evr.Symbol][]EarlyQuitEvent)
 	}
-	s.History[time.Now().Unix()] = false
+	events := s.Modes[mode]
+	i := len(events)
+	for i > 0 && events[i-1].Timestamp.After(ts) {
+		i--
+	}
+	s.Modes[mode] = slices.Insert(events, i, EarlyQuitEvent{Timestamp: ts, EarlyQuit: earlyQuit})
 }
 
-func (s *EarlyQuitStatistics) ApplyEarlyQuitPenalty(logger *zap.Logger, userID string, label *MatchLabel, playerStats evr.PlayerStatistics, penaltyPercent float64) {
-	// This will apply a penalty to the player's statistics
-	// The penalty is a float64 value 0 and 1.0 that will adjust the players ratio by that amount
-	// A penalty of 0.1 will adjust the player's win/loss ratio by -10%
+// Prune removes the mode's history outside of the window, keeping at most maxEntries of the most recent matches.
+func (s *EarlyQuitStatistics) Prune(mode evr.Symbol, window time.Duration, maxEntries int, now time.Time) {
+	cutoff := now.Add(-window)
+	events := slices.DeleteFunc(s.Modes[mode], func(e EarlyQuitEvent) bool {
+		return e.Timestamp.Before(cutoff)
+	})
 
-	// The following statistics are affected by the penalty:
-	/*
-		ArenaWinPercentage
-		ArenaWins
-		ArenaLosses
-		HighestArenaWinStreak
-	*/
+	if maxEntries > 0 && len(events) > maxEntries {
+		events = slices.Delete(events, 0, len(events)-maxEntries)
+	}
 
-	if penaltyPercent <= 0 || penaltyPercent > 1.0 {
+	if len(events) == 0 {
+		delete(s.Modes, mode)
 		return
 	}
-	if label.Mode != evr.ModeArenaPublic {
-		// Only apply the penalty to Arena matches
-		return
+	s.Modes[mode] = events
+}
+
+// Counts returns the number of early quits and completed matches in the mode since the cutoff.
+func (s *EarlyQuitStatistics) Counts(mode evr.Symbol, since time.Time) (earlyQuits int, completed int) {
+	for _, e := range s.Modes[mode] {
+		if e.Timestamp.Before(since) {
+			continue
+		}
+		if e.EarlyQuit {
+			earlyQuits++
+		} else {
+			completed++
+		}
 	}
+	return earlyQuits, completed
+}
 
-	if userID == label.GameServer.OperatorID.String() {
-		// The broadcaster is not penalized
-		return
+// UnforgivenEarlyQuits returns the early quits in the mode within the window that have not been forgiven by completed matches.
+func (s *EarlyQuitStatistics) UnforgivenEarlyQuits(policy EarlyQuitPolicy, mode evr.Symbol, now time.Time) int {
+	earlyQuits, completed := s.Counts(mode, now.Add(-policy.Window()))
+	if policy.CompletedMatchesPerForgiveness > 0 {
+		earlyQuits -= completed / policy.CompletedMatchesPerForgiveness
 	}
+	return max(earlyQuits, 0)
+}
 
-	gameClock := label.GameState.RoundClock.Current()
-	roundDuration := label.GameState.RoundClock.Duration
+// PenaltyLevel returns the highest tier of the mode's policy reached by the unforgiven early quits, or zero for none.
+func (s *EarlyQuitStatistics) PenaltyLevel(policy EarlyQuitPolicy, mode evr.Symbol, now time.Time) int {
+	modePolicy := policy.Modes[mode]
+	unforgiven := s.UnforgivenEarlyQuits(policy, mode, now)
+	level := 0
+	for i, t := range modePolicy.Tiers {
+		if unforgiven >= t.MinEarlyQuits {
+			level = i + 1
+		}
+	}
+	return level
+}
 
-	if gameClock == 0 || roundDuration == 0 {
-		// The game has not started yet. No penalty is applied.
+// ApplyEarlyQuitPenalty records an early quit in the mode, and extends the lockout by the penalty of the tier it reaches.
+func (s *EarlyQuitStatistics) ApplyEarlyQuitPenalty(policy EarlyQuitPolicy, mode evr.Symbol, remainingTime time.Duration, now time.Time) {
+	s.record(mode, now, true)
+	s.Prune(mode, policy.Window(), policy.MaxHistory, now)
+
+	tier := policy.Modes[mode].Tier(s.PenaltyLevel(policy, mode, now))
+	if tier == nil {
 		return
 	}
 
-	remainingTime := label.GameState.RoundClock.Remaining()
+	penalty := time.Duration(tier.LockoutSecs) * time.Second
 	if remainingTime > 0 {
-		// The game is still in progress. Set a penalty timer for double the length of the game time remaining.
-		expiry := time.Now().Add(remainingTime * 2)
-		s.PenaltyExpiry = expiry.UTC().Unix()
-	} else {
-		// The game is over. No penalty is applied.
-		s.PenaltyExpiry = 0
+		// The game is still in progress. Add a multiple of the time remaining.
+		penalty += time.Duration(float64(remainingTime) * tier.RemainingTimeMultiplier)
+	}
+
+	if expiry := now.Add(penalty).UTC().Unix(); penalty > 0 && expiry > s.PenaltyExpiry {
+		s.PenaltyExpiry = expiry
+	}
+}
+
+// RecordCompletedMatch records a completed match in the mode, which forgives early quits. An active lockout is not shortened.
+func (s *EarlyQuitStatistics) RecordCompletedMatch(policy EarlyQuitPolicy, mode evr.Symbol, now time.Time) {
+	s.record(mode, now, false)
+	s.Prune(mode, policy.Window(), policy.MaxHistory, now)
+}
+
+func (s *EarlyQuitStatistics) Status(policy EarlyQuitPolicy, mode evr.Symbol, now time.Time) EarlyQuitPenaltyStatus {
+	modePolicy := policy.Modes[mode]
+
+	earlyQuits, completed := s.Counts(mode, now.Add(-policy.Window()))
+	status := EarlyQuitPenaltyStatus{
+		Mode:                 mode,
+		Level:                s.PenaltyLevel(policy, mode, now),
+		EarlyQuits:           earlyQuits,
+		CompletedMatches:     completed,
+		UnforgivenEarlyQuits: s.UnforgivenEarlyQuits(policy, mode, now),
+	}
+
+	if status.Level < len(modePolicy.Tiers) {
+		status.NextLevelEarlyQuits = modePolicy.Tiers[status.Level].MinEarlyQuits
+	}
+
+	if tier := modePolicy.Tier(status.Level); tier != nil {
+		status.QuitterPool = tier.QuitterPool
 	}
 
+	if s.PenaltyExpiry > now.Unix() {
+		status.LockoutExpiry = time.Unix(s.PenaltyExpiry, 0).UTC()
+	}
+
+	return status
+}
+
+// earlyQuitModePolicy returns the current policy, and the mode's policy if early quits are penalized in the mode.
+func earlyQuitModePolicy(mode evr.Symbol) (EarlyQuitPolicy, EarlyQuitModePolicy, bool) {
+	settings := ServiceSettings()
+	if settings == nil {
+		return EarlyQuitPolicy{}, EarlyQuitModePolicy{}, false
+	}
+	policy := settings.Matchmaking.EarlyQuitPolicy
+	modePolicy, ok := policy.Modes[mode]
+	return policy, modePolicy, ok
+}
+
+func EarlyQuitStatisticsLoad(ctx context.Context, nk runtime.NakamaModule, userID string) (*EarlyQuitStatistics, error) {
+	statistics := &EarlyQuitStatistics{}
+	if err := StorageRead(ctx, nk, userID, statistics, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		statistics.version = "*" // Only create the statistics if they do not exist.
+	}
+	return statistics, nil
+}
+
+// EarlyQuitStatisticsUpdate applies the update to the player's statistics, retrying when they were modified concurrently.
+func EarlyQuitStatisticsUpdate(ctx context.Context, nk runtime.NakamaModule, userID string, updateFn func(s *EarlyQuitStatistics)) error {
+	var err error
+	for range 3 {
+		var statistics *EarlyQuitStatistics
+		if statistics, err = EarlyQuitStatisticsLoad(ctx, nk, userID); err != nil {
+			return err
+		}
+
+		updateFn(statistics)
+
+		var op *runtime.StorageWrite
+		if op, err = StorageWriteOp(userID, statistics); err != nil {
+			return err
+		}
+		if _, err = nk.StorageWrite(ctx, []*runtime.StorageWrite{op}); err == nil {
+			return nil
+		} else if !errors.Is(err, runtime.ErrStorageRejectedVersion) {
+			return err
+		}
+	}
+	return errors.Join(errors.New("failed to update the early quit statistics"), err)
+}
+
+// EarlyQuitPenaltyStatuses returns the player's penalty status in each mode that early quits are penalized in.
+func EarlyQuitPenaltyStatuses(ctx context.Context, nk runtime.NakamaModule, userID string) ([]EarlyQuitPenaltyStatus, error) {
+	settings := ServiceSettings()
+	if settings == nil {
+		return nil, nil
+	}
+	policy := settings.Matchmaking.EarlyQuitPolicy
+
+	statistics, err := EarlyQuitStatisticsLoad(ctx, nk, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	statuses := make([]EarlyQuitPenaltyStatus, 0, len(policy.Modes))
+	for mode := range policy.Modes {
+		statuses = append(statuses, statistics.Status(policy, mode, now))
+	}
+	slices.SortFunc(statuses, func(a, b EarlyQuitPenaltyStatus) int {
+		return strings.Compare(a.Mode.String(), b.Mode.String())
+	})
+	return statuses, nil
 }
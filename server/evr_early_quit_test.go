@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+func TestEarlyQuitStatisticsPenalty(t *testing.T) {
+	data := &ServiceSettingsData{}
+	FixDefaultServiceSettings(data)
+	policy := data.Matchmaking.EarlyQuitPolicy
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &EarlyQuitStatistics{}
+
+	// The first tier only penalizes for the remaining round time.
+	s.ApplyEarlyQuitPenalty(policy, evr.ModeArenaPublic, time.Minute, now)
+	if got := s.Status(policy, evr.ModeArenaPublic, now); got.Level != 1 || got.QuitterPool || !got.LockoutExpiry.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("unexpected status after one early quit: %+v", got)
+	}
+
+	// Escalate to the quitter pool.
+	for i := 1; i <= 2; i++ {
+		s.ApplyEarlyQuitPenalty(policy, evr.ModeArenaPublic, 0, now.Add(time.Duration(i)*time.Minute))
+	}
+	now = now.Add(2 * time.Minute)
+	if got := s.Status(policy, evr.ModeArenaPublic, now); got.Level != 2 || !got.QuitterPool || !got.IsLockedOut(now) {
+		t.Fatalf("unexpected status after three early quits: %+v", got)
+	}
+
+	// Completed matches forgive early quits.
+	for i := 1; i <= 2; i++ {
+		s.RecordCompletedMatch(policy, evr.ModeArenaPublic, now.Add(time.Duration(i)*time.Hour))
+	}
+	now = now.Add(2 * time.Hour)
+	if got := s.Status(policy, evr.ModeArenaPublic, now); got.Level != 1 || got.UnforgivenEarlyQuits != 2 || got.IsLockedOut(now) {
+		t.Fatalf("unexpected status after completed matches: %+v", got)
+	}
+
+	// Early quits decay out of the window.
+	now = now.Add(policy.Window() + time.Second)
+	if got := s.Status(policy, evr.ModeArenaPublic, now); got.Level != 0 || got.EarlyQuits != 0 {
+		t.Fatalf("unexpected status after the window: %+v", got)
+	}
+
+	s.RecordCompletedMatch(policy, evr.ModeArenaPublic, now)
+	if n := len(s.Modes[evr.ModeArenaPublic]); n != 1 {
+		t.Errorf("expected the history to be pruned, got %d entries", n)
+	}
+}
+
+func TestEarlyQuitStatisticsModes(t *testing.T) {
+	data := &ServiceSettingsData{}
+	FixDefaultServiceSettings(data)
+	policy := data.Matchmaking.EarlyQuitPolicy
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &EarlyQuitStatistics{}
+
+	// Events in the same second, and in other modes, are all kept.
+	s.ApplyEarlyQuitPenalty(policy, evr.ModeArenaPublic, 0, now.Add(-time.Hour))
+	s.ApplyEarlyQuitPenalty(policy, evr.ModeArenaPublic, 0, now)
+	s.ApplyEarlyQuitPenalty(policy, evr.ModeArenaPublic, 0, now)
+	s.RecordCompletedMatch(policy, evr.ModeCombatPublic, now)
+
+	if got := s.Status(policy, evr.ModeArenaPublic, now); got.EarlyQuits != 3 || got.CompletedMatches != 0 || got.Level != 2 {
+		t.Errorf("unexpected arena status: %+v", got)
+	}
+	if got := s.Status(policy, evr.ModeCombatPublic, now); got.EarlyQuits != 0 || got.CompletedMatches != 1 {
+		t.Errorf("unexpected combat status: %+v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrGameServerInMatch       = errors.New("game server is hosting a match")
+	ErrGameServerInvalidAction = errors.New("invalid game server action")
+	ErrGameServerDraining      = errors.New("game server is draining")
+)
+
+type GameServerAction string
+
+const (
+	GameServerActionDrain          GameServerAction = "drain"           // Finish the current match, and then disconnect
+	GameServerActionUndrain        GameServerAction = "undrain"         // Resume allocating the server, if it is still hosting its match
+	GameServerActionRestartParking GameServerAction = "restart_parking" // Replace the server's parking match
+)
+
+// GameServerDashboardEntry is an operator's view of one of their game servers.
+type GameServerDashboardEntry struct {
+	ServerID    uint64            `json:"server_id"`
+	SessionID   string            `json:"session_id"`
+	OperatorID  string            `json:"operator_id"`
+	Endpoint    string            `json:"endpoint"`
+	Region      string            `json:"region,omitempty"`
+	RegionCodes []string          `json:"region_codes"`
+	VersionLock string            `json:"version_lock"`
+	Match       *MatchLabel       `json:"match"` // The public view of the server's current match
+	Draining    bool              `json:"draining"`
+	UptimeSecs  int64             `json:"uptime_secs"`
+	Health      BroadcasterHealth `json:"health"` // Only known on the node the server is connected to
+}
+
+func (e GameServerDashboardEntry) IsParked() bool {
+	return e.Match == nil || e.Match.LobbyType == UnassignedLobby
+}
+
+// GameServerDashboardList returns the operator's connected game servers, by endpoint. Every connected server has
+// a match, which is a parking match while it is idle.
+func GameServerDashboardList(ctx context.Context, nk runtime.NakamaModule, operatorID string) ([]*GameServerDashboardEntry, error) {
+	query := fmt.Sprintf("+label.broadcaster.oper:%s", Query.MatchItem([]string{operatorID}))
+	matches, err := nk.MatchList(ctx, 1000, true, "", nil, nil, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list matches: %w", err)
+	}
+
+	now := time.Now()
+	entries := make([]*GameServerDashboardEntry, 0, len(matches))
+	for _, match := range matches {
+		label := &MatchLabel{}
+		if err := json.Unmarshal([]byte(match.GetLabel().GetValue()), label); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal match label: %w", err)
+		}
+		if label.GameServer == nil {
+			continue
+		}
+		gs := label.GameServer
+		entry := &GameServerDashboardEntry{
+			ServerID:    gs.ServerID,
+			SessionID:   gs.SessionID.String(),
+			OperatorID:  gs.OperatorID.String(),
+			Endpoint:    gs.Endpoint.ExternalAddress(),
+			Region:      gs.Region,
+			RegionCodes: gs.RegionCodes,
+			VersionLock: gs.VersionLock.String(),
+			Match:       label.PublicView(),
+			Draining:    gs.Draining,
+		}
+		if h, ok := globalBroadcasterRegistry.Get(entry.Endpoint); ok {
+			entry.Health = h
+			if !h.ConnectedAt.IsZero() {
+				entry.UptimeSecs = int64(now.Sub(h.ConnectedAt).Seconds())
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b *GameServerDashboardEntry) int {
+		return strings.Compare(a.Endpoint, b.Endpoint)
+	})
+	return entries, nil
+}
+
+// GameServerActionApply drains, undrains or restarts the parking match of a game server. The action is sent to the
+// server's match, which runs on the node that the server is connected to.
+func GameServerActionApply(ctx context.Context, nk runtime.NakamaModule, sessionID uuid.UUID, action GameServerAction) error {
+	switch action {
+	case GameServerActionDrain, GameServerActionUndrain, GameServerActionRestartParking:
+	default:
+		return ErrGameServerInvalidAction
+	}
+
+	matchID, _, err := GameServerBySessionID(nk, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := SignalMatch(ctx, nk, matchID, SignalGameServerAction, SignalGameServerActionPayload{Action: action}); err != nil {
+		// The match returns the message of the error.
+		for _, e := range []error{ErrGameServerInMatch, ErrGameServerDraining, ErrGameServerInvalidAction, ErrGameServerPresenceNotFound} {
+			if strings.HasSuffix(err.Error(), e.Error()) {
+				return e
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// gameServerActionApply applies the action in the game server's match, on the node that the server is connected to.
+// The match's label is updated by the caller.
+func gameServerActionApply(nk *RuntimeGoNakamaModule, state *MatchLabel, action GameServerAction) error {
+	switch action {
+	case GameServerActionDrain, GameServerActionUndrain:
+	case GameServerActionRestartParking:
+		if state.GameServer.Draining {
+			return ErrGameServerDraining
+		}
+		if state.LobbyType != UnassignedLobby {
+			return ErrGameServerInMatch
+		}
+	default:
+		return ErrGameServerInvalidAction
+	}
+
+	s, ok := nk.sessionRegistry.Get(state.GameServer.SessionID).(*sessionWS)
+	if !ok || s == nil {
+		return ErrGameServerPresenceNotFound
+	}
+
+	switch action {
+	case GameServerActionDrain, GameServerActionUndrain:
+		return gameServerSetDraining(nk, s, state, action == GameServerActionDrain)
+
+	case GameServerActionRestartParking:
+		// The parking match shuts down once the server leaves it. The match loop is not blocked while it does.
+		config := *state.GameServer
+		matchID := state.ID
+		go func() {
+			if err := nk.StreamUserLeave(StreamModeMatchAuthoritative, matchID.UUID.String(), "", matchID.Node, s.UserID().String(), s.ID().String()); err != nil {
+				nk.logger.Warn("Failed to leave parking match", zap.Error(err))
+				return
+			}
+			if _, err := newParkingMatch(nk.logger, nk, s, &config); err != nil {
+				nk.logger.Warn("Failed to create parking match", zap.Error(err))
+			}
+		}()
+		return nil
+	}
+
+	return ErrGameServerInvalidAction
+}
+
+// gameServerSetDraining sets the drain state on the match's label and the server's presence. A draining server is
+// not allocated or backfilled, and is disconnected once its match ends; a parked server is disconnected now.
+func gameServerSetDraining(nk *RuntimeGoNakamaModule, s *sessionWS, state *MatchLabel, draining bool) error {
+	state.GameServer.Draining = draining
+	status := state.GameServer.GetStatus()
+
+	subjects := []string{s.ID().String()}
+	for _, gID := range state.GameServer.GroupIDs {
+		subjects = append(subjects, gID.String())
+	}
+	for _, subject := range subjects {
+		if err := nk.StreamUserUpdate(StreamModeGameServer, subject, "", "", s.UserID().String(), s.ID().String(), false, false, status); err != nil {
+			return fmt.Errorf("failed to update game server presence: %w", err)
+		}
+	}
+
+	if draining && state.LobbyType == UnassignedLobby {
+		go s.Close("Game server drained", runtime.PresenceReasonDisconnect)
+	}
+	return nil
+}
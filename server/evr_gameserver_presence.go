@@ -38,6 +38,7 @@ type GameServerPresence struct {
 	Latitude        float64      `json:"latitude,omitempty"`         // The latitude of the server.
 	Longitude       float64      `json:"longitude,omitempty"`        // The longitude of the server.
 	ASNumber        int          `json:"asn,omitempty"`              // The ASN of the server.
+	Draining        bool         `json:"draining,omitempty"`         // The server finishes its match, and then disconnects.
 }
 
 func (g GameServerPresence) GetHidden() bool {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 	"github.com/heroiclabs/nakama/v3/server/evr"
@@ -42,26 +43,37 @@ type ServiceSettingsData struct {
 	KickPlayersWithDisabledAlternates     bool                      `json:"kick_players_with_disabled_alts"` // Kick players with disabled alts
 	VRMLEntitlementNotifyChannelID        string                    `json:"vrml_entitlement_notify_channel_id"`
 	EnableContinuousGameserverHealthCheck bool                      `json:"enable_continuous_gameserver_health_check"`
+	EnableMatchDataJournal                bool                      `json:"enable_match_data_journal"` // Journal match data to the match data sink
 	version                               string
 	serviceStatusMessage                  string
 }
 
 type GlobalMatchmakingSettings struct {
-	MatchmakingTimeoutSecs         int                    `json:"matchmaking_timeout_secs"`            // The matchmaking timeout
-	FailsafeTimeoutSecs            int                    `json:"failsafe_timeout_secs"`               // The failsafe timeout
-	FallbackTimeoutSecs            int                    `json:"fallback_timeout_secs"`               // The fallback timeout
-	DisableArenaBackfill           bool                   `json:"disable_arena_backfill"`              // Disable backfilling for arena matches
-	QueryAddons                    QueryAddons            `json:"query_addons"`                        // Additional queries to add to matchmaking queries
-	MaxServerRTT                   int                    `json:"max_server_rtt"`                      // The maximum RTT to allow
-	RankPercentile                 RankPercentileSettings `json:"rank_percentile"`                     // The rank percentile settings
-	EnableSBMM                     bool                   `json:"enable_skill_based_mm"`               // Disable SBMM
-	EnableDivisions                bool                   `json:"enable_divisions"`                    // Enable divisions
-	GreenDivisionMaxAccountAgeDays int                    `json:"green_division_max_account_age_days"` // The maximum account age to be in the green division
-	EnableEarlyQuitPenalty         bool                   `json:"enable_early_quit_penalty"`           // Disable early quit penalty
-	ServerRatings                  ServerRatings          `json:"server_ratings"`                      // The server ratings
-	EnableOrdinalRange             bool                   `json:"enable_ordinal_range"`                // Enable ordinal range
-	EnableRankPercentileRange      bool                   `json:"enable_rank_percentile_range"`        // Enable rank percentile range
-	OrdinalRange                   float64                `json:"ordinal_range"`                       // The ordinal range
+	MatchmakingTimeoutSecs         int                     `json:"matchmaking_timeout_secs"`            // The matchmaking timeout
+	FailsafeTimeoutSecs            int                     `json:"failsafe_timeout_secs"`               // The failsafe timeout
+	FallbackTimeoutSecs            int                     `json:"fallback_timeout_secs"`               // The fallback timeout
+	DisableArenaBackfill           bool                    `json:"disable_arena_backfill"`              // Disable backfilling for arena matches
+	QueryAddons                    QueryAddons             `json:"query_addons"`                        // Additional queries to add to matchmaking queries
+	MaxServerRTT                   int                     `json:"max_server_rtt"`                      // The maximum RTT to allow
+	RankPercentile                 RankPercentileSettings  `json:"rank_percentile"`                     // The rank percentile settings
+	EnableSBMM                     bool                    `json:"enable_skill_based_mm"`               // Disable SBMM
+	EnableDivisions                bool                    `json:"enable_divisions"`                    // Enable divisions
+	EnableTeamBalancing            bool                    `json:"enable_team_balancing"`               // Rebalance the matchmaker's teams by predicted outcome
+	GreenDivisionMaxAccountAgeDays int                     `json:"green_division_max_account_age_days"` // The maximum account age to be in the green division
+	EnableEarlyQuitPenalty         bool                    `json:"enable_early_quit_penalty"`           // Disable early quit penalty
+	ServerRatings                  ServerRatings           `json:"server_ratings"`                      // The server ratings
+	EnableOrdinalRange             bool                    `json:"enable_ordinal_range"`                // Enable ordinal range
+	EnableRankPercentileRange      bool                    `json:"enable_rank_percentile_range"`        // Enable rank percentile range
+	OrdinalRange                   float64                 `json:"ordinal_range"`                       // The ordinal range
+	EnableRangeFilter              bool                    `json:"enable_range_filter"`                 // Also drop the matchmaker's candidates that are outside of the ranges
+	EarlyQuitPolicy                EarlyQuitPolicy         `json:"early_quit_policy"`                   // The early quit penalty policy
+	RatingDecay                    RatingDecaySettings     `json:"rating_decay"`                        // The rating decay and seasonal soft reset settings
+	RatingWeighting                RatingWeightingSettings `json:"rating_weighting"`                    // The weighting of rating changes by margin of victory and participation
+}
+
+type RatingWeightingSettings struct {
+	EnableMarginOfVictory bool `json:"enable_margin_of_victory"` // Scale rating changes by the goal point margin. A margin of zero is not weighted.
+	EnableParticipation   bool `json:"enable_participation"`     // Reduce the rating changes of players that joined after the round started
 }
 
 type QueryAddons struct {
@@ -81,6 +93,48 @@ type RankPercentileSettings struct {
 	LeaderboardWeights  map[evr.Symbol]map[string]float64 `json:"board_weights"`        // The weights to use for ranking boards map[mode][board]weight
 }
 
+type RatingDecaySettings struct {
+	Schedule               string  `json:"schedule"`                  // The cron expression of the inactivity decay job, or empty to disable it
+	InactivityDays         int     `json:"inactivity_days"`           // The days without a rated match before a player's rating decays
+	SigmaInflation         float64 `json:"sigma_inflation"`           // The sigma added, in quadrature, to an inactive player's rating on each run
+	MaxSigma               float64 `json:"max_sigma"`                 // Sigma is not inflated beyond this
+	SeasonSchedule         string  `json:"season_schedule"`           // The cron expression of the seasonal soft reset, or empty to disable it
+	SeasonCompression      float64 `json:"season_compression"`        // The fraction of the distance to the mean that is removed from mu and rank percentile each season
+	SeasonSigmaInflation   float64 `json:"season_sigma_inflation"`    // The sigma added, in quadrature, to every rating each season
+	AuditHistoryMaxEntries int     `json:"audit_history_max_entries"` // The number of adjustments kept in each player's audit history
+}
+
+type EarlyQuitPolicy struct {
+	WindowHours                    int                                `json:"window_hours"`                      // The rolling window of history used to determine the penalty tier
+	MaxHistory                     int                                `json:"max_history"`                       // The maximum number of matches kept in the history
+	CompletedMatchesPerForgiveness int                                `json:"completed_matches_per_forgiveness"` // The number of completed matches that forgive one early quit
+	Modes                          map[evr.Symbol]EarlyQuitModePolicy `json:"modes"`                             // The policy for each mode that early quits are penalized in
+}
+
+type EarlyQuitModePolicy struct {
+	MinimumMatchSecs int                    `json:"minimum_match_secs"` // Leaving a match before it has run this long is not an early quit
+	Tiers            []EarlyQuitPenaltyTier `json:"tiers"`              // The penalty tiers, in ascending order of early quits
+}
+
+type EarlyQuitPenaltyTier struct {
+	MinEarlyQuits           int     `json:"min_early_quits"`           // The number of unforgiven early quits in the window to reach this tier
+	LockoutSecs             int     `json:"lockout_secs"`              // The time the player is blocked from matchmaking
+	RemainingTimeMultiplier float64 `json:"remaining_time_multiplier"` // The remaining round time is multiplied by this and added to the lockout
+	QuitterPool             bool    `json:"quitter_pool"`              // The player is only matched with other early quitters
+}
+
+func (p EarlyQuitPolicy) Window() time.Duration {
+	return time.Duration(p.WindowHours) * time.Hour
+}
+
+// Tier returns the penalty tier for the level, or nil if there is no penalty.
+func (p EarlyQuitModePolicy) Tier(level int) *EarlyQuitPenaltyTier {
+	if level <= 0 || len(p.Tiers) == 0 {
+		return nil
+	}
+	return &p.Tiers[min(level, len(p.Tiers))-1]
+}
+
 type ServerRatings struct {
 	ByExternalIP map[string]float64 `json:"by_external_ip"`
 	ByOperatorID map[string]float64 `json:"by_operator_id"`
@@ -189,6 +243,55 @@ func FixDefaultServiceSettings(data *ServiceSettingsData) {
 		data.Matchmaking.RankPercentile.ResetScheduleDamper = "weekly"
 	}
 
+	if data.Matchmaking.EarlyQuitPolicy.WindowHours == 0 {
+		data.Matchmaking.EarlyQuitPolicy.WindowHours = 24 * 7
+	}
+
+	if data.Matchmaking.EarlyQuitPolicy.MaxHistory == 0 {
+		data.Matchmaking.EarlyQuitPolicy.MaxHistory = 100
+	}
+
+	if data.Matchmaking.EarlyQuitPolicy.CompletedMatchesPerForgiveness == 0 {
+		data.Matchmaking.EarlyQuitPolicy.CompletedMatchesPerForgiveness = 2
+	}
+
+	if data.Matchmaking.EarlyQuitPolicy.Modes == nil {
+		data.Matchmaking.EarlyQuitPolicy.Modes = map[evr.Symbol]EarlyQuitModePolicy{
+			evr.ModeArenaPublic: {
+				MinimumMatchSecs: 60,
+				Tiers: []EarlyQuitPenaltyTier{
+					{MinEarlyQuits: 1, RemainingTimeMultiplier: 2},
+					{MinEarlyQuits: 3, LockoutSecs: 5 * 60, RemainingTimeMultiplier: 2, QuitterPool: true},
+					{MinEarlyQuits: 5, LockoutSecs: 30 * 60, RemainingTimeMultiplier: 2, QuitterPool: true},
+				},
+			},
+		}
+	}
+
+	if data.Matchmaking.RatingDecay.InactivityDays == 0 {
+		data.Matchmaking.RatingDecay.InactivityDays = 14
+	}
+
+	if data.Matchmaking.RatingDecay.SigmaInflation == 0 {
+		data.Matchmaking.RatingDecay.SigmaInflation = 0.5
+	}
+
+	if data.Matchmaking.RatingDecay.MaxSigma == 0 {
+		data.Matchmaking.RatingDecay.MaxSigma = NewDefaultRating().Sigma
+	}
+
+	if data.Matchmaking.RatingDecay.SeasonCompression == 0 {
+		data.Matchmaking.RatingDecay.SeasonCompression = 0.25
+	}
+
+	if data.Matchmaking.RatingDecay.SeasonSigmaInflation == 0 {
+		data.Matchmaking.RatingDecay.SeasonSigmaInflation = 2
+	}
+
+	if data.Matchmaking.RatingDecay.AuditHistoryMaxEntries == 0 {
+		data.Matchmaking.RatingDecay.AuditHistoryMaxEntries = 50
+	}
+
 	if data.RemoteLogFilters == nil {
 		data.RemoteLogFilters = map[string][]string{
 			"message": {
@@ -7,27 +7,31 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/heroiclabs/nakama/v3/server/evr"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type GroupMetadata struct {
-	GuildID                            string            `json:"guild_id"`                   // The guild ID
-	MinimumAccountAgeDays              int               `json:"minimum_account_age_days"`   // The minimum account age in days to be able to play echo on this guild's sessions
-	MembersOnlyMatchmaking             bool              `json:"members_only_matchmaking"`   // Restrict matchmaking to members only (when this group is the active one)
-	DisableCreateCommand               bool              `json:"disable_create_command"`     // Disable the public allocate command
-	LogAlternateAccounts               bool              `json:"log_alternate_accounts"`     // Log alternate accounts
-	EnforcersHaveGoldNames             bool              `json:"moderators_have_gold_names"` // Enforcers have gold display names
-	RoleMap                            GuildGroupRoles   `json:"roles"`                      // The roles text displayed on the main menu
-	MatchmakingChannelIDs              map[string]string `json:"matchmaking_channel_ids"`    // The matchmaking channel IDs
-	AuditChannelID                     string            `json:"audit_channel_id"`           // The audit channel
-	ErrorChannelID                     string            `json:"error_channel_id"`           // The error channel
-	CommandChannelID                   string            `json:"command_channel_id"`         // The command channel
-	BlockVPNUsers                      bool              `json:"block_vpn_users"`            // Block VPN users
-	FraudScoreThreshold                int               `json:"fraud_score_threshold"`      // The fraud score threshold
-	AllowedFeatures                    []string          `json:"allowed_features"`           // Allowed features
-	AlternateAccountNotificationExpiry time.Time         `json:"alt_notification_threshold"` // Show alternate notifications newer than this time.
-	EnableEnforcementCountInNames      bool              `json:"enable_enforcement_count_in_names"`
+	GuildID                            string                    `json:"guild_id"`                   // The guild ID
+	MinimumAccountAgeDays              int                       `json:"minimum_account_age_days"`   // The minimum account age in days to be able to play echo on this guild's sessions
+	MembersOnlyMatchmaking             bool                      `json:"members_only_matchmaking"`   // Restrict matchmaking to members only (when this group is the active one)
+	DisableCreateCommand               bool                      `json:"disable_create_command"`     // Disable the public allocate command
+	LogAlternateAccounts               bool                      `json:"log_alternate_accounts"`     // Log alternate accounts
+	EnforcersHaveGoldNames             bool                      `json:"moderators_have_gold_names"` // Enforcers have gold display names
+	RoleMap                            GuildGroupRoles           `json:"roles"`                      // The roles text displayed on the main menu
+	MatchmakingChannelIDs              map[string]string         `json:"matchmaking_channel_ids"`    // The matchmaking channel IDs
+	AuditChannelID                     string                    `json:"audit_channel_id"`           // The audit channel
+	ErrorChannelID                     string                    `json:"error_channel_id"`           // The error channel
+	CommandChannelID                   string                    `json:"command_channel_id"`         // The command channel
+	BlockVPNUsers                      bool                      `json:"block_vpn_users"`            // Block VPN users
+	FraudScoreThreshold                int                       `json:"fraud_score_threshold"`      // The fraud score threshold
+	AllowedFeatures                    []string                  `json:"allowed_features"`           // Allowed features
+	AlternateAccountNotificationExpiry time.Time                 `json:"alt_notification_threshold"` // Show alternate notifications newer than this time.
+	EnableEnforcementCountInNames      bool                      `json:"enable_enforcement_count_in_names"`
+	RatingSystems                      map[evr.Symbol]string     `json:"rating_systems"`              // The rating system used for each mode
+	TrustedGroupIDs                    []string                  `json:"trusted_group_ids"`           // The guilds whose suspensions are honoured by this guild
+	FederatedSuspensionPolicy          []FederatedSuspensionRule `json:"federated_suspension_policy"` // How suspensions from trusted guilds are treated, by their length
 }
 
 func NewGuildGroupMetadata(guildID string) *GroupMetadata {
@@ -38,6 +42,14 @@ func NewGuildGroupMetadata(guildID string) *GroupMetadata {
 	}
 }
 
+// RatingSystemName returns the name of the rating system used for the mode, defaulting to OpenSkill.
+func (g *GroupMetadata) RatingSystemName(mode evr.Symbol) string {
+	if name, ok := g.RatingSystems[mode]; ok {
+		return RatingSystemByName(name).Name()
+	}
+	return RatingSystemOpenSkill
+}
+
 func (g *GroupMetadata) MarshalMap() map[string]any {
 	m := make(map[string]any)
 	data, _ := json.Marshal(g)
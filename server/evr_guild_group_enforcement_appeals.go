@@ -0,0 +1,357 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageCollectionEnforcementAppeal      = "EnforcementAppeal"
+	StorageCollectionEnforcementAppealIndex = "EnforcementAppealIndex"
+	StorageCollectionEnforcementAuditTrail  = "EnforcementAuditTrail"
+)
+
+type EnforcementAppealState string
+
+const (
+	EnforcementAppealStateOpen        EnforcementAppealState = "open"
+	EnforcementAppealStateUnderReview EnforcementAppealState = "under_review"
+	EnforcementAppealStateUpheld      EnforcementAppealState = "upheld"
+	EnforcementAppealStateReduced     EnforcementAppealState = "reduced"
+	EnforcementAppealStateVoided      EnforcementAppealState = "voided"
+)
+
+var (
+	ErrEnforcementAppealExists            = errors.New("the record has already been appealed")
+	ErrEnforcementAppealInvalidTransition = errors.New("invalid appeal state transition")
+
+	// The states that each state may transition to. The resolved states are final.
+	enforcementAppealTransitions = map[EnforcementAppealState][]EnforcementAppealState{
+		EnforcementAppealStateOpen:        {EnforcementAppealStateUnderReview, EnforcementAppealStateUpheld, EnforcementAppealStateReduced, EnforcementAppealStateVoided},
+		EnforcementAppealStateUnderReview: {EnforcementAppealStateUpheld, EnforcementAppealStateReduced, EnforcementAppealStateVoided},
+	}
+)
+
+func (s EnforcementAppealState) CanTransitionTo(next EnforcementAppealState) bool {
+	return slices.Contains(enforcementAppealTransitions[s], next)
+}
+
+func (s EnforcementAppealState) IsResolved() bool {
+	return s == EnforcementAppealStateUpheld || s == EnforcementAppealStateReduced || s == EnforcementAppealStateVoided
+}
+
+func (s EnforcementAppealState) String() string {
+	return strings.ReplaceAll(string(s), "_", " ")
+}
+
+var (
+	_ = IndexedVersionedStorable(&EnforcementAppeal{})
+	_ = VersionedStorable(&EnforcementAuditTrail{})
+)
+
+// EnforcementAppeal is a player's appeal of an enforcement record, keyed by the record ID.
+type EnforcementAppeal struct {
+	RecordID       string                 `json:"record_id"`
+	GroupID        string                 `json:"group_id"`
+	UserID         string                 `json:"user_id"`
+	State          EnforcementAppealState `json:"state"`
+	Reason         string                 `json:"reason"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	ReviewerUserID string                 `json:"reviewer_id,omitempty"`
+	ReviewerNotes  string                 `json:"reviewer_notes,omitempty"`
+
+	version string
+}
+
+func (a EnforcementAppeal) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionEnforcementAppeal,
+		Key:             a.RecordID,
+		PermissionRead:  runtime.STORAGE_PERMISSION_OWNER_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         a.version,
+	}
+}
+
+func (a EnforcementAppeal) StorageIndex() *StorageIndexMeta {
+	return &StorageIndexMeta{
+		Name:       StorageCollectionEnforcementAppealIndex,
+		Collection: StorageCollectionEnforcementAppeal,
+		Fields:     []string{"record_id", "group_id", "user_id", "state", "created_at"},
+		MaxEntries: 1000000,
+		IndexOnly:  false,
+	}
+}
+
+func (a EnforcementAppeal) GetStorageVersion() string {
+	return a.version
+}
+
+func (a *EnforcementAppeal) SetStorageVersion(userID, version string) {
+	a.UserID = userID
+	a.version = version
+}
+
+// EnforcementAuditEntry is a single change to an enforcement record, or its appeal.
+type EnforcementAuditEntry struct {
+	Time             time.Time              `json:"time"`
+	ActorUserID      string                 `json:"actor_id"`
+	Action           string                 `json:"action"`
+	FromState        EnforcementAppealState `json:"from_state,omitempty"`
+	ToState          EnforcementAppealState `json:"to_state,omitempty"`
+	Notes            string                 `json:"notes,omitempty"`
+	SuspensionExpiry time.Time              `json:"suspension_expiry,omitempty"` // The expiry of the record after the change
+}
+
+// EnforcementAuditTrail is the history of an enforcement record. Entries are only ever appended.
+type EnforcementAuditTrail struct {
+	RecordID string                  `json:"record_id"`
+	GroupID  string                  `json:"group_id"`
+	UserID   string                  `json:"user_id"`
+	Entries  []EnforcementAuditEntry `json:"entries"`
+
+	version string
+}
+
+func (t EnforcementAuditTrail) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionEnforcementAuditTrail,
+		Key:             t.RecordID,
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         t.version,
+	}
+}
+
+func (t *EnforcementAuditTrail) SetStorageVersion(userID, version string) {
+	t.UserID = userID
+	t.version = version
+}
+
+// EnforcementAuditTrailAppend appends the entry to the record's audit trail.
+func EnforcementAuditTrailAppend(ctx context.Context, nk runtime.NakamaModule, userID, groupID, recordID string, entry EnforcementAuditEntry) error {
+	var err error
+	for range 3 {
+		trail := &EnforcementAuditTrail{RecordID: recordID, GroupID: groupID}
+		if err = StorageRead(ctx, nk, userID, trail, false); err != nil {
+			if status.Code(err) != codes.NotFound {
+				return err
+			}
+			trail.version = "*"
+		}
+
+		trail.Entries = append(trail.Entries, entry)
+
+		if _, err = StorageWrite(ctx, nk, userID, trail); err == nil {
+			return nil
+		}
+	}
+	return errors.Join(errors.New("failed to append to the enforcement audit trail"), err)
+}
+
+func EnforcementAuditTrailLoad(ctx context.Context, nk runtime.NakamaModule, userID, recordID string) (*EnforcementAuditTrail, error) {
+	trail := &EnforcementAuditTrail{RecordID: recordID}
+	if err := StorageRead(ctx, nk, userID, trail, false); err != nil && status.Code(err) != codes.NotFound {
+		return nil, err
+	}
+	return trail, nil
+}
+
+func (s *GuildEnforcementRecords) Record(recordID string) *GuildEnforcementRecord {
+	for _, r := range s.Records {
+		if r.ID == recordID {
+			return r
+		}
+	}
+	return nil
+}
+
+func EnforcementAppealLoad(ctx context.Context, nk runtime.NakamaModule, userID, recordID string) (*EnforcementAppeal, error) {
+	appeal := &EnforcementAppeal{RecordID: recordID}
+	if err := StorageRead(ctx, nk, userID, appeal, false); err != nil {
+		return nil, err
+	}
+	return appeal, nil
+}
+
+// EnforcementAppealFile opens an appeal of the player's enforcement record. Each record may only be appealed once.
+func EnforcementAppealFile(ctx context.Context, nk runtime.NakamaModule, userID, groupID, recordID, reason string) (*EnforcementAppeal, error) {
+	records := NewGuildEnforcementRecords(userID, groupID)
+	if err := StorageRead(ctx, nk, userID, records, false); err != nil {
+		return nil, err
+	}
+
+	record := records.Record(recordID)
+	if record == nil {
+		return nil, status.Error(codes.NotFound, "enforcement record not found")
+	} else if record.IsVoid {
+		return nil, status.Error(codes.FailedPrecondition, "the record has been voided")
+	}
+
+	now := time.Now().UTC()
+	appeal := &EnforcementAppeal{
+		RecordID:  recordID,
+		GroupID:   groupID,
+		UserID:    userID,
+		State:     EnforcementAppealStateOpen,
+		Reason:    reason,
+		CreatedAt: now,
+		UpdatedAt: now,
+		version:   "*", // Only create the appeal if the record has not been appealed.
+	}
+	if _, err := StorageWrite(ctx, nk, userID, appeal); err != nil {
+		if _, err := EnforcementAppealLoad(ctx, nk, userID, recordID); err == nil {
+			return nil, ErrEnforcementAppealExists
+		}
+		return nil, fmt.Errorf("failed to write the appeal: %w", err)
+	}
+
+	if err := EnforcementAuditTrailAppend(ctx, nk, userID, groupID, recordID, EnforcementAuditEntry{
+		Time:             now,
+		ActorUserID:      userID,
+		Action:           "appeal_filed",
+		ToState:          EnforcementAppealStateOpen,
+		Notes:            reason,
+		SuspensionExpiry: record.SuspensionExpiry,
+	}); err != nil {
+		return appeal, err
+	}
+
+	return appeal, nil
+}
+
+// EnforcementAppealTransition moves the appeal to the next state, and applies the outcome to the enforcement record.
+// The reduced expiry is required when the suspension is reduced, and must be earlier than the current expiry.
+func EnforcementAppealTransition(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, reviewerUserID, userID, recordID string, next EnforcementAppealState, notes string, reducedExpiry time.Time) (*EnforcementAppeal, error) {
+	appeal, err := EnforcementAppealLoad(ctx, nk, userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := appeal.State
+	if !previous.CanTransitionTo(next) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrEnforcementAppealInvalidTransition, previous, next)
+	}
+
+	records := NewGuildEnforcementRecords(userID, appeal.GroupID)
+	if err := StorageRead(ctx, nk, userID, records, false); err != nil {
+		return nil, err
+	}
+	record := records.Record(recordID)
+	if record == nil {
+		return nil, status.Error(codes.NotFound, "enforcement record not found")
+	}
+
+	// Apply the outcome to the record.
+	switch next {
+	case EnforcementAppealStateReduced:
+		if reducedExpiry.IsZero() || !reducedExpiry.Before(record.SuspensionExpiry) {
+			return nil, status.Error(codes.InvalidArgument, "the reduced expiry must be earlier than the current expiry")
+		}
+		record.SuspensionExpiry = reducedExpiry.UTC()
+	case EnforcementAppealStateVoided:
+		record.IsVoid = true
+	}
+
+	now := time.Now().UTC()
+	appeal.State = next
+	appeal.UpdatedAt = now
+	appeal.ReviewerUserID = reviewerUserID
+	if notes != "" {
+		appeal.ReviewerNotes = notes
+	}
+
+	// The record and the appeal are written together, and their versions prevent two reviewers from resolving the appeal at once.
+	records.UpdateSummary()
+	recordsOp, err := StorageWriteOp(userID, records)
+	if err != nil {
+		return nil, err
+	}
+	appealOp, err := StorageWriteOp(userID, appeal)
+	if err != nil {
+		return nil, err
+	}
+	acks, err := nk.StorageWrite(ctx, []*runtime.StorageWrite{recordsOp, appealOp})
+	if err != nil {
+		if errors.Is(err, runtime.ErrStorageRejectedVersion) {
+			return nil, status.Error(codes.Aborted, "the appeal or the enforcement record was changed by someone else")
+		}
+		return nil, fmt.Errorf("failed to write the appeal: %w", err)
+	}
+	for _, ack := range acks {
+		if ack.GetCollection() == StorageCollectionEnforcementAppeal {
+			appeal.SetStorageVersion(userID, ack.GetVersion())
+		}
+	}
+
+	// A reduced or voided record may lift a restriction on the player's connected sessions.
+	if err := EnforcementRecordsApply(ctx, nk, records); err != nil {
+		logger.WithField("error", err).Warn("Failed to apply the enforcement records to the player's sessions")
+	}
+
+	if err := EnforcementAuditTrailAppend(ctx, nk, userID, appeal.GroupID, recordID, EnforcementAuditEntry{
+		Time:             now,
+		ActorUserID:      reviewerUserID,
+		Action:           "appeal_" + string(next),
+		FromState:        previous,
+		ToState:          next,
+		Notes:            notes,
+		SuspensionExpiry: record.SuspensionExpiry,
+	}); err != nil {
+		return appeal, err
+	}
+
+	return appeal, nil
+}
+
+// EnforcementAppealsList returns the guild's appeals in the states, oldest first.
+func EnforcementAppealsList(ctx context.Context, nk runtime.NakamaModule, groupID string, states ...EnforcementAppealState) ([]*EnforcementAppeal, error) {
+	qparts := []string{
+		fmt.Sprintf("+value.group_id:%s", Query.Escape(groupID)),
+	}
+	if len(states) > 0 {
+		values := make([]string, 0, len(states))
+		for _, s := range states {
+			values = append(values, string(s))
+		}
+		qparts = append(qparts, fmt.Sprintf("+value.state:%s", Query.MatchItem(values)))
+	}
+
+	var (
+		query   = strings.Join(qparts, " ")
+		orderBy = []string{"value.created_at"}
+		appeals = make([]*EnforcementAppeal, 0)
+		cursor  = ""
+	)
+
+	for {
+		objs, nextCursor, err := nk.StorageIndexList(ctx, SystemUserID, StorageCollectionEnforcementAppealIndex, query, 100, orderBy, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range objs.GetObjects() {
+			appeal := &EnforcementAppeal{}
+			if err := json.Unmarshal([]byte(obj.GetValue()), appeal); err != nil {
+				return nil, err
+			}
+			appeal.SetStorageVersion(obj.GetUserId(), obj.GetVersion())
+			appeals = append(appeals, appeal)
+		}
+
+		if cursor = nextCursor; len(objs.GetObjects()) == 0 || cursor == "" {
+			break
+		}
+	}
+	return appeals, nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnforcementAppealStateTransitions(t *testing.T) {
+	tests := []struct {
+		from, to EnforcementAppealState
+		want     bool
+	}{
+		{EnforcementAppealStateOpen, EnforcementAppealStateUnderReview, true},
+		{EnforcementAppealStateOpen, EnforcementAppealStateVoided, true},
+		{EnforcementAppealStateUnderReview, EnforcementAppealStateReduced, true},
+		{EnforcementAppealStateUnderReview, EnforcementAppealStateOpen, false},
+		{EnforcementAppealStateUpheld, EnforcementAppealStateVoided, false},
+		{EnforcementAppealStateVoided, EnforcementAppealStateOpen, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s: expected %v, got %v", tt.from, tt.to, tt.want, got)
+		}
+	}
+
+	if EnforcementAppealStateUnderReview.IsResolved() || !EnforcementAppealStateReduced.IsResolved() {
+		t.Error("expected only upheld, reduced and voided to be resolved")
+	}
+}
+
+func TestGuildEnforcementRecordVoidIsNotSuspended(t *testing.T) {
+	record := NewGuildEnforcementRecord("enforcer", "notice", "", false, time.Now().Add(time.Hour))
+	if !record.IsSuspended() {
+		t.Fatal("expected the record to be suspended")
+	}
+
+	records := NewGuildEnforcementRecords("user", "group")
+	records.AddRecord(record)
+	record.IsVoid = true
+
+	if record.IsSuspended() {
+		t.Error("expected a voided record not to be suspended")
+	}
+	if len(records.ActiveSuspensions()) != 0 {
+		t.Error("expected no active suspensions")
+	}
+}
+
+func TestParseSuspensionDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30":  30 * time.Minute,
+		"15m": 15 * time.Minute,
+		"2h":  2 * time.Hour,
+		"3d":  72 * time.Hour,
+		"1w":  7 * 24 * time.Hour,
+	}
+	for s, want := range tests {
+		if got, err := parseSuspensionDuration(s); err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v (%v)", s, want, got, err)
+		}
+	}
+
+	for _, s := range []string{"", "h", "-1d", "1y"} {
+		if _, err := parseSuspensionDuration(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+type FederatedSuspensionAction string
+
+const (
+	FederatedSuspensionActionIgnore FederatedSuspensionAction = "ignore" // The suspension is not honoured
+	FederatedSuspensionActionFlag   FederatedSuspensionAction = "flag"   // The player may join, and the enforcers are notified
+	FederatedSuspensionActionBlock  FederatedSuspensionAction = "block"  // The player is rejected, as if suspended locally
+)
+
+// The severity of an action, for choosing the most severe of several suspensions.
+func (a FederatedSuspensionAction) severity() int {
+	switch a {
+	case FederatedSuspensionActionBlock:
+		return 2
+	case FederatedSuspensionActionFlag:
+		return 1
+	}
+	return 0
+}
+
+// FederatedSuspensionRule maps suspensions of at least the minimum length to an action.
+type FederatedSuspensionRule struct {
+	MinimumDurationHours float64                   `json:"minimum_duration_hours"`
+	Action               FederatedSuspensionAction `json:"action"`
+}
+
+// By default, every suspension from a trusted guild is honoured.
+var DefaultFederatedSuspensionPolicy = []FederatedSuspensionRule{
+	{MinimumDurationHours: 0, Action: FederatedSuspensionActionBlock},
+}
+
+// FederatedSuspension is an active suspension issued by a trusted guild.
+type FederatedSuspension struct {
+	GroupID string
+	Record  *GuildEnforcementRecord
+	Action  FederatedSuspensionAction
+}
+
+// IsTrustedGroup returns true if the guild honours the suspensions of the given guild.
+func (g *GroupMetadata) IsTrustedGroup(groupID string) bool {
+	return slices.Contains(g.TrustedGroupIDs, groupID)
+}
+
+// FederatedSuspensionAction returns how a suspension from a trusted guild is treated, by the rule with the
+// largest minimum duration that the suspension meets.
+func (g *GroupMetadata) FederatedSuspensionAction(record *GuildEnforcementRecord) FederatedSuspensionAction {
+	policy := g.FederatedSuspensionPolicy
+	if len(policy) == 0 {
+		policy = DefaultFederatedSuspensionPolicy
+	}
+
+	duration := record.SuspensionExpiry.Sub(record.CreatedAt)
+
+	action := FederatedSuspensionActionIgnore
+	minimum := -1.0
+	for _, rule := range policy {
+		threshold := time.Duration(rule.MinimumDurationHours * float64(time.Hour))
+		if duration >= threshold && rule.MinimumDurationHours > minimum {
+			action, minimum = rule.Action, rule.MinimumDurationHours
+		}
+	}
+	return action
+}
+
+// federatedSuspensions returns the active suspensions from the guild's trusted guilds, most severe first.
+func federatedSuspensions(g *GroupMetadata, groupID string, recordsByGroup map[string]map[string]*GuildEnforcementRecords, userID string) []FederatedSuspension {
+	suspensions := make([]FederatedSuspension, 0)
+	for sourceGroupID, byUserID := range recordsByGroup {
+		if sourceGroupID == groupID || !g.IsTrustedGroup(sourceGroupID) {
+			continue
+		}
+		records, ok := byUserID[userID]
+		if !ok {
+			continue
+		}
+		for _, r := range records.Records {
+			if !r.IsSuspended() {
+				continue
+			}
+			if action := g.FederatedSuspensionAction(r); action != FederatedSuspensionActionIgnore {
+				suspensions = append(suspensions, FederatedSuspension{
+					GroupID: sourceGroupID,
+					Record:  r,
+					Action:  action,
+				})
+			}
+		}
+	}
+
+	slices.SortStableFunc(suspensions, func(a, b FederatedSuspension) int {
+		if s := b.Action.severity() - a.Action.severity(); s != 0 {
+			return s
+		}
+		return b.Record.SuspensionExpiry.Compare(a.Record.SuspensionExpiry)
+	})
+	return suspensions
+}
+
+// federatedSuspensionNotices records the flagged suspensions that each guild's enforcers have been notified of,
+// so the audit channel is notified once per user, guild and record, instead of on every join. It is local to the node.
+var federatedSuspensionNotices = &federatedSuspensionNoticeCache{notified: make(map[string]time.Time)}
+
+type federatedSuspensionNoticeCache struct {
+	sync.Mutex
+	notified map[string]time.Time // The expiry of the record, keyed by guild, user and record
+}
+
+// ShouldNotify returns true the first time that the guild is notified of the user's record, until the record expires.
+func (c *federatedSuspensionNoticeCache) ShouldNotify(groupID, userID string, record *GuildEnforcementRecord, now time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	for key, expiry := range c.notified {
+		if now.After(expiry) {
+			delete(c.notified, key)
+		}
+	}
+
+	key := groupID + ":" + userID + ":" + record.ID
+	if _, ok := c.notified[key]; ok {
+		return false
+	}
+	c.notified[key] = record.SuspensionExpiry
+	return true
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFederatedSuspensionAction(t *testing.T) {
+	now := time.Now()
+	record := func(d time.Duration) *GuildEnforcementRecord {
+		return &GuildEnforcementRecord{CreatedAt: now, SuspensionExpiry: now.Add(d)}
+	}
+
+	g := &GroupMetadata{}
+	if got := g.FederatedSuspensionAction(record(time.Hour)); got != FederatedSuspensionActionBlock {
+		t.Errorf("expected the default policy to block, got %s", got)
+	}
+
+	g.FederatedSuspensionPolicy = []FederatedSuspensionRule{
+		{MinimumDurationHours: 24, Action: FederatedSuspensionActionBlock},
+		{MinimumDurationHours: 1, Action: FederatedSuspensionActionFlag},
+	}
+	tests := map[time.Duration]FederatedSuspensionAction{
+		30 * time.Minute: FederatedSuspensionActionIgnore,
+		2 * time.Hour:    FederatedSuspensionActionFlag,
+		72 * time.Hour:   FederatedSuspensionActionBlock,
+	}
+	for d, want := range tests {
+		if got := g.FederatedSuspensionAction(record(d)); got != want {
+			t.Errorf("%v: expected %s, got %s", d, want, got)
+		}
+	}
+}
+
+func TestFederatedSuspensions(t *testing.T) {
+	now := time.Now()
+	g := &GroupMetadata{
+		TrustedGroupIDs: []string{"trusted-a", "trusted-b"},
+		FederatedSuspensionPolicy: []FederatedSuspensionRule{
+			{MinimumDurationHours: 0, Action: FederatedSuspensionActionFlag},
+			{MinimumDurationHours: 24, Action: FederatedSuspensionActionBlock},
+		},
+	}
+
+	records := func(groupID string, d time.Duration, void bool) map[string]*GuildEnforcementRecords {
+		r := NewGuildEnforcementRecords("user", groupID)
+		r.Records = append(r.Records, &GuildEnforcementRecord{CreatedAt: now, SuspensionExpiry: now.Add(d), IsVoid: void})
+		return map[string]*GuildEnforcementRecords{"user": r}
+	}
+
+	recordsByGroup := map[string]map[string]*GuildEnforcementRecords{
+		"local":     records("local", 48*time.Hour, false),
+		"untrusted": records("untrusted", 48*time.Hour, false),
+		"trusted-a": records("trusted-a", time.Hour, false),
+		"trusted-b": records("trusted-b", 48*time.Hour, false),
+	}
+
+	got := federatedSuspensions(g, "local", recordsByGroup, "user")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 suspensions, got %d", len(got))
+	}
+	if got[0].GroupID != "trusted-b" || got[0].Action != FederatedSuspensionActionBlock {
+		t.Errorf("expected the blocking suspension first, got %+v", got[0])
+	}
+
+	recordsByGroup["trusted-b"] = records("trusted-b", 48*time.Hour, true)
+	if got := federatedSuspensions(g, "local", recordsByGroup, "user"); len(got) != 1 || got[0].Action != FederatedSuspensionActionFlag {
+		t.Errorf("expected the voided suspension to be ignored, got %+v", got)
+	}
+}
+
+func TestFederatedSuspensionNoticeCache(t *testing.T) {
+	now := time.Now()
+	c := &federatedSuspensionNoticeCache{notified: make(map[string]time.Time)}
+	record := &GuildEnforcementRecord{ID: "record", SuspensionExpiry: now.Add(time.Hour)}
+
+	if !c.ShouldNotify("guild", "user", record, now) {
+		t.Fatal("expected the first flag to notify")
+	}
+	if c.ShouldNotify("guild", "user", record, now.Add(time.Minute)) {
+		t.Error("expected a repeated flag not to notify")
+	}
+	if !c.ShouldNotify("other", "user", record, now) {
+		t.Error("expected another guild to be notified")
+	}
+	if !c.ShouldNotify("guild", "user", &GuildEnforcementRecord{ID: "new", SuspensionExpiry: now.Add(time.Hour)}, now) {
+		t.Error("expected a new record to notify")
+	}
+
+	// The record may notify again after it expires and is reissued with the same ID.
+	if !c.ShouldNotify("guild", "user", record, now.Add(2*time.Hour)) {
+		t.Error("expected the expired notice to be pruned")
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/gofrs/uuid/v5"
 	"github.com/heroiclabs/nakama-common/api"
 	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
 )
 
 const (
@@ -19,6 +21,29 @@ const (
 
 var _ = IndexedVersionedStorable(&GuildEnforcementRecords{})
 
+// EnforcementActionType is the restriction that a record places on the player, until it expires.
+type EnforcementActionType string
+
+const (
+	EnforcementActionSuspension          EnforcementActionType = "suspension"           // The player may not join the guild's lobbies
+	EnforcementActionVoiceMute           EnforcementActionType = "voice_mute"           // The player is muted in the guild's matches
+	EnforcementActionSpectatorOnly       EnforcementActionType = "spectator_only"       // The player may only spectate arena and combat matches
+	EnforcementActionPrivateOnly         EnforcementActionType = "private_only"         // The player may only join private matches
+	EnforcementActionMatchmakingCooldown EnforcementActionType = "matchmaking_cooldown" // The player may not play public arena or combat matches
+)
+
+var EnforcementActionTypes = []EnforcementActionType{
+	EnforcementActionSuspension,
+	EnforcementActionVoiceMute,
+	EnforcementActionSpectatorOnly,
+	EnforcementActionPrivateOnly,
+	EnforcementActionMatchmakingCooldown,
+}
+
+func (t EnforcementActionType) String() string {
+	return strings.ReplaceAll(string(t), "_", " ")
+}
+
 type GuildEnforcementRecords struct {
 	SuspensionExpiry           time.Time                 `json:"suspension_expiry"`
 	CommunityValuesCompletedAt time.Time                 `json:"community_values_completed_at"`
@@ -74,63 +99,161 @@ func (s *GuildEnforcementRecords) SetStorageVersion(userID, version string) {
 func (s *GuildEnforcementRecords) ActiveSuspensions() []*GuildEnforcementRecord {
 	active := make([]*GuildEnforcementRecord, 0)
 	for _, r := range s.Records {
-		if time.Now().Before(r.SuspensionExpiry) {
+		if r.IsSuspended() {
 			active = append(active, r)
 		}
 	}
 	return active
 }
 
-func (s *GuildEnforcementRecords) MarshalJSON() ([]byte, error) {
-
-	for i := len(s.Records) - 1; i >= 0; i-- {
-		if s.Records[i].IsSuspended() && s.Records[i].SuspensionExpiry.After(s.SuspensionExpiry) {
-			s.SuspensionExpiry = s.Records[i].SuspensionExpiry
+// ActiveAction returns the active record of the action type that expires last, or nil.
+func (s *GuildEnforcementRecords) ActiveAction(actionType EnforcementActionType) *GuildEnforcementRecord {
+	var active *GuildEnforcementRecord
+	for _, r := range s.Records {
+		if r.Action() != actionType || !r.IsActive() {
+			continue
+		}
+		if active == nil || r.SuspensionExpiry.After(active.SuspensionExpiry) {
+			active = r
 		}
 	}
+	return active
+}
 
-	s.IsCommunityValuesRequired = false
-	for i := len(s.Records) - 1; i >= 0; i-- {
-		if s.Records[i].CommunityValuesRequired && s.Records[i].CreatedAt.After(s.CommunityValuesCompletedAt) {
-			// Set the flag to true if any record requires community values
-			s.IsCommunityValuesRequired = true
-			break
+// LobbyRestriction returns the active record that prevents the player from joining a lobby of the mode with the role, or nil.
+// Suspensions are handled separately, as they apply to every lobby.
+func (s *GuildEnforcementRecords) LobbyRestriction(mode evr.Symbol, role int) *GuildEnforcementRecord {
+	isPlayer := role != evr.TeamSpectator && role != evr.TeamModerator
+
+	switch mode {
+	case evr.ModeArenaPublic, evr.ModeCombatPublic:
+		if r := s.ActiveAction(EnforcementActionPrivateOnly); r != nil {
+			return r
+		}
+		if !isPlayer {
+			return nil
+		}
+		if r := s.ActiveAction(EnforcementActionMatchmakingCooldown); r != nil {
+			return r
 		}
+		return s.ActiveAction(EnforcementActionSpectatorOnly)
+
+	case evr.ModeSocialPublic:
+		return s.ActiveAction(EnforcementActionPrivateOnly)
+
+	case evr.ModeArenaPrivate, evr.ModeCombatPrivate:
+		if !isPlayer {
+			return nil
+		}
+		return s.ActiveAction(EnforcementActionSpectatorOnly)
 	}
+	return nil
+}
 
-	// Use the default JSON marshaler for the struct
-	type Alias GuildEnforcementRecords
-	b, err := json.Marshal(&struct {
-		*Alias
-	}{
-		Alias: (*Alias)(s),
-	})
+// EnforcementRecordsApply applies changed records to the player's connected sessions.
+// The records cached on the sessions at lobby authorization are replaced, and the player is kicked
+// from any of the guild's matches that the records now suspend or restrict them from. Otherwise,
+// the player's voice mute is updated in the match's label.
+func EnforcementRecordsApply(ctx context.Context, nk runtime.NakamaModule, records *GuildEnforcementRecords) error {
+	presences, err := nk.StreamUserList(StreamModeService, records.UserID, "", StreamLabelMatchService, false, true)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to get stream presences: %w", err)
+	}
+
+	var sessionRegistry SessionRegistry
+	if _nk, ok := nk.(*RuntimeGoNakamaModule); ok {
+		sessionRegistry = _nk.sessionRegistry
 	}
 
-	return b, nil
+	var (
+		isSuspended  = len(records.ActiveSuspensions()) > 0
+		isVoiceMuted = records.ActiveAction(EnforcementActionVoiceMute) != nil
+		errs         []error
+	)
+
+	for _, p := range presences {
+
+		// Sessions on other nodes load the records again at their next lobby authorization.
+		if sessionRegistry != nil {
+			if session := sessionRegistry.Get(uuid.FromStringOrNil(p.GetSessionId())); session != nil {
+				if params, ok := LoadParams(session.Context()); ok && params.enforcementRecords != nil {
+					if cached := params.enforcementRecords.Load(); cached == nil || cached.GroupID == records.GroupID {
+						params.enforcementRecords.Store(records)
+					}
+				}
+			}
+		}
+
+		matchID := MatchIDFromStringOrNil(p.GetStatus())
+		if matchID.IsNil() {
+			continue
+		}
+
+		label, err := MatchLabelByID(ctx, nk, matchID)
+		if err != nil || label == nil || label.GetGroupID().String() != records.GroupID {
+			continue
+		}
+
+		for _, player := range label.Players {
+			if player.SessionID != p.GetSessionId() {
+				continue
+			}
+			if isSuspended || records.LobbyRestriction(label.Mode, int(player.Team)) != nil {
+				if err := KickPlayerFromMatch(ctx, nk, matchID, records.UserID); err != nil {
+					errs = append(errs, err)
+				}
+			} else if player.IsVoiceMuted != isVoiceMuted {
+				update := MatchPlayerUpdate{SessionID: player.SessionID, IsVoiceMuted: &isVoiceMuted}
+				if _, err := SignalMatch(ctx, nk, matchID, SignalPlayerUpdate, update); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// UpdateSummary recalculates the suspension expiry and the community values requirement from the records.
+// It is called before the records are written, as records may have been added, voided or reduced.
+func (s *GuildEnforcementRecords) UpdateSummary() {
+	s.SuspensionExpiry = time.Time{}
+	for _, r := range s.Records {
+		if r.IsSuspended() && r.SuspensionExpiry.After(s.SuspensionExpiry) {
+			s.SuspensionExpiry = r.SuspensionExpiry
+		}
+	}
+
+	s.IsCommunityValuesRequired = false
+	for _, r := range s.Records {
+		if r.CommunityValuesRequired && r.CreatedAt.After(s.CommunityValuesCompletedAt) {
+			s.IsCommunityValuesRequired = true
+			break
+		}
+	}
 }
 
 type GuildEnforcementRecord struct {
-	ID                      string    `json:"id"`
-	EnforcerUserID          string    `json:"enforcer_id"`
-	CreatedAt               time.Time `json:"created_at"`
-	SuspensionNotice        string    `json:"suspension_notice"`
-	SuspensionExpiry        time.Time `json:"suspension_expiry"`
-	CommunityValuesRequired bool      `json:"community_values_required"`
-	Notes                   string    `json:"notes"`
-	IsVoid                  bool      `json:"is_void"`
+	ID                      string                `json:"id"`
+	EnforcerUserID          string                `json:"enforcer_id"`
+	CreatedAt               time.Time             `json:"created_at"`
+	SuspensionNotice        string                `json:"suspension_notice"`
+	SuspensionExpiry        time.Time             `json:"suspension_expiry"`
+	CommunityValuesRequired bool                  `json:"community_values_required"`
+	Notes                   string                `json:"notes"`
+	IsVoid                  bool                  `json:"is_void"`
+	ActionType              EnforcementActionType `json:"action_type,omitempty"` // Empty for suspensions
 }
 
 func NewGuildEnforcementRecord(enforcerUserID string, suspensionNotice, notes string, requireCommunityValues bool, suspensionExpiry time.Time) *GuildEnforcementRecord {
 	return &GuildEnforcementRecord{
-		ID:               uuid.Must(uuid.NewV4()).String(),
-		EnforcerUserID:   enforcerUserID,
-		CreatedAt:        time.Now(),
-		SuspensionNotice: suspensionNotice,
-		SuspensionExpiry: suspensionExpiry,
-		Notes:            notes,
+		ID:                      uuid.Must(uuid.NewV4()).String(),
+		EnforcerUserID:          enforcerUserID,
+		CreatedAt:               time.Now(),
+		SuspensionNotice:        suspensionNotice,
+		SuspensionExpiry:        suspensionExpiry,
+		CommunityValuesRequired: requireCommunityValues,
+		Notes:                   notes,
 	}
 }
 
@@ -138,8 +261,21 @@ func (s *GuildEnforcementRecord) IsExpired() bool {
 	return time.Now().After(s.SuspensionExpiry)
 }
 
+// Action returns the action type of the record. Records from before action types are suspensions.
+func (s *GuildEnforcementRecord) Action() EnforcementActionType {
+	if s.ActionType == "" {
+		return EnforcementActionSuspension
+	}
+	return s.ActionType
+}
+
+// IsActive returns true if the record's action is in effect.
+func (s *GuildEnforcementRecord) IsActive() bool {
+	return !s.IsVoid && time.Now().Before(s.SuspensionExpiry)
+}
+
 func (s *GuildEnforcementRecord) IsSuspended() bool {
-	return time.Now().Before(s.SuspensionExpiry)
+	return s.Action() == EnforcementActionSuspension && s.IsActive()
 }
 
 func (s *GuildEnforcementRecord) RequiresCommunityValues() bool {
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+func TestGuildEnforcementRecordAction(t *testing.T) {
+	record := NewGuildEnforcementRecord("enforcer", "notice", "", false, time.Now().Add(time.Hour))
+	if record.Action() != EnforcementActionSuspension || !record.IsSuspended() {
+		t.Error("expected a record without an action type to be a suspension")
+	}
+
+	record.ActionType = EnforcementActionVoiceMute
+	if record.IsSuspended() || !record.IsActive() {
+		t.Error("expected a voice mute to be active, but not a suspension")
+	}
+}
+
+func TestGuildEnforcementRecordsLobbyRestriction(t *testing.T) {
+	newRecords := func(actionType EnforcementActionType) *GuildEnforcementRecords {
+		records := NewGuildEnforcementRecords("user", "group")
+		record := NewGuildEnforcementRecord("enforcer", "notice", "", false, time.Now().Add(time.Hour))
+		record.ActionType = actionType
+		records.AddRecord(record)
+		return records
+	}
+
+	tests := []struct {
+		action  EnforcementActionType
+		mode    evr.Symbol
+		role    int
+		blocked bool
+	}{
+		{EnforcementActionVoiceMute, evr.ModeArenaPublic, evr.TeamBlue, false},
+		{EnforcementActionSpectatorOnly, evr.ModeArenaPublic, evr.TeamBlue, true},
+		{EnforcementActionSpectatorOnly, evr.ModeArenaPrivate, evr.TeamUnassigned, true},
+		{EnforcementActionSpectatorOnly, evr.ModeArenaPublic, evr.TeamSpectator, false},
+		{EnforcementActionSpectatorOnly, evr.ModeSocialPublic, evr.TeamSocial, false},
+		{EnforcementActionPrivateOnly, evr.ModeSocialPublic, evr.TeamSocial, true},
+		{EnforcementActionPrivateOnly, evr.ModeCombatPublic, evr.TeamSpectator, true},
+		{EnforcementActionPrivateOnly, evr.ModeArenaPrivate, evr.TeamBlue, false},
+		{EnforcementActionMatchmakingCooldown, evr.ModeCombatPublic, evr.TeamUnassigned, true},
+		{EnforcementActionMatchmakingCooldown, evr.ModeCombatPrivate, evr.TeamUnassigned, false},
+		{EnforcementActionMatchmakingCooldown, evr.ModeSocialPublic, evr.TeamSocial, false},
+	}
+	for _, tt := range tests {
+		if got := newRecords(tt.action).LobbyRestriction(tt.mode, tt.role); (got != nil) != tt.blocked {
+			t.Errorf("%s in %s as %d: expected blocked %v", tt.action, tt.mode.String(), tt.role, tt.blocked)
+		}
+	}
+
+	records := newRecords(EnforcementActionPrivateOnly)
+	records.Records[0].IsVoid = true
+	if records.LobbyRestriction(evr.ModeSocialPublic, evr.TeamSocial) != nil {
+		t.Error("expected a voided restriction to be ignored")
+	}
+}
+
+func TestGuildEnforcementRecordsUpdateSummary(t *testing.T) {
+	records := NewGuildEnforcementRecords("user", "group")
+	expiry := time.Now().Add(time.Hour).UTC()
+	records.AddRecord(NewGuildEnforcementRecord("enforcer", "notice", "", true, expiry))
+	voided := NewGuildEnforcementRecord("enforcer", "notice", "", false, expiry.Add(time.Hour))
+	voided.IsVoid = true
+	records.AddRecord(voided)
+
+	// Marshaling does not change the summary.
+	if _, err := json.Marshal(records); err != nil {
+		t.Fatal(err)
+	}
+	if !records.SuspensionExpiry.IsZero() || records.IsCommunityValuesRequired {
+		t.Fatalf("expected the summary to be unchanged by marshaling: %+v", records)
+	}
+
+	records.UpdateSummary()
+	if !records.SuspensionExpiry.Equal(expiry) || !records.IsCommunityValuesRequired {
+		t.Errorf("expected the voided record to be ignored: %+v", records)
+	}
+
+	records.CommunityValuesCompletedAt = time.Now().Add(time.Minute)
+	records.UpdateSummary()
+	if records.IsCommunityValuesRequired {
+		t.Error("expected the community values to be completed")
+	}
+}
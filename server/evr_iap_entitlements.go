@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageCollectionIAPLedger = "IAPLedger"
+	StorageKeyIAPLedger        = "ledger"
+
+	// The wallet holds the reconciled state; the ledger records how it was reached.
+	IAPWalletEchoPoints = "echopoints"
+	iapWalletSKUPrefix  = "iap:"
+
+	// The most recent entries kept in the ledger object. The wallet ledger keeps the full history.
+	iapLedgerMaxEntries = 500
+)
+
+type IAPLedgerAction string
+
+const (
+	IAPLedgerActionGrant    IAPLedgerAction = "grant"
+	IAPLedgerActionRevoke   IAPLedgerAction = "revoke"
+	IAPLedgerActionPurchase IAPLedgerAction = "purchase" // A SKU bought from the store, for its price in echo points
+)
+
+// The config resources that list the store's SKUs and their prices.
+var iapStoreConfigTypes = []string{"active_store_featured_entry", "active_store_entry"}
+
+var (
+	ErrIAPEmptyEntry          = errors.New("the entry must grant or revoke a SKU or echo points")
+	ErrIAPInvalidAction       = errors.New("invalid ledger action")
+	ErrIAPSKUOwned            = errors.New("the SKU is already owned")
+	ErrIAPSKUNotOwned         = errors.New("the SKU is not owned")
+	ErrIAPInsufficientBalance = errors.New("insufficient echo points")
+)
+
+var _ = VersionedStorable(&IAPLedger{})
+
+// IAPLedgerEntry is a single grant or revocation of a SKU and/or echo points.
+type IAPLedgerEntry struct {
+	TransactionID int64           `json:"transaction_id"`
+	Time          time.Time       `json:"time"`
+	Action        IAPLedgerAction `json:"action"`
+	EvrID         evr.EvrId       `json:"evr_id"` // The EVR ID that the entry was made for
+	SKU           string          `json:"sku,omitempty"`
+	EchoPoints    int64           `json:"echo_points,omitempty"`
+	ActorUserID   string          `json:"actor_id,omitempty"`
+	Reason        string          `json:"reason,omitempty"`
+}
+
+// IAPLedger is the history of the store entitlements granted to a user, across all of their EVR IDs.
+// It is kept with the user's wallet, which holds the entitlements. Entries are only ever appended.
+type IAPLedger struct {
+	UserID        string           `json:"user_id"`
+	TransactionID int64            `json:"transaction_id"` // The ID of the last transaction
+	Entries       []IAPLedgerEntry `json:"entries"`
+
+	version string
+}
+
+func NewIAPLedger(userID string) *IAPLedger {
+	return &IAPLedger{
+		UserID:  userID,
+		Entries: make([]IAPLedgerEntry, 0),
+	}
+}
+
+func (l IAPLedger) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionIAPLedger,
+		Key:             StorageKeyIAPLedger,
+		PermissionRead:  runtime.STORAGE_PERMISSION_OWNER_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         l.version,
+	}
+}
+
+func (l *IAPLedger) SetStorageVersion(userID, version string) {
+	l.UserID = userID
+	l.version = version
+}
+
+// Apply validates the entry against the wallet, and appends it to the ledger with the next transaction ID.
+// It returns the wallet changeset that applies the entry.
+func (l *IAPLedger) Apply(wallet map[string]int64, entry *IAPLedgerEntry) (map[string]int64, error) {
+	if entry.SKU == "" && entry.EchoPoints == 0 {
+		return nil, ErrIAPEmptyEntry
+	} else if entry.EchoPoints < 0 {
+		return nil, status.Error(codes.InvalidArgument, "echo points must not be negative")
+	}
+
+	changeset := make(map[string]int64, 2)
+	switch entry.Action {
+	case IAPLedgerActionGrant:
+		if entry.SKU != "" {
+			if wallet[iapWalletSKUPrefix+entry.SKU] > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrIAPSKUOwned, entry.SKU)
+			}
+			changeset[iapWalletSKUPrefix+entry.SKU] = 1
+		}
+		if entry.EchoPoints > 0 {
+			changeset[IAPWalletEchoPoints] = entry.EchoPoints
+		}
+
+	case IAPLedgerActionPurchase:
+		// The echo points are the price, which is debited from the balance.
+		if entry.SKU == "" {
+			return nil, status.Error(codes.InvalidArgument, "a purchase requires a SKU")
+		}
+		if wallet[iapWalletSKUPrefix+entry.SKU] > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrIAPSKUOwned, entry.SKU)
+		}
+		if wallet[IAPWalletEchoPoints] < entry.EchoPoints {
+			return nil, ErrIAPInsufficientBalance
+		}
+		changeset[iapWalletSKUPrefix+entry.SKU] = 1
+		if entry.EchoPoints > 0 {
+			changeset[IAPWalletEchoPoints] = -entry.EchoPoints
+		}
+
+	case IAPLedgerActionRevoke:
+		if entry.SKU != "" {
+			if wallet[iapWalletSKUPrefix+entry.SKU] <= 0 {
+				return nil, fmt.Errorf("%w: %s", ErrIAPSKUNotOwned, entry.SKU)
+			}
+			changeset[iapWalletSKUPrefix+entry.SKU] = -wallet[iapWalletSKUPrefix+entry.SKU]
+		}
+		if entry.EchoPoints > 0 {
+			if wallet[IAPWalletEchoPoints] < entry.EchoPoints {
+				return nil, ErrIAPInsufficientBalance
+			}
+			changeset[IAPWalletEchoPoints] = -entry.EchoPoints
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrIAPInvalidAction, entry.Action)
+	}
+
+	l.TransactionID++
+	entry.TransactionID = l.TransactionID
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	l.Entries = append(l.Entries, *entry)
+	if len(l.Entries) > iapLedgerMaxEntries {
+		l.Entries = l.Entries[len(l.Entries)-iapLedgerMaxEntries:]
+	}
+
+	return changeset, nil
+}
+
+// IAPStorePrice returns the price in echo points of the SKU in the store resource.
+func IAPStorePrice(store map[string]interface{}, sku string) (int64, bool) {
+	slots, _ := store["store_slots"].([]interface{})
+	for _, slot := range slots {
+		slot, _ := slot.(map[string]interface{})
+		items, _ := slot["reward_items"].([]interface{})
+		for _, item := range items {
+			item, _ := item.(map[string]interface{})
+			bundle, _ := item["bundle"].(map[string]interface{})
+			if bundle["sku"] != sku {
+				continue
+			}
+			price, _ := bundle["price"].(map[string]interface{})
+			if echoPoints, ok := price["echopoints"].(float64); ok && echoPoints >= 0 {
+				return int64(echoPoints), true
+			}
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// IAPEntitlements is the reconciled state of a user's store purchases.
+type IAPEntitlements struct {
+	TransactionID int64    `json:"transaction_id"`
+	EchoPoints    int64    `json:"echo_points"`
+	SKUs          []string `json:"skus"`
+}
+
+func newIAPEntitlements(ledger *IAPLedger, wallet map[string]int64) *IAPEntitlements {
+	skus := make([]string, 0)
+	for k, v := range wallet {
+		if sku, ok := strings.CutPrefix(k, iapWalletSKUPrefix); ok && v > 0 {
+			skus = append(skus, sku)
+		}
+	}
+	slices.Sort(skus)
+
+	return &IAPEntitlements{
+		TransactionID: ledger.TransactionID,
+		EchoPoints:    wallet[IAPWalletEchoPoints],
+		SKUs:          skus,
+	}
+}
+
+func iapWalletLoad(ctx context.Context, nk runtime.NakamaModule, userID string) (map[string]int64, error) {
+	account, err := nk.AccountGetId(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	wallet := make(map[string]int64)
+	if err := json.Unmarshal([]byte(account.Wallet), &wallet); err != nil {
+		return nil, status.Error(codes.Internal, "failed to unmarshal wallet")
+	}
+	return wallet, nil
+}
+
+func iapLedgerLoad(ctx context.Context, nk runtime.NakamaModule, userID string) (*IAPLedger, error) {
+	ledger := NewIAPLedger(userID)
+	if err := StorageRead(ctx, nk, userID, ledger, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		ledger.version = "*"
+	}
+	return ledger, nil
+}
+
+// IAPEntitlementsLoad returns the reconciled echo points balance and owned SKUs of the user.
+func IAPEntitlementsLoad(ctx context.Context, nk runtime.NakamaModule, userID string) (*IAPEntitlements, error) {
+	ledger, err := iapLedgerLoad(ctx, nk, userID)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := iapWalletLoad(ctx, nk, userID)
+	if err != nil {
+		return nil, err
+	}
+	return newIAPEntitlements(ledger, wallet), nil
+}
+
+// IAPLedgerApply records the entry in the user's ledger, and applies it to the user's wallet.
+// The ledger and the wallet are updated together, so they can not disagree.
+func IAPLedgerApply(ctx context.Context, nk runtime.NakamaModule, userID string, entry IAPLedgerEntry) (*IAPEntitlements, error) {
+	var err error
+	for range 3 {
+		var ledger *IAPLedger
+		if ledger, err = iapLedgerLoad(ctx, nk, userID); err != nil {
+			return nil, err
+		}
+		var wallet map[string]int64
+		if wallet, err = iapWalletLoad(ctx, nk, userID); err != nil {
+			return nil, err
+		}
+
+		e := entry
+		var changeset map[string]int64
+		if changeset, err = ledger.Apply(wallet, &e); err != nil {
+			return nil, err
+		}
+
+		var data []byte
+		if data, err = json.Marshal(ledger); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal the ledger: %v", err)
+		}
+		meta := ledger.StorageMeta()
+
+		storageWrites := []*runtime.StorageWrite{{
+			Collection:      meta.Collection,
+			Key:             meta.Key,
+			UserID:          userID,
+			Value:           string(data),
+			Version:         meta.Version,
+			PermissionRead:  meta.PermissionRead,
+			PermissionWrite: meta.PermissionWrite,
+		}}
+
+		walletUpdates := []*runtime.WalletUpdate{{
+			UserID:    userID,
+			Changeset: changeset,
+			Metadata: map[string]interface{}{
+				"evr_id":         e.EvrID.String(),
+				"transaction_id": e.TransactionID,
+				"action":         e.Action,
+				"sku":            e.SKU,
+				"actor_id":       e.ActorUserID,
+				"reason":         e.Reason,
+			},
+		}}
+
+		if _, _, err = nk.MultiUpdate(ctx, nil, storageWrites, nil, walletUpdates, true); err == nil {
+			for k, v := range changeset {
+				wallet[k] += v
+			}
+			return newIAPEntitlements(ledger, wallet), nil
+		} else if !errors.Is(err, runtime.ErrStorageRejectedVersion) {
+			return nil, fmt.Errorf("failed to update the ledger: %w", err)
+		}
+	}
+	return nil, errors.Join(errors.New("failed to update the ledger"), err)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+func TestIAPLedgerApply(t *testing.T) {
+	ledger := NewIAPLedger("user")
+	wallet := map[string]int64{}
+
+	apply := func(entry IAPLedgerEntry) error {
+		changeset, err := ledger.Apply(wallet, &entry)
+		if err != nil {
+			return err
+		}
+		for k, v := range changeset {
+			wallet[k] += v
+		}
+		return nil
+	}
+
+	if err := apply(IAPLedgerEntry{Action: IAPLedgerActionGrant, SKU: "rwd_tag_0001", EchoPoints: 500}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apply(IAPLedgerEntry{Action: IAPLedgerActionGrant, SKU: "rwd_tag_0001"}); !errors.Is(err, ErrIAPSKUOwned) {
+		t.Errorf("expected ErrIAPSKUOwned, got %v", err)
+	}
+	if err := apply(IAPLedgerEntry{Action: IAPLedgerActionPurchase, SKU: "rwd_tag_0001"}); !errors.Is(err, ErrIAPSKUOwned) {
+		t.Errorf("expected a purchase of an owned SKU to return ErrIAPSKUOwned, got %v", err)
+	}
+	if err := apply(IAPLedgerEntry{Action: IAPLedgerActionRevoke, EchoPoints: 600}); !errors.Is(err, ErrIAPInsufficientBalance) {
+		t.Errorf("expected ErrIAPInsufficientBalance, got %v", err)
+	}
+	if err := apply(IAPLedgerEntry{Action: IAPLedgerActionGrant}); !errors.Is(err, ErrIAPEmptyEntry) {
+		t.Errorf("expected ErrIAPEmptyEntry, got %v", err)
+	}
+	if err := apply(IAPLedgerEntry{Action: IAPLedgerActionRevoke, SKU: "rwd_tag_0001", EchoPoints: 200}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apply(IAPLedgerEntry{Action: IAPLedgerActionRevoke, SKU: "rwd_tag_0001"}); !errors.Is(err, ErrIAPSKUNotOwned) {
+		t.Errorf("expected ErrIAPSKUNotOwned, got %v", err)
+	}
+
+	if ledger.TransactionID != 2 || len(ledger.Entries) != 2 || ledger.Entries[1].TransactionID != 2 {
+		t.Errorf("expected only the applied entries to be recorded, got %+v", ledger.Entries)
+	}
+
+	entitlements := newIAPEntitlements(ledger, wallet)
+	if entitlements.EchoPoints != 300 || len(entitlements.SKUs) != 0 {
+		t.Errorf("expected 300 echo points and no SKUs, got %+v", entitlements)
+	}
+}
+
+func TestIAPLedgerPurchase(t *testing.T) {
+	ledger := NewIAPLedger("user")
+	wallet := map[string]int64{IAPWalletEchoPoints: 1000}
+
+	if _, err := ledger.Apply(wallet, &IAPLedgerEntry{Action: IAPLedgerActionPurchase, SKU: "rwd_tag_0001", EchoPoints: 1200}); !errors.Is(err, ErrIAPInsufficientBalance) {
+		t.Errorf("expected ErrIAPInsufficientBalance, got %v", err)
+	}
+
+	changeset, err := ledger.Apply(wallet, &IAPLedgerEntry{Action: IAPLedgerActionPurchase, SKU: "rwd_tag_0001", EchoPoints: 999})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if changeset[IAPWalletEchoPoints] != -999 || changeset[iapWalletSKUPrefix+"rwd_tag_0001"] != 1 {
+		t.Errorf("expected the price to be debited and the SKU granted, got %v", changeset)
+	}
+}
+
+func TestIAPStorePrice(t *testing.T) {
+	store := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(evr.DefaultActiveStoreFeaturedEntryConfigResource), &store); err != nil {
+		t.Fatal(err)
+	}
+
+	if price, ok := IAPStorePrice(store, "store_rwd_chassis_ranger_a_bundled"); !ok || price != 999 {
+		t.Errorf("expected a price of 999, got %d (%v)", price, ok)
+	}
+	if _, ok := IAPStorePrice(store, "store_rwd_unknown"); ok {
+		t.Error("expected an unknown SKU not to be for sale")
+	}
+}
@@ -1,18 +1,37 @@
 package server
 
 import (
+	"container/list"
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+const (
+	IPInfoCacheDefaultSize = 50000
+	IPInfoCacheDefaultTTL  = time.Hour * 6
+	// The results of the fallback providers, and the IPs that no provider knows, are looked up again sooner.
+	IPInfoCacheFallbackTTL = time.Minute * 10
+)
+
 type IPInfoProvider interface {
 	Name() string
 	Get(ctx context.Context, ip string) (IPInfo, error)
 }
 
+type ipInfoCacheEntry struct {
+	ip        string
+	info      IPInfo // Nil if no provider knows the IP
+	expiresAt time.Time
+}
+
 type IPInfoCache struct {
+	sync.Mutex
 	ctx      context.Context
 	cancelFn context.CancelFunc
 
@@ -20,6 +39,13 @@ type IPInfoCache struct {
 	metrics Metrics
 
 	clients []IPInfoProvider
+
+	// The most recently used results, most recent first.
+	size        int
+	ttl         time.Duration
+	fallbackTTL time.Duration
+	entries     map[string]*list.Element
+	lru         *list.List
 }
 
 func NewIPInfoCache(logger *zap.Logger, metrics Metrics, clients ...IPInfoProvider) (*IPInfoCache, error) {
@@ -33,11 +59,57 @@ func NewIPInfoCache(logger *zap.Logger, metrics Metrics, clients ...IPInfoProvid
 		metrics: metrics,
 
 		clients: clients,
+
+		size:        IPInfoCacheDefaultSize,
+		ttl:         IPInfoCacheDefaultTTL,
+		fallbackTTL: IPInfoCacheFallbackTTL,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
 	}
 
 	return &ipqs, nil
 }
 
+// load returns the cached result, if it has not expired.
+func (s *IPInfoCache) load(ip string) (IPInfo, bool) {
+	s.Lock()
+	defer s.Unlock()
+	e, ok := s.entries[ip]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*ipInfoCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.lru.Remove(e)
+		delete(s.entries, ip)
+		return nil, false
+	}
+	s.lru.MoveToFront(e)
+	return entry.info, true
+}
+
+func (s *IPInfoCache) store(ip string, info IPInfo, ttl time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	expiresAt := time.Now().Add(ttl)
+	if e, ok := s.entries[ip]; ok {
+		entry := e.Value.(*ipInfoCacheEntry)
+		entry.info = info
+		entry.expiresAt = expiresAt
+		s.lru.MoveToFront(e)
+		return
+	}
+	s.entries[ip] = s.lru.PushFront(&ipInfoCacheEntry{ip, info, expiresAt})
+
+	for s.lru.Len() > s.size {
+		e := s.lru.Back()
+		s.lru.Remove(e)
+		delete(s.entries, e.Value.(*ipInfoCacheEntry).ip)
+	}
+}
+
+// Get returns the result of the first provider that knows the IP. If none does, it returns the providers' errors, or
+// nil if none failed.
 func (s *IPInfoCache) Get(ctx context.Context, ip string) (IPInfo, error) {
 
 	// ignore reserved IPs
@@ -45,18 +117,35 @@ func (s *IPInfoCache) Get(ctx context.Context, ip string) (IPInfo, error) {
 		return &StubIPInfo{}, nil
 	}
 
-	errored := make(map[string]error, 0)
+	if result, ok := s.load(ip); ok {
+		s.metrics.CustomCounter("ipinfo_cache_count", map[string]string{"result": "hit", "provider": ""}, 1)
+		return result, nil
+	}
+
+	errs := make([]error, 0, len(s.clients))
 
-	for _, client := range s.clients {
+	for i, client := range s.clients {
 		result, err := client.Get(ctx, ip)
 		if err != nil {
-			errored[client.Name()] = err
+			errs = append(errs, fmt.Errorf("%s: %w", client.Name(), err))
 			continue
 		}
 		if result != nil {
+			s.metrics.CustomCounter("ipinfo_cache_count", map[string]string{"result": "miss", "provider": client.Name()}, 1)
+			if i == 0 {
+				s.store(ip, result, s.ttl)
+			} else {
+				s.store(ip, result, s.fallbackTTL)
+			}
 			return result, nil
 		}
 	}
+
+	s.metrics.CustomCounter("ipinfo_cache_count", map[string]string{"result": "not_found", "provider": ""}, 1)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	s.store(ip, nil, s.fallbackTTL)
 	return nil, nil
 }
 
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+type testIPInfoProvider struct {
+	name  string
+	infos map[string]IPInfo
+	err   error
+	calls int
+}
+
+func (p *testIPInfoProvider) Name() string {
+	return p.name
+}
+
+func (p *testIPInfoProvider) Get(ctx context.Context, ip string) (IPInfo, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.infos[ip], nil
+}
+
+func TestIPInfoCache(t *testing.T) {
+	info := &StubIPInfo{}
+	first := &testIPInfoProvider{name: "first", infos: map[string]IPInfo{}}
+	second := &testIPInfoProvider{name: "second", infos: map[string]IPInfo{"1.1.1.1": info, "8.8.8.8": info, "9.9.9.9": info}}
+
+	cache, err := NewIPInfoCache(logger, metrics, first, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.size = 2
+
+	// The second provider is used when the first does not know the IP, and the result is cached.
+	for i := 0; i < 2; i++ {
+		if got, err := cache.Get(context.Background(), "1.1.1.1"); err != nil || got != info {
+			t.Fatalf("unexpected result: %v, %v", got, err)
+		}
+	}
+	if first.calls != 1 || second.calls != 1 {
+		t.Errorf("expected one lookup per provider, got %d and %d", first.calls, second.calls)
+	}
+
+	// The least recently used result is evicted.
+	cache.Get(context.Background(), "8.8.8.8")
+	cache.Get(context.Background(), "1.1.1.1")
+	cache.Get(context.Background(), "9.9.9.9")
+	if _, ok := cache.load("8.8.8.8"); ok {
+		t.Errorf("expected 8.8.8.8 to be evicted")
+	}
+	if _, ok := cache.load("1.1.1.1"); !ok {
+		t.Errorf("expected 1.1.1.1 to be cached")
+	}
+
+	// Expired results are looked up again.
+	cache.store("1.1.1.1", info, -time.Second)
+	if _, ok := cache.load("1.1.1.1"); ok {
+		t.Errorf("expected 1.1.1.1 to be expired")
+	}
+
+	// IPs that no provider knows are cached.
+	if got, err := cache.Get(context.Background(), "5.5.5.5"); err != nil || got != nil {
+		t.Fatalf("unexpected result: %v, %v", got, err)
+	}
+	if got, ok := cache.load("5.5.5.5"); !ok || got != nil {
+		t.Errorf("expected 5.5.5.5 to be cached as unknown")
+	}
+
+	// An error is returned when no provider knows the IP, and any provider failed.
+	first.err = errors.New("unavailable")
+	second.infos["2.2.2.2"] = info
+	if got, err := cache.Get(context.Background(), "2.2.2.2"); err != nil || got != info {
+		t.Errorf("expected the second provider's result: %v, %v", got, err)
+	}
+	if _, err := cache.Get(context.Background(), "4.4.4.4"); err == nil {
+		t.Errorf("expected an error when a provider fails and the others do not know the IP")
+	}
+	if _, ok := cache.load("4.4.4.4"); ok {
+		t.Errorf("expected 4.4.4.4 not to be cached after a failure")
+	}
+}
+
+func TestMMDBData(t *testing.T) {
+	city := &geoip2.City{}
+	city.City.Names = map[string]string{"en": "Los Angeles"}
+	city.Country.IsoCode = "US"
+	city.Location.Latitude = 34.05
+	city.Location.Longitude = -118.24
+	city.Subdivisions = make([]struct {
+		Names     map[string]string `maxminddb:"names"`
+		IsoCode   string            `maxminddb:"iso_code"`
+		GeoNameID uint              `maxminddb:"geoname_id"`
+	}, 1)
+	city.Subdivisions[0].IsoCode = "CA"
+
+	data := &mmdbData{city: city}
+	if data.City() != "Los Angeles" || data.Region() != "CA" || data.CountryCode() != "US" || data.GeoHash(2) != "9q" {
+		t.Errorf("unexpected location: %s, %s, %s, %s", data.City(), data.Region(), data.CountryCode(), data.GeoHash(2))
+	}
+	if data.ASN() != 0 || data.Organization() != "" {
+		t.Errorf("expected no ASN without an ASN database")
+	}
+
+	data.asn = &geoip2.ASN{AutonomousSystemNumber: 7018, AutonomousSystemOrganization: "AT&T"}
+	if data.ASN() != 7018 || data.ISP() != "AT&T" {
+		t.Errorf("unexpected ASN: %d, %s", data.ASN(), data.ISP())
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mmcloughlin/geohash"
+	"github.com/oschwald/geoip2-golang"
+	"go.uber.org/zap"
+)
+
+// How often the database files are checked for changes.
+const mmdbReloadInterval = time.Minute
+
+var _ = IPInfo(&mmdbData{})
+
+type mmdbData struct {
+	city *geoip2.City
+	asn  *geoip2.ASN // Nil without an ASN database
+}
+
+func (r *mmdbData) DataProvider() string {
+	return "MaxMind"
+}
+
+// IsVPN is always false; the GeoLite2 databases have no anonymizer data.
+func (r *mmdbData) IsVPN() bool {
+	return false
+}
+
+func (r *mmdbData) Latitude() float64 {
+	return r.city.Location.Latitude
+}
+
+func (r *mmdbData) Longitude() float64 {
+	return r.city.Location.Longitude
+}
+
+func (r *mmdbData) City() string {
+	return r.city.City.Names["en"]
+}
+
+func (r *mmdbData) Region() string {
+	if len(r.city.Subdivisions) == 0 {
+		return ""
+	}
+	return r.city.Subdivisions[0].IsoCode
+}
+
+func (r *mmdbData) CountryCode() string {
+	return r.city.Country.IsoCode
+}
+
+func (r *mmdbData) GeoHash(geoPrecision uint) string {
+	return geohash.EncodeWithPrecision(r.Latitude(), r.Longitude(), geoPrecision)
+}
+
+func (r *mmdbData) ASN() int {
+	if r.asn == nil {
+		return 0
+	}
+	return int(r.asn.AutonomousSystemNumber)
+}
+
+// FraudScore is always zero; the GeoLite2 databases have no fraud data.
+func (r *mmdbData) FraudScore() int {
+	return 0
+}
+
+func (r *mmdbData) ISP() string {
+	return r.Organization()
+}
+
+func (r *mmdbData) Organization() string {
+	if r.asn == nil {
+		return ""
+	}
+	return r.asn.AutonomousSystemOrganization
+}
+
+type mmdbFile struct {
+	path    string
+	modTime time.Time
+	reader  *geoip2.Reader
+}
+
+func openMMDBFile(path string) (*mmdbFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &mmdbFile{path, info.ModTime(), reader}, nil
+}
+
+var _ = IPInfoProvider(&MMDBClient{})
+
+// MMDBClient looks up IPs in local MaxMind GeoLite2 City and ASN databases, and reloads them when the files change.
+type MMDBClient struct {
+	sync.RWMutex
+	ctx      context.Context
+	cancelFn context.CancelFunc
+
+	logger  *zap.Logger
+	metrics Metrics
+
+	city *mmdbFile
+	asn  *mmdbFile // Optional
+}
+
+func NewMMDBClient(logger *zap.Logger, metrics Metrics, cityPath, asnPath string) (*MMDBClient, error) {
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	client := MMDBClient{
+		ctx:      ctx,
+		cancelFn: cancelFn,
+
+		logger:  logger,
+		metrics: metrics,
+	}
+
+	var err error
+	if client.city, err = openMMDBFile(cityPath); err != nil {
+		cancelFn()
+		return nil, err
+	}
+	if asnPath != "" {
+		if client.asn, err = openMMDBFile(asnPath); err != nil {
+			client.city.reader.Close()
+			cancelFn()
+			return nil, err
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(mmdbReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-client.ctx.Done():
+				return
+			case <-ticker.C:
+				client.reload()
+			}
+		}
+	}()
+
+	return &client, nil
+}
+
+func (s *MMDBClient) Name() string {
+	return "MaxMind"
+}
+
+// reload reopens the databases that have been modified since they were opened.
+func (s *MMDBClient) reload() {
+	for _, f := range []**mmdbFile{&s.city, &s.asn} {
+		s.RLock()
+		current := *f
+		s.RUnlock()
+		if current == nil {
+			continue
+		}
+
+		if info, err := os.Stat(current.path); err != nil || !info.ModTime().After(current.modTime) {
+			continue
+		}
+
+		updated, err := openMMDBFile(current.path)
+		if err != nil {
+			// The file may still be being written.
+			s.logger.Warn("Failed to reload MaxMind database", zap.String("path", current.path), zap.Error(err))
+			continue
+		}
+
+		s.Lock()
+		*f = updated
+		s.Unlock()
+		current.reader.Close()
+
+		s.logger.Info("Reloaded MaxMind database", zap.String("path", current.path), zap.Time("modified", updated.modTime))
+	}
+}
+
+func (s *MMDBClient) Get(ctx context.Context, ip string) (IPInfo, error) {
+	if s == nil {
+		return nil, nil
+	}
+	// ignore reserved IPs
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, errors.New("invalid IP address")
+	} else if addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsMulticast() || addr.IsPrivate() {
+		return &StubIPInfo{}, nil
+	}
+	startTime := time.Now()
+	metricsTags := map[string]string{"result": "hit"}
+
+	defer func() {
+		s.metrics.CustomTimer("mmdb_lookup_duration", metricsTags, time.Since(startTime))
+	}()
+
+	s.RLock()
+	defer s.RUnlock()
+
+	city, err := s.city.reader.City(addr)
+	if err != nil {
+		metricsTags["result"] = "error"
+		return nil, err
+	}
+	// Unknown IPs are returned empty, so the next provider is tried.
+	if city.Country.IsoCode == "" {
+		metricsTags["result"] = "miss"
+		return nil, nil
+	}
+
+	data := &mmdbData{city: city}
+	if s.asn != nil {
+		if asn, err := s.asn.reader.ASN(addr); err != nil {
+			s.logger.Debug("Failed to look up ASN", zap.String("ip", ip), zap.Error(err))
+		} else {
+			data.asn = asn
+		}
+	}
+	return data, nil
+}
+
+func (s *MMDBClient) Stop() {
+	s.cancelFn()
+
+	s.Lock()
+	defer s.Unlock()
+	s.city.reader.Close()
+	if s.asn != nil {
+		s.asn.reader.Close()
+	}
+}
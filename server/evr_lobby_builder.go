@@ -24,6 +24,9 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// The most parties that are searched for balanced teams; beyond this, the parties are distributed greedily.
+const balanceTeamsMaxParties = 12
+
 // Builds the match after the matchmaker has created it
 type LobbyBuilder struct {
 	sync.Mutex
@@ -57,7 +60,7 @@ func NewLobbyBuilder(logger *zap.Logger, nk runtime.NakamaModule, sessionRegistr
 func (b *LobbyBuilder) handleMatchedEntries(entries [][]*MatchmakerEntry) {
 	// build matches one at a time.
 	for _, entrants := range entries {
-		if _, err := b.buildMatch(b.logger, entrants); err != nil {
+		if _, err := b.buildMatch(b.logger, entrants, nil); err != nil {
 			b.logger.With(zap.Any("entries", entries)).Error("Failed to build match", zap.Error(err))
 			return
 		}
@@ -149,7 +152,7 @@ func (b *LobbyBuilder) groupByTicket(entrants []*MatchmakerEntry) [][]*Matchmake
 	return parties
 }
 
-func (b *LobbyBuilder) buildMatch(logger *zap.Logger, entrants []*MatchmakerEntry) (matchID *MatchID, err error) {
+func (b *LobbyBuilder) buildMatch(logger *zap.Logger, entrants []*MatchmakerEntry, alignments TeamAlignments) (matchID *MatchID, err error) {
 	// Build matches one at a time.
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -172,7 +175,26 @@ func (b *LobbyBuilder) buildMatch(logger *zap.Logger, entrants []*MatchmakerEntr
 		teams[i/teamSize] = append(teams[i/teamSize], e)
 	}
 
-	// Split the entrants into teams, half and half
+	// The balanced teams are kept for the players that rejoin the match.
+	var teamAlignments map[string]int
+	if settings := ServiceSettings(); settings != nil && settings.Matchmaking.EnableTeamBalancing {
+		// Rebalance the teams by predicted outcome, keeping the parties together if possible.
+		parties := b.groupByTicket(entrants)
+		if balanced, _, ok := b.balanceTeams(parties, alignments); ok {
+			teams = balanced
+		} else {
+			distributed := b.distributeParties(parties)
+			teams = [2][]*MatchmakerEntry{distributed[0], distributed[1]}
+		}
+
+		teamAlignments = make(map[string]int, len(entrants))
+		for teamIndex, players := range teams {
+			for _, e := range players {
+				teamAlignments[e.Presence.GetUserId()] = teamIndex
+			}
+		}
+	}
+	predictedDraw := predictTeamsDraw(teams)
 
 	entrantPresences := make([]*EvrMatchPresence, 0, len(entrants))
 	sessions := make([]Session, 0, len(entrants))
@@ -187,12 +209,7 @@ func (b *LobbyBuilder) buildMatch(logger *zap.Logger, entrants []*MatchmakerEntr
 				continue
 			}
 
-			mu := entry.NumericProperties["rating_mu"]
-			sigma := entry.NumericProperties["rating_sigma"]
-			rating := rating.NewWithOptions(&types.OpenSkillOptions{
-				Mu:    &mu,
-				Sigma: &sigma,
-			})
+			rating := matchmakerEntryRating(entry)
 
 			percentile, ok := entry.NumericProperties["rank_percentile"]
 			if !ok {
@@ -233,6 +250,8 @@ func (b *LobbyBuilder) buildMatch(logger *zap.Logger, entrants []*MatchmakerEntr
 		Reservations:        entrantPresences,
 		ReservationLifetime: 20 * time.Second,
 		StartTime:           time.Now().UTC(),
+		TeamAlignments:      teamAlignments,
+		PredictedDraw:       predictedDraw,
 	}
 
 	var label *MatchLabel
@@ -312,7 +331,7 @@ func (b *LobbyBuilder) buildMatch(logger *zap.Logger, entrants []*MatchmakerEntr
 	b.metrics.CustomCounter("lobby_join_match_made", tags, int64(len(successful)))
 	b.metrics.CustomCounter("lobby_error_match_made", tags, int64(len(errored)))
 
-	logger.Info("Match built.", zap.String("mid", label.ID.UUID.String()), zap.Any("teams", teams), zap.Any("successful", successful), zap.Any("errored", errored), zap.Any("game_server", label.GameServer))
+	logger.Info("Match built.", zap.String("mid", label.ID.UUID.String()), zap.Any("teams", teams), zap.Float64("predicted_draw", predictedDraw), zap.Any("successful", successful), zap.Any("errored", errored), zap.Any("game_server", label.GameServer))
 	return &label.ID, nil
 }
 
@@ -335,6 +354,99 @@ func (b *LobbyBuilder) groupIDFromEntrants(entrants []*MatchmakerEntry) (uuid.UU
 	return uuid.FromStringOrNil(groupID), nil
 }
 
+// matchmakerEntryRating returns the rating from the entry's properties, or the default rating if it has none.
+func matchmakerEntryRating(e *MatchmakerEntry) types.Rating {
+	mu := e.NumericProperties["rating_mu"]
+	sigma := e.NumericProperties["rating_sigma"]
+	if mu == 0 || sigma == 0 {
+		return NewDefaultRating()
+	}
+	return rating.NewWithOptions(&types.OpenSkillOptions{
+		Mu:    &mu,
+		Sigma: &sigma,
+	})
+}
+
+func teamsRatings(teams [2][]*MatchmakerEntry) []types.Team {
+	ratings := []types.Team{make(types.Team, 0, len(teams[0])), make(types.Team, 0, len(teams[1]))}
+	for i, players := range teams {
+		for _, e := range players {
+			ratings[i] = append(ratings[i], matchmakerEntryRating(e))
+		}
+	}
+	return ratings
+}
+
+// predictTeamsDraw returns the predicted probability of a draw between the teams.
+func predictTeamsDraw(teams [2][]*MatchmakerEntry) float64 {
+	if len(teams[0]) == 0 || len(teams[1]) == 0 {
+		return 0
+	}
+	return rating.PredictDraw(teamsRatings(teams), nil)
+}
+
+// balanceTeams searches the assignments of the parties to the teams for the one that minimizes the difference in the
+// predicted win probabilities. Each party is kept together, and each aligned player is kept on their team.
+// It returns false if there is no such assignment with even teams.
+func (b *LobbyBuilder) balanceTeams(parties [][]*MatchmakerEntry, alignments TeamAlignments) (teams [2][]*MatchmakerEntry, draw float64, ok bool) {
+	if len(parties) == 0 || len(parties) > balanceTeamsMaxParties {
+		return teams, 0, false
+	}
+
+	// Sort the parties, so the result does not depend on their order.
+	parties = slices.Clone(parties)
+	slices.SortStableFunc(parties, func(a, b []*MatchmakerEntry) int {
+		return strings.Compare(a[0].GetTicket(), b[0].GetTicket())
+	})
+
+	// The team that each party must be on, or AnyTeam.
+	total := 0
+	required := make([]TeamIndex, len(parties))
+	for i, party := range parties {
+		total += len(party)
+		required[i] = AnyTeam
+		for _, e := range party {
+			team, found := alignments[e.Presence.GetUserId()]
+			if !found || (TeamIndex(team) != BlueTeam && TeamIndex(team) != OrangeTeam) {
+				continue
+			}
+			if required[i] != AnyTeam && required[i] != TeamIndex(team) {
+				// The party is aligned to both teams.
+				return teams, 0, false
+			}
+			required[i] = TeamIndex(team)
+		}
+	}
+
+	bestSkew := math.Inf(1)
+	for mask := 0; mask < 1<<len(parties); mask++ {
+		// Each bit is the team of the party.
+		var candidate [2][]*MatchmakerEntry
+		valid := true
+		for i, party := range parties {
+			team := TeamIndex(mask >> i & 1)
+			if required[i] != AnyTeam && required[i] != team {
+				valid = false
+				break
+			}
+			candidate[team] = append(candidate[team], party...)
+		}
+		if diff := len(candidate[0]) - len(candidate[1]); !valid || len(candidate[0]) == 0 || len(candidate[1]) == 0 || diff > total%2 || -diff > total%2 {
+			continue
+		}
+
+		probabilities := rating.PredictWin(teamsRatings(candidate), nil)
+		if skew := math.Abs(probabilities[0] - probabilities[1]); skew < bestSkew {
+			bestSkew, teams, ok = skew, candidate, true
+		}
+	}
+
+	if !ok {
+		return teams, 0, false
+	}
+	return teams, predictTeamsDraw(teams), true
+}
+
 func (b *LobbyBuilder) distributeParties(parties [][]*MatchmakerEntry) [][]*MatchmakerEntry {
 	// Distribute the players from each party on the two teams.
 	// Try to keep the parties together, but the teams must be balanced.
@@ -517,6 +629,7 @@ func LobbyGameServerAllocate(ctx context.Context, logger runtime.Logger, nk runt
 
 	qparts := []string{
 		fmt.Sprintf("+label.broadcaster.group_ids:%s", Query.MatchItem(groupIDs)),
+		"-label.broadcaster.draining:T",
 		queryAddon,
 	}
 
@@ -567,6 +680,10 @@ func LobbyGameServerAllocate(ctx context.Context, logger runtime.Logger, nk runt
 		}
 		allServers = append(allServers, label)
 		if label.LobbyType == UnassignedLobby {
+			// Quarantined and draining servers are not allocated.
+			if label.GameServer.Draining || globalBroadcasterRegistry.IsQuarantined(label.GameServer.Endpoint.ExternalAddress()) {
+				continue
+			}
 			availableServers = append(availableServers, label)
 		} else {
 			activeCountByHostID[label.GameServer.Endpoint.GetHostID()]++
@@ -276,3 +276,56 @@ func TestSortLabelIndexes(t *testing.T) {
 		})
 	}
 }
+
+func TestBalanceTeams(t *testing.T) {
+	entry := func(ticket, userID string, mu float64) *MatchmakerEntry {
+		return &MatchmakerEntry{
+			Ticket:            ticket,
+			Presence:          &MatchmakerPresence{UserId: userID},
+			NumericProperties: map[string]float64{"rating_mu": mu, "rating_sigma": 3},
+		}
+	}
+
+	b := &LobbyBuilder{}
+	parties := [][]*MatchmakerEntry{
+		{entry("a", "1", 40), entry("a", "2", 40)},
+		{entry("b", "3", 10)},
+		{entry("c", "4", 10)},
+		{entry("d", "5", 40)},
+		{entry("e", "6", 10)},
+	}
+
+	teamOf := func(teams [2][]*MatchmakerEntry, userID string) int {
+		for i, players := range teams {
+			for _, e := range players {
+				if e.Presence.UserId == userID {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	teams, draw, ok := b.balanceTeams(parties, nil)
+	assert.True(t, ok)
+	assert.Len(t, teams[0], 3)
+	assert.Len(t, teams[1], 3)
+	assert.Greater(t, draw, 0.0)
+	// The party is kept together, and the strong solo player is on the other team.
+	assert.Equal(t, teamOf(teams, "1"), teamOf(teams, "2"))
+	assert.NotEqual(t, teamOf(teams, "1"), teamOf(teams, "5"))
+
+	// The alignments are respected.
+	teams, _, ok = b.balanceTeams(parties, TeamAlignments{"1": int(OrangeTeam), "3": int(OrangeTeam)})
+	assert.True(t, ok)
+	assert.Equal(t, 1, teamOf(teams, "1"))
+	assert.Equal(t, 1, teamOf(teams, "3"))
+
+	// A party aligned to both teams can not be kept together.
+	_, _, ok = b.balanceTeams(parties, TeamAlignments{"1": int(BlueTeam), "2": int(OrangeTeam)})
+	assert.False(t, ok)
+
+	// Parties that can not be split evenly.
+	_, _, ok = b.balanceTeams([][]*MatchmakerEntry{parties[0], {entry("f", "7", 10), entry("f", "8", 10), entry("f", "9", 10), entry("f", "10", 10)}}, nil)
+	assert.False(t, ok)
+}
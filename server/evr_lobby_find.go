@@ -43,6 +43,11 @@ func (p *EvrPipeline) lobbyFind(ctx context.Context, logger *zap.Logger, session
 		return NewLobbyError(BadRequest, fmt.Sprintf("`%s` is an invalid mode for matchmaking.", lobbyParams.Mode.String()))
 	}
 
+	// Enforce the early quit penalty.
+	if err := p.lobbyEarlyQuitPenalty(ctx, logger, lobbyParams); err != nil {
+		return err
+	}
+
 	// Cancel matchmaking after the timeout.
 	ctx, cancel := context.WithTimeoutCause(ctx, lobbyParams.MatchmakingTimeout, ErrMatchmakingTimeout)
 	defer cancel()
@@ -144,6 +149,40 @@ func (p *EvrPipeline) lobbyFind(ctx context.Context, logger *zap.Logger, session
 
 }
 
+// lobbyEarlyQuitPenalty blocks players that are locked out of matchmaking, and places players in the early quitter pool.
+func (p *EvrPipeline) lobbyEarlyQuitPenalty(ctx context.Context, logger *zap.Logger, lobbyParams *LobbySessionParameters) error {
+	if settings := ServiceSettings(); settings == nil || !settings.Matchmaking.EnableEarlyQuitPenalty {
+		return nil
+	}
+
+	policy, _, ok := earlyQuitModePolicy(lobbyParams.Mode)
+	if !ok {
+		return nil
+	}
+
+	statistics, err := EarlyQuitStatisticsLoad(ctx, p.nk, lobbyParams.UserID.String())
+	if err != nil {
+		// Do not block matchmaking if the statistics are unavailable.
+		logger.Warn("Failed to load early quit statistics", zap.Error(err))
+		return nil
+	}
+
+	now := time.Now().UTC()
+	status := statistics.Status(policy, lobbyParams.Mode, now)
+
+	if status.IsLockedOut(now) {
+		return NewLobbyError(BadRequest, fmt.Sprintf("You are restricted from matchmaking for quitting matches early. Try again in %s.", status.LockoutExpiry.Sub(now).Round(time.Second)))
+	}
+
+	if status.QuitterPool {
+		lobbyParams.EarlyQuitterPool = true
+		// Early quitters are not backfilled into matches with other players.
+		lobbyParams.DisableArenaBackfill = true
+	}
+
+	return nil
+}
+
 func (p *EvrPipeline) configureParty(ctx context.Context, logger *zap.Logger, session *sessionWS, lobbyParams *LobbySessionParameters) (*LobbyGroup, []uuid.UUID, bool, error) {
 
 	// Join the party if a player has a party group id set.
@@ -564,7 +603,7 @@ func PrepareEntrantPresences(ctx context.Context, logger *zap.Logger, nk runtime
 			rankPercentile = ServiceSettings().Matchmaking.RankPercentile.Default
 		}
 
-		rating, err := MatchmakingRatingLoad(ctx, nk, session.UserID().String(), lobbyParams.GroupID.String(), mmMode)
+		rating, err := MatchmakingRatingLoad(ctx, nk, session.UserID().String(), lobbyParams.GroupID.String(), mmMode, lobbyParams.RatingSystem)
 		if err != nil {
 			logger.Warn("Failed to load rating", zap.String("sid", sessionID.String()), zap.Error(err))
 			rating = NewDefaultRating()
@@ -12,6 +12,8 @@ import (
 	"github.com/heroiclabs/nakama-common/api"
 	"github.com/heroiclabs/nakama/v3/server/evr"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (p *EvrPipeline) LobbyJoinEntrants(logger *zap.Logger, label *MatchLabel, presences ...*EvrMatchPresence) error {
@@ -150,6 +152,10 @@ func LobbyJoinEntrants(logger *zap.Logger, matchRegistry MatchRegistry, tracker
 		}
 	}
 
+	if params, ok := LoadParams(sessionCtx); ok {
+		params.lobbyGameServer.Store(&LobbyGameServer{MatchID: label.ID, Address: label.GameServer.Endpoint.ExternalAddress()})
+	}
+
 	// Leave any other lobby group stream.
 	tracker.UntrackLocalByModes(session.ID(), map[uint8]struct{}{StreamModeMatchmaking: {}, StreamModeGuildGroup: {}}, guildGroupStream)
 
@@ -229,17 +235,24 @@ func (p *EvrPipeline) lobbyAuthorize(ctx context.Context, logger *zap.Logger, se
 
 		return ErrSuspended
 	}
-	if recordsByGuild, err := EnforcementSuspensionSearch(ctx, p.nk, groupID, []string{userID}, false, false); err != nil {
+	// Search every guild when the guild honours the suspensions of trusted guilds.
+	searchGroupID := groupID
+	if len(gg.TrustedGroupIDs) > 0 {
+		searchGroupID = ""
+	}
+
+	if recordsByGuild, err := EnforcementSuspensionSearch(ctx, p.nk, searchGroupID, []string{userID}, false, false); err != nil {
 		logger.Warn("Unable to read enforcement records", zap.Error(err))
 	} else if len(recordsByGuild) > 0 {
 		var latestRecord *GuildEnforcementRecord
 
-		for _, byUserID := range recordsByGuild {
-			for _, records := range byUserID {
-				for _, r := range records.Records {
-					if latestRecord == nil || r.CreatedAt.After(latestRecord.CreatedAt) && time.Now().Before(r.SuspensionExpiry) {
-						latestRecord = r
-					}
+		if records, ok := recordsByGuild[groupID][userID]; ok {
+			for _, r := range records.Records {
+				if !r.IsSuspended() {
+					continue
+				}
+				if latestRecord == nil || r.CreatedAt.After(latestRecord.CreatedAt) {
+					latestRecord = r
 				}
 			}
 		}
@@ -250,15 +263,51 @@ func (p *EvrPipeline) lobbyAuthorize(ctx context.Context, logger *zap.Logger, se
 			if _, err := p.appBot.LogAuditMessage(ctx, groupID, fmt.Sprintf("Rejected suspended user <@%s> (%s) (expires <t:%d:R>)", userID, latestRecord.SuspensionNotice, latestRecord.SuspensionExpiry.Unix()), true); err != nil {
 				p.logger.Warn("Failed to send audit message", zap.String("channel_id", gg.AuditChannelID), zap.Error(err))
 			}
-			const maxMessageLength = 60
-			message := latestRecord.SuspensionNotice
-			expires := fmt.Sprintf(" [exp: %s]", formatDuration(time.Until(latestRecord.SuspensionExpiry), false))
+			return NewLobbyError(KickedFromLobbyGroup, suspensionLobbyMessage(latestRecord))
+		}
+
+		// Honour the suspensions issued by the guild's trusted guilds.
+		if suspensions := federatedSuspensions(&gg.GroupMetadata, groupID, recordsByGuild, userID); len(suspensions) > 0 {
+			s := suspensions[0]
+
+			sourceName := s.GroupID
+			if sg := p.guildGroupRegistry.Get(s.GroupID); sg != nil {
+				sourceName = sg.Name()
+			}
+
+			switch s.Action {
+			case FederatedSuspensionActionBlock:
+				metricsTags["error"] = "federated_suspended_user"
+				if _, err := p.appBot.LogAuditMessage(ctx, groupID, fmt.Sprintf("Rejected user <@%s> suspended by trusted guild **%s** (%s) (expires <t:%d:R>)", userID, sourceName, s.Record.SuspensionNotice, s.Record.SuspensionExpiry.Unix()), true); err != nil {
+					p.logger.Warn("Failed to send audit message", zap.String("channel_id", gg.AuditChannelID), zap.Error(err))
+				}
+				return NewLobbyError(KickedFromLobbyGroup, suspensionLobbyMessage(s.Record))
+
+			case FederatedSuspensionActionFlag:
+				metricsTags["flag"] = "federated_suspended_user"
+				if federatedSuspensionNotices.ShouldNotify(groupID, userID, s.Record, time.Now()) {
+					if _, err := p.appBot.LogAuditMessage(ctx, groupID, fmt.Sprintf("Allowed user <@%s>, who is suspended by trusted guild **%s** (%s) (expires <t:%d:R>)", userID, sourceName, s.Record.SuspensionNotice, s.Record.SuspensionExpiry.Unix()), true); err != nil {
+						p.logger.Warn("Failed to send audit message", zap.String("channel_id", gg.AuditChannelID), zap.Error(err))
+					}
+				}
+			}
+		}
+	}
 
-			if len(message)+len(expires) > maxMessageLength {
-				message = message[:maxMessageLength-len(expires)-3] + "..."
+	// Apply the guild's enforcement actions that restrict, rather than suspend, the player.
+	records := NewGuildEnforcementRecords(userID, groupID)
+	if err := StorageRead(ctx, p.nk, userID, records, false); err != nil && status.Code(err) != codes.NotFound {
+		logger.Warn("Unable to read enforcement records", zap.Error(err))
+	} else {
+		// The entrant presences are flagged from the stored records (e.g. voice mute).
+		params.enforcementRecords.Store(records)
+
+		if r := records.LobbyRestriction(lobbyParams.Mode, lobbyParams.Role); r != nil {
+			metricsTags["error"] = "restricted_" + string(r.Action())
+			if _, err := p.appBot.LogAuditMessage(ctx, groupID, fmt.Sprintf("Rejected user <@%s> from %s (%s: %s) (expires <t:%d:R>)", userID, lobbyParams.Mode.String(), r.Action().String(), r.SuspensionNotice, r.SuspensionExpiry.Unix()), true); err != nil {
+				p.logger.Warn("Failed to send audit message", zap.String("channel_id", gg.AuditChannelID), zap.Error(err))
 			}
-			message = message + expires
-			return NewLobbyError(KickedFromLobbyGroup, message)
+			return NewLobbyError(KickedFromLobbyGroup, suspensionLobbyMessage(r))
 		}
 	}
 
@@ -425,6 +474,18 @@ func (p *EvrPipeline) lobbyAuthorize(ctx context.Context, logger *zap.Logger, se
 	return nil
 }
 
+// suspensionLobbyMessage returns the suspension notice shown to the player in game, with the time remaining.
+func suspensionLobbyMessage(record *GuildEnforcementRecord) string {
+	const maxMessageLength = 60
+	message := record.SuspensionNotice
+	expires := fmt.Sprintf(" [exp: %s]", formatDuration(time.Until(record.SuspensionExpiry), false))
+
+	if len(message)+len(expires) > maxMessageLength {
+		message = message[:maxMessageLength-len(expires)-3] + "..."
+	}
+	return message + expires
+}
+
 func formatDuration(d time.Duration, withSeconds bool) string {
 	// Format the duration as a string in the format "1d1h2m3s"
 	// if it's more than 1 day, it's "1d1h" (the hour is rounded up)
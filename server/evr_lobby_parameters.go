@@ -53,7 +53,9 @@ type LobbySessionParameters struct {
 	MatchmakingRating            *atomic.Pointer[types.Rating] `json:"matchmaking_rating"`
 	MatchmakingOrdinal           *atomic.Float64               `json:"matchmaking_ordinal"`
 	IsEarlyQuitter               bool                          `json:"quit_last_game_early"`
+	EarlyQuitterPool             bool                          `json:"early_quitter_pool"`
 	EnableSBMM                   bool                          `json:"disable_sbmm"`
+	RatingSystem                 string                        `json:"rating_system"`
 	EnableRankPercentileRange    bool                          `json:"enable_rank_percentile_range"`
 	EnableOrdinalRange           bool                          `json:"enable_ordinal_range"`
 	EnableDivisions              bool                          `json:"enable_divisions"`
@@ -319,6 +321,11 @@ func NewLobbyParametersFromRequest(ctx context.Context, logger *zap.Logger, nk r
 	if mode == evr.ModeSocialPublic || mode == evr.ModeArenaPublicAI {
 		mmMode = evr.ModeArenaPublic
 	}
+
+	ratingSystem := RatingSystemOpenSkill
+	if gg, ok := sessionParams.guildGroups[groupIDStr]; ok && gg != nil {
+		ratingSystem = gg.RatingSystemName(mmMode)
+	}
 	if globalSettings.EnableSBMM && groupID != uuid.Nil {
 
 		if globalSettings.RankPercentile.MaxDelta > 0 {
@@ -338,7 +345,7 @@ func NewLobbyParametersFromRequest(ctx context.Context, logger *zap.Logger, nk r
 			}
 		}
 
-		matchmakingRating, err = MatchmakingRatingLoad(ctx, p.nk, userID, groupIDStr, mmMode)
+		matchmakingRating, err = MatchmakingRatingLoad(ctx, p.nk, userID, groupIDStr, mmMode, ratingSystem)
 		if err != nil {
 			logger.Warn("Failed to load matchmaking rating", zap.String("group_id", groupIDStr), zap.String("mode", mmMode.String()), zap.Error(err))
 			matchmakingRating = NewDefaultRating()
@@ -404,6 +411,7 @@ func NewLobbyParametersFromRequest(ctx context.Context, logger *zap.Logger, nk r
 		latencyHistory:               params.latencyHistory,
 		BlockedIDs:                   blockedIDs,
 		EnableSBMM:                   globalSettings.EnableSBMM,
+		RatingSystem:                 ratingSystem,
 		EnableDivisions:              globalSettings.EnableDivisions,
 		EnableRankPercentileRange:    globalSettings.EnableRankPercentileRange,
 		EnableOrdinalRange:           globalSettings.EnableOrdinalRange,
@@ -439,6 +447,7 @@ func (p *LobbySessionParameters) BackfillSearchQuery(includeMMR bool, includeMax
 		fmt.Sprintf("+label.mode:%s", p.Mode.String()),
 		fmt.Sprintf("+label.group_id:%s", Query.Escape(p.GroupID.String())),
 		//fmt.Sprintf("label.version_lock:%s", p.VersionLock.String()),
+		"-label.broadcaster.draining:T", // Draining servers only finish their current match
 		p.BackfillQueryAddon,
 	}
 
@@ -575,6 +584,12 @@ func (p *LobbySessionParameters) MatchmakingParameters(ticketParams *Matchmaking
 		qparts = append(qparts, fmt.Sprintf(`-properties.submission_time:<="%s"`, submissionTime))
 	}
 
+	// Players in the early quitter pool are only matched with each other
+	if ticketParams.IncludeEarlyQuitPenalty {
+		stringProperties["early_quitter_pool"] = strconv.FormatBool(p.EarlyQuitterPool)
+		qparts = append(qparts, fmt.Sprintf("+properties.early_quitter_pool:%s", strconv.FormatBool(p.EarlyQuitterPool)))
+	}
+
 	// If the user has a matchmaking Division, use it instead of SBMM
 
 	if p.EnableSBMM {
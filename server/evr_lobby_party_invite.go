@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// How long an in-game party invite may be accepted.
+const partyInviteTTL = 5 * time.Minute
+
+const (
+	StorageCollectionPartyInvites = "PartyInvites"
+	StorageKeyPartyInvites        = "pending"
+)
+
+var (
+	partyInviteMessageType     = evr.ToSymbol("ovr_social_member_data")
+	partyInviteNackMessageType = evr.ToSymbol("ovr_social_member_data_nack")
+
+	ErrPartyInviteOffline    = errors.New("player is offline")
+	ErrPartyInviteBlocked    = errors.New("player is blocked")
+	ErrPartyInviteNotFriend  = errors.New("player is not a friend")
+	ErrPartyInviteNotMember  = errors.New("player is not a member of the guild")
+	ErrPartyInviteUnexpected = errors.New("unexpected message type")
+)
+
+var _ = VersionedStorable(&PartyInvites{})
+
+// PartyInvites are the pending in-game party invites sent to the player, stored so that they are shared by all nodes.
+type PartyInvites struct {
+	Invites map[string]time.Time `json:"invites"` // The time each invite was sent, by sender user ID
+
+	version string
+}
+
+func (s PartyInvites) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionPartyInvites,
+		Key:             StorageKeyPartyInvites,
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         s.version,
+	}
+}
+
+func (s *PartyInvites) SetStorageVersion(userID, version string) {
+	s.version = version
+}
+
+// Add records the invite from the sender, removing any that have expired.
+func (s *PartyInvites) Add(senderUserID string, now time.Time) {
+	if s.Invites == nil {
+		s.Invites = make(map[string]time.Time)
+	}
+	for userID, sentAt := range s.Invites {
+		if now.Sub(sentAt) >= partyInviteTTL {
+			delete(s.Invites, userID)
+		}
+	}
+	s.Invites[senderUserID] = now
+}
+
+// Take removes the invite from the sender, returning true if it had not expired.
+func (s *PartyInvites) Take(senderUserID string, now time.Time) bool {
+	sentAt, ok := s.Invites[senderUserID]
+	delete(s.Invites, senderUserID)
+	return ok && now.Sub(sentAt) < partyInviteTTL
+}
+
+func partyInvitesLoad(ctx context.Context, nk runtime.NakamaModule, targetUserID string) (*PartyInvites, error) {
+	invites := &PartyInvites{}
+	if err := StorageRead(ctx, nk, targetUserID, invites, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		invites.version = "*"
+	}
+	return invites, nil
+}
+
+// partyInvitesStore writes the invites, returning runtime.ErrStorageRejectedVersion if they were changed since they were loaded.
+func partyInvitesStore(ctx context.Context, nk runtime.NakamaModule, targetUserID string, invites *PartyInvites) error {
+	op, err := StorageWriteOp(targetUserID, invites)
+	if err != nil {
+		return err
+	}
+	if _, err := nk.StorageWrite(ctx, []*runtime.StorageWrite{op}); err != nil {
+		if errors.Is(err, runtime.ErrStorageRejectedVersion) {
+			return runtime.ErrStorageRejectedVersion
+		}
+		return fmt.Errorf("failed to write party invites: %w", err)
+	}
+	return nil
+}
+
+// partyInviteAdd records the sender's invite to the target.
+func partyInviteAdd(ctx context.Context, nk runtime.NakamaModule, senderUserID, targetUserID string) error {
+	invites, err := partyInvitesLoad(ctx, nk, targetUserID)
+	if err != nil {
+		return err
+	}
+	invites.Add(senderUserID, time.Now())
+	return partyInvitesStore(ctx, nk, targetUserID, invites)
+}
+
+// partyInviteTake removes the sender's invite to the target, returning true if it had not expired.
+// If the invite is taken concurrently (e.g. by a session on another node), only one of them returns true.
+func partyInviteTake(ctx context.Context, nk runtime.NakamaModule, senderUserID, targetUserID string) (bool, error) {
+	invites, err := partyInvitesLoad(ctx, nk, targetUserID)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := invites.Invites[senderUserID]; !ok {
+		return false, nil
+	}
+	pending := invites.Take(senderUserID, time.Now())
+	if err := partyInvitesStore(ctx, nk, targetUserID, invites); err != nil {
+		if errors.Is(err, runtime.ErrStorageRejectedVersion) {
+			return false, nil
+		}
+		return false, err
+	}
+	return pending, nil
+}
+
+// userEdgeState returns the friend state of the edge from the user to the other user, or -1 if there is none.
+func userEdgeState(ctx context.Context, db *sql.DB, userID, otherUserID string) (int, error) {
+	query := "SELECT state FROM user_edge WHERE source_id = $1 AND destination_id = $2"
+	var state int
+	if err := db.QueryRowContext(ctx, query, userID, otherUserID).Scan(&state); err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil
+		}
+		return -1, fmt.Errorf("error finding user edge: %w", err)
+	}
+	return state, nil
+}
+
+// partyInviteAuthorize returns an error if the sender may not invite the target to a party in the guild.
+// The players must be friends, neither may have blocked the other, and the target must be able to play in the guild.
+func (p *EvrPipeline) partyInviteAuthorize(ctx context.Context, senderUserID, targetUserID, groupID string) error {
+	for _, edge := range [][2]string{{senderUserID, targetUserID}, {targetUserID, senderUserID}} {
+		if state, err := userEdgeState(ctx, p.db, edge[0], edge[1]); err != nil {
+			return err
+		} else if state == FriendStateBlocked {
+			return ErrPartyInviteBlocked
+		} else if state != FriendStateFriends {
+			return ErrPartyInviteNotFriend
+		}
+	}
+
+	if ok, err := CheckGroupMembershipByID(ctx, p.db, targetUserID, groupID, GuildGroupLangTag); err != nil {
+		return fmt.Errorf("failed to check guild membership: %w", err)
+	} else if !ok {
+		return ErrPartyInviteNotMember
+	}
+
+	if gg := p.guildGroupRegistry.Get(groupID); gg != nil {
+		if gg.MembersOnlyMatchmaking && gg.RoleMap.Member != "" && !gg.IsMember(targetUserID) {
+			return ErrPartyInviteNotMember
+		}
+		if gg.IsSuspended(targetUserID, nil) {
+			return ErrPartyInviteNotMember
+		}
+	}
+	return nil
+}
+
+// partyInviteAccept puts both players into the sender's party group, creating one if the sender has none.
+// The group is stored in their matchmaking settings, so it is shared with the Discord `/party` command.
+func (p *EvrPipeline) partyInviteAccept(ctx context.Context, logger *zap.Logger, senderUserID, targetUserID string) error {
+	senderSettings, err := LoadMatchmakingSettings(ctx, p.nk, senderUserID)
+	if err != nil {
+		return fmt.Errorf("failed to load matchmaking settings: %w", err)
+	}
+
+	if senderSettings.LobbyGroupName == "" {
+		senderSettings.LobbyGroupName = strings.ReplaceAll(uuid.Must(uuid.NewV4()).String(), "-", "")[:12]
+		if err := StoreMatchmakingSettings(ctx, p.nk, senderUserID, senderSettings); err != nil {
+			return fmt.Errorf("failed to store matchmaking settings: %w", err)
+		}
+	}
+	groupName := senderSettings.LobbyGroupName
+
+	targetSettings, err := LoadMatchmakingSettings(ctx, p.nk, targetUserID)
+	if err != nil {
+		return fmt.Errorf("failed to load matchmaking settings: %w", err)
+	}
+	if targetSettings.LobbyGroupName != groupName {
+		targetSettings.LobbyGroupName = groupName
+		if err := StoreMatchmakingSettings(ctx, p.nk, targetUserID, targetSettings); err != nil {
+			return fmt.Errorf("failed to store matchmaking settings: %w", err)
+		}
+	}
+
+	// Join the party now, with the sender first so that they lead it. Players without a lobby
+	// session will join it with their next lobby request.
+	partyID := uuid.NewV5(EntrantIDSalt, groupName)
+	for _, userID := range []string{senderUserID, targetUserID} {
+		presences, err := p.nk.StreamUserList(StreamModeService, userID, "", StreamLabelMatchService, false, true)
+		if err != nil {
+			return fmt.Errorf("failed to list lobby sessions: %w", err)
+		}
+		for _, presence := range presences {
+			session, ok := p.nk.sessionRegistry.Get(uuid.FromStringOrNil(presence.GetSessionId())).(*sessionWS)
+			if !ok || session == nil {
+				continue
+			}
+			currentMatchID, _ := MatchIDFromString(presence.GetStatus())
+			if _, _, err := JoinPartyGroup(session, groupName, partyID, currentMatchID); err != nil {
+				logger.Warn("Failed to join party group", zap.String("uid", userID), zap.String("group", groupName), zap.Error(err))
+			}
+		}
+	}
+
+	logger.Info("Party invite accepted", zap.String("sender_uid", senderUserID), zap.String("target_uid", targetUserID), zap.String("group", groupName))
+	return nil
+}
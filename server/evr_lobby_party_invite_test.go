@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPartyInvitesTake(t *testing.T) {
+	now := time.Now()
+	invites := &PartyInvites{}
+	invites.Add("a", now)
+
+	if invites.Take("b", now) {
+		t.Error("expected no invite from b")
+	}
+	if !invites.Take("a", now) {
+		t.Error("expected the invite from a to be pending")
+	}
+	if invites.Take("a", now) {
+		t.Error("expected the invite to be removed once taken")
+	}
+
+	invites.Add("c", now.Add(-partyInviteTTL))
+	if invites.Take("c", now) {
+		t.Error("expected the expired invite not to be pending")
+	}
+
+	invites.Add("d", now.Add(-partyInviteTTL))
+	invites.Add("e", now)
+	if _, ok := invites.Invites["d"]; ok {
+		t.Error("expected the expired invite to be removed when another is added")
+	}
+}
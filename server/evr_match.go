@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"reflect"
 	"slices"
 	"strconv"
@@ -98,6 +99,7 @@ type MatchSettings struct {
 	TeamAlignments      map[string]int
 	Reservations        []*EvrMatchPresence
 	ReservationLifetime time.Duration
+	PredictedDraw       float64 // The predicted probability of a draw between the teams, when the match was built
 }
 
 // This is the match handler for all matches.
@@ -183,6 +185,7 @@ var (
 	ErrJoinRejectReasonMatchTerminating          = errors.New("match terminating")
 	ErrJoinRejectReasonMatchClosed               = errors.New("match closed to new entrants")
 	ErrJoinRejectReasonFeatureMismatch           = errors.New("feature mismatch")
+	ErrJoinRejectReasonSpectatorOnly             = errors.New("restricted to spectating")
 )
 
 type EntrantMetadata struct {
@@ -342,6 +345,18 @@ func (m *EvrMatch) MatchJoinAttempt(ctx context.Context, logger runtime.Logger,
 		}
 	}
 
+	// Players restricted to spectating may not join a team.
+	switch state.Mode {
+	case evr.ModeArenaPublic, evr.ModeArenaPrivate, evr.ModeCombatPublic, evr.ModeCombatPrivate:
+		if meta.Presence.IsPlayer() {
+			for _, p := range meta.Presences() {
+				if p.IsSpectatorOnly {
+					return state, false, ErrJoinRejectReasonSpectatorOnly.Error()
+				}
+			}
+		}
+	}
+
 	// Ensure the player has a role alignment
 	metricsTags := map[string]string{
 		"mode":     state.Mode.String(),
@@ -574,6 +589,7 @@ func (m *EvrMatch) MatchLeave(ctx context.Context, logger runtime.Logger, db *sq
 
 			ts := state.joinTimestamps[mp.GetSessionId()]
 			nk.MetricsTimerRecord("match_player_session_duration", tags, time.Since(ts))
+			globalBroadcasterRegistry.RecordPlayerTime(state.GameServer.Endpoint.ExternalAddress(), time.Since(ts))
 
 			// Store the player's time in the match to a leaderboard
 
@@ -582,7 +598,13 @@ func (m *EvrMatch) MatchLeave(ctx context.Context, logger runtime.Logger, db *sq
 			}
 
 			// If the round is not over, then add an early quit count to the player.
-			if state.Mode == evr.ModeArenaPublic && time.Since(state.StartTime) >= (time.Second*60) && state.GameState != nil && state.GameState.MatchOver == false {
+			policy, modePolicy, penalized := earlyQuitModePolicy(state.Mode)
+			if penalized && time.Since(state.StartTime) >= time.Duration(modePolicy.MinimumMatchSecs)*time.Second && state.GameState != nil && state.GameState.MatchOver == false {
+
+				var remainingTime time.Duration
+				if state.GameState.RoundClock != nil && state.GameState.RoundClock.Current() > 0 {
+					remainingTime = state.GameState.RoundClock.Remaining()
+				}
 
 				for _, p := range presences {
 					if mp, ok := state.presenceMap[p.GetSessionId()]; ok {
@@ -612,6 +634,17 @@ func (m *EvrMatch) MatchLeave(ctx context.Context, logger runtime.Logger, db *sq
 								logger.Warn("Failed to write early quit record: %v", err)
 							}
 						}
+
+						go func(userID string, mode evr.Symbol) {
+							// The match context is cancelled when the match ends, which may be before the update completes.
+							ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+							defer cancel()
+							if err := EarlyQuitStatisticsUpdate(ctx, nk, userID, func(s *EarlyQuitStatistics) {
+								s.ApplyEarlyQuitPenalty(policy, mode, remainingTime, time.Now().UTC())
+							}); err != nil {
+								logger.Warn("Failed to apply early quit penalty: %v", err)
+							}
+						}(mp.GetUserId(), state.Mode)
 					}
 				}
 			}
@@ -933,6 +966,11 @@ func (m *EvrMatch) MatchShutdown(ctx context.Context, logger runtime.Logger, db
 	}
 
 	if state.server != nil {
+		// Notify the players that the match has ended.
+		if err := m.sendMatchEnded(ctx, logger, dispatcher, state); err != nil {
+			logger.Warn("Failed to send match ended: %v", err)
+		}
+
 		entrantIDs := make([]uuid.UUID, 0, len(state.presenceMap))
 
 		for _, mp := range state.presenceMap {
@@ -990,7 +1028,12 @@ func (m *EvrMatch) MatchSignal(ctx context.Context, logger runtime.Logger, db *s
 		}
 
 		if len(entrantIDs) > 0 {
-			if err := m.kickEntrants(ctx, logger, dispatcher, state, entrantIDs...); err != nil {
+			// The game server removes the entrant on either message; only the smite carries a message to display.
+			if data.Message != "" {
+				if err := m.smiteEntrants(ctx, logger, dispatcher, state, http.StatusForbidden, data.Message, entrantIDs...); err != nil {
+					return state, SignalResponse{Message: fmt.Sprintf("failed to smite player: %v", err)}.String()
+				}
+			} else if err := m.kickEntrants(ctx, logger, dispatcher, state, entrantIDs...); err != nil {
 				return state, SignalResponse{Message: fmt.Sprintf("failed to kick player: %v", err)}.String()
 			}
 		}
@@ -1164,6 +1207,8 @@ func (m *EvrMatch) MatchSignal(ctx context.Context, logger runtime.Logger, db *s
 			state.PlayerLimit = min(state.TeamSize*2, state.MaxSize)
 		}
 
+		state.PredictedDraw = settings.PredictedDraw
+
 		state.TeamAlignments = make(map[string]int, state.MaxSize)
 
 		for userID, role := range settings.TeamAlignments {
@@ -1228,7 +1273,28 @@ func (m *EvrMatch) MatchSignal(ctx context.Context, logger runtime.Logger, db *s
 					mp.MatchmakingAt = nil
 				}
 			}
+			if update.IsVoiceMuted != nil {
+				mp.IsVoiceMuted = *update.IsVoiceMuted
+			}
+		}
+
+	case SignalGameServerAction:
+		var data SignalGameServerActionPayload
+		if err := json.Unmarshal(signal.Payload, &data); err != nil {
+			return state, SignalResponse{Message: fmt.Sprintf("failed to unmarshal game server action payload: %v", err)}.String()
+		}
+		_nk, ok := nk.(*RuntimeGoNakamaModule)
+		if !ok {
+			return state, SignalResponse{Message: "failed to cast nakama module"}.String()
+		}
+		if err := gameServerActionApply(_nk, state, data.Action); err != nil {
+			return state, SignalResponse{Message: err.Error()}.String()
 		}
+		// The label excludes a draining match from backfill and allocation.
+		if err := m.updateLabel(logger, dispatcher, state); err != nil {
+			return state, SignalResponse{Message: fmt.Sprintf("failed to update label: %v", err)}.String()
+		}
+		return state, SignalResponse{Success: true}.String()
 
 	default:
 		logger.Warn("Unknown signal: %v", signal.OpCode)
@@ -1279,6 +1345,9 @@ func (m *EvrMatch) MatchStart(ctx context.Context, logger runtime.Logger, nk run
 		return state, fmt.Errorf("failed to dispatch message: %w", err)
 	}
 	state.levelLoaded = true
+	if state.GameServer != nil {
+		globalBroadcasterRegistry.SessionStarting(state.ID.UUID, state.GameServer.Endpoint.ExternalAddress())
+	}
 
 	MatchDataEvent(ctx, nk, state.ID, MatchDataStarted{
 		State: state,
@@ -1329,3 +1398,35 @@ func (m *EvrMatch) sendEntrantReject(ctx context.Context, logger runtime.Logger,
 	}
 	return nil
 }
+
+// smiteEntrants instructs the game server to forcibly remove the entrants, displaying the message to them.
+func (m *EvrMatch) smiteEntrants(ctx context.Context, logger runtime.Logger, dispatcher runtime.MatchDispatcher, state *MatchLabel, statusCode uint64, message string, entrantIDs ...uuid.UUID) error {
+	messages := make([]evr.Message, 0, len(entrantIDs))
+	for _, p := range state.presenceMap {
+		if slices.Contains(entrantIDs, p.EntrantID(state.ID)) {
+			messages = append(messages, evr.NewSNSLobbySmiteEntrant(p.EvrID, statusCode, message))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	if err := m.dispatchMessages(ctx, logger, dispatcher, messages, []runtime.Presence{state.server}, nil); err != nil {
+		return fmt.Errorf("failed to dispatch message: %w", err)
+	}
+	return nil
+}
+
+// sendMatchEnded notifies the players in the match that the lobby session has ended.
+func (m *EvrMatch) sendMatchEnded(ctx context.Context, logger runtime.Logger, dispatcher runtime.MatchDispatcher, state *MatchLabel) error {
+	presences := make([]runtime.Presence, 0, len(state.presenceMap))
+	for _, p := range state.presenceMap {
+		presences = append(presences, p)
+	}
+	if len(presences) == 0 {
+		return nil
+	}
+	if err := m.dispatchMessages(ctx, logger, dispatcher, []evr.Message{evr.NewMatchEnded()}, presences, nil); err != nil {
+		return fmt.Errorf("failed to dispatch message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,501 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	MatchDataSinkMongo    = "mongo"
+	MatchDataSinkPostgres = "postgres"
+	MatchDataSinkFile     = "file"
+
+	DefaultMatchDataFileDirectory = "./data/match_data"
+	DefaultMatchDataFileMaxBytes  = 100 * 1024 * 1024
+)
+
+// MatchDataSink persists completed match data journals.
+type MatchDataSink interface {
+	Name() string
+	Write(ctx context.Context, journals []*MatchDataJournal) error
+	Close() error
+}
+
+// globalMatchDataReader is the reader of the runtime module's match data sink, shared with the Discord bot.
+var globalMatchDataReader = &atomic.Value{}
+
+// GlobalMatchDataReader returns the reader of the runtime module's match data sink, or nil if it cannot be read.
+func GlobalMatchDataReader() MatchDataReader {
+	reader, _ := globalMatchDataReader.Load().(MatchDataReader)
+	return reader
+}
+
+// MatchDataReader is implemented by the sinks that can read back the journals of a match.
+// The journals are found by the match UUID, whichever node the match ran on.
+type MatchDataReader interface {
+	Read(ctx context.Context, matchUUID uuid.UUID) ([]*MatchDataJournal, error)
+}
+
+// MatchDataScanner is implemented by the sinks that can scan the journals created in a time range, in order.
+// Only the journals with an entry of the type are scanned, and they are not held in memory.
+type MatchDataScanner interface {
+	Scan(ctx context.Context, entryType string, since, until time.Time, fn func(j *MatchDataJournal) error) error
+}
+
+// NewMatchDataSink creates the sink selected by the MATCH_DATA_SINK runtime variable.
+// If it is not set, MongoDB is used when MONGO_URI is set, and the database otherwise.
+// Without a database, match data is not persisted, and nil is returned.
+func NewMatchDataSink(ctx context.Context, logger runtime.Logger, db *sql.DB, vars map[string]string) (MatchDataSink, error) {
+	kind := vars["MATCH_DATA_SINK"]
+	if kind == "" {
+		switch {
+		case vars["MONGO_URI"] != "":
+			kind = MatchDataSinkMongo
+		case db != nil:
+			kind = MatchDataSinkPostgres
+		default:
+			logger.Warn("No match data sink is configured (MATCH_DATA_SINK or MONGO_URI), match data will not be persisted.")
+			return nil, nil
+		}
+	}
+
+	switch kind {
+	case MatchDataSinkMongo:
+		if vars["MONGO_URI"] == "" {
+			return nil, fmt.Errorf("MONGO_URI is required for the %s match data sink", kind)
+		}
+		opts := options.Client().ApplyURI(vars["MONGO_URI"]).SetTimeout(3 * time.Second).SetBSONOptions(&options.BSONOptions{
+			DefaultDocumentM: true, // Decode the journal data as maps, as it is when the journal is written
+		})
+		client, err := mongo.Connect(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		}
+		if err := client.Ping(ctx, nil); err != nil {
+			logger.WithField("error", err).Warn("Failed to ping MongoDB")
+		}
+		return NewMatchDataMongoSink(client), nil
+
+	case MatchDataSinkPostgres:
+		if db == nil {
+			return nil, fmt.Errorf("a database is required for the %s match data sink", kind)
+		}
+		return NewMatchDataPostgresSink(db), nil
+
+	case MatchDataSinkFile:
+		dir := vars["MATCH_DATA_FILE_DIR"]
+		if dir == "" {
+			dir = DefaultMatchDataFileDirectory
+		}
+		maxBytes := int64(DefaultMatchDataFileMaxBytes)
+		if s := vars["MATCH_DATA_FILE_MAX_BYTES"]; s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid MATCH_DATA_FILE_MAX_BYTES: %s", s)
+			}
+			maxBytes = n
+		}
+		return NewMatchDataFileSink(dir, maxBytes)
+
+	default:
+		return nil, fmt.Errorf("unknown match data sink: %s", kind)
+	}
+}
+
+// NewMatchDataFallbackSink creates the file sink that journals are written to when the match data sink fails.
+// It is only created when MATCH_DATA_FALLBACK_DIR is set.
+func NewMatchDataFallbackSink(vars map[string]string) (MatchDataSink, error) {
+	dir := vars["MATCH_DATA_FALLBACK_DIR"]
+	if dir == "" {
+		return nil, nil
+	}
+	return NewMatchDataFileSink(dir, DefaultMatchDataFileMaxBytes)
+}
+
+// MatchDataMongoSink inserts the journals into the match data collection.
+type MatchDataMongoSink struct {
+	client *mongo.Client
+}
+
+func NewMatchDataMongoSink(client *mongo.Client) *MatchDataMongoSink {
+	return &MatchDataMongoSink{client: client}
+}
+
+func (s *MatchDataMongoSink) Name() string {
+	return MatchDataSinkMongo
+}
+
+func (s *MatchDataMongoSink) Write(ctx context.Context, journals []*MatchDataJournal) error {
+	docs := make([]any, 0, len(journals))
+	for _, j := range journals {
+		docs = append(docs, j)
+	}
+	collection := s.client.Database(matchDataDatabaseName).Collection(matchDataCollectionName)
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to insert match data: %w", err)
+	}
+	return nil
+}
+
+func (s *MatchDataMongoSink) Read(ctx context.Context, matchUUID uuid.UUID) ([]*MatchDataJournal, error) {
+	collection := s.client.Database(matchDataDatabaseName).Collection(matchDataCollectionName)
+	filter := bson.M{"matchid": bson.M{"$regex": "^" + matchUUID.String() + `\.`}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"createdat": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find match data: %w", err)
+	}
+	journals := make([]*MatchDataJournal, 0)
+	if err := cursor.All(ctx, &journals); err != nil {
+		return nil, fmt.Errorf("failed to decode match data: %w", err)
+	}
+	return journals, nil
+}
+
+func (s *MatchDataMongoSink) Scan(ctx context.Context, entryType string, since, until time.Time, fn func(j *MatchDataJournal) error) error {
+	collection := s.client.Database(matchDataDatabaseName).Collection(matchDataCollectionName)
+	filter := bson.M{
+		"createdat":   bson.M{"$gte": since, "$lt": until},
+		"events.type": entryType,
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"createdat": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to find match data: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		j := &MatchDataJournal{}
+		if err := cursor.Decode(j); err != nil {
+			return fmt.Errorf("failed to decode match data: %w", err)
+		}
+		if err := fn(j); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (s *MatchDataMongoSink) Close() error {
+	return s.client.Disconnect(context.Background())
+}
+
+// MatchDataPostgresSink writes the journals to the match_data_journal table.
+type MatchDataPostgresSink struct {
+	db *sql.DB
+}
+
+func NewMatchDataPostgresSink(db *sql.DB) *MatchDataPostgresSink {
+	return &MatchDataPostgresSink{db: db}
+}
+
+func (s *MatchDataPostgresSink) Name() string {
+	return MatchDataSinkPostgres
+}
+
+func (s *MatchDataPostgresSink) Write(ctx context.Context, journals []*MatchDataJournal) error {
+	query := `
+INSERT INTO match_data_journal (match_id, create_time, update_time, events)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (match_id, create_time) DO UPDATE SET update_time = $3, events = $4`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	for _, j := range journals {
+		events, err := json.Marshal(j.Events)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to marshal match data: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, j.MatchID, j.CreatedAt, j.UpdatedAt, events); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to insert match data: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit match data: %w", err)
+	}
+	return nil
+}
+
+func (s *MatchDataPostgresSink) Read(ctx context.Context, matchUUID uuid.UUID) ([]*MatchDataJournal, error) {
+	query := "SELECT match_id, create_time, update_time, events FROM match_data_journal WHERE match_id LIKE $1 ORDER BY create_time"
+	rows, err := s.db.QueryContext(ctx, query, matchUUID.String()+".%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query match data: %w", err)
+	}
+	defer rows.Close()
+
+	journals := make([]*MatchDataJournal, 0)
+	for rows.Next() {
+		j := &MatchDataJournal{}
+		var events []byte
+		if err := rows.Scan(&j.MatchID, &j.CreatedAt, &j.UpdatedAt, &events); err != nil {
+			return nil, fmt.Errorf("failed to scan match data: %w", err)
+		}
+		if err := json.Unmarshal(events, &j.Events); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal match data: %w", err)
+		}
+		journals = append(journals, j)
+	}
+	return journals, rows.Err()
+}
+
+func (s *MatchDataPostgresSink) Scan(ctx context.Context, entryType string, since, until time.Time, fn func(j *MatchDataJournal) error) error {
+	contains, err := json.Marshal([]map[string]string{{"type": entryType}})
+	if err != nil {
+		return err
+	}
+
+	query := `
+SELECT match_id, create_time, update_time, events FROM match_data_journal
+WHERE create_time >= $1 AND create_time < $2 AND events @> $3::jsonb
+ORDER BY create_time`
+	rows, err := s.db.QueryContext(ctx, query, since, until, contains)
+	if err != nil {
+		return fmt.Errorf("failed to query match data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		j := &MatchDataJournal{}
+		var events []byte
+		if err := rows.Scan(&j.MatchID, &j.CreatedAt, &j.UpdatedAt, &events); err != nil {
+			return fmt.Errorf("failed to scan match data: %w", err)
+		}
+		if err := json.Unmarshal(events, &j.Events); err != nil {
+			return fmt.Errorf("failed to unmarshal match data: %w", err)
+		}
+		if err := fn(j); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *MatchDataPostgresSink) Close() error {
+	return nil
+}
+
+// MatchDataFileSink appends the journals to newline-delimited JSON files.
+// A new file is started each day, or when the current file exceeds the maximum size.
+type MatchDataFileSink struct {
+	sync.Mutex
+
+	dir      string
+	maxBytes int64
+
+	file     *os.File
+	fileDate string
+	size     int64
+}
+
+func NewMatchDataFileSink(dir string, maxBytes int64) (*MatchDataFileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create match data directory: %w", err)
+	}
+	return &MatchDataFileSink{
+		dir:      dir,
+		maxBytes: maxBytes,
+	}, nil
+}
+
+func (s *MatchDataFileSink) Name() string {
+	return MatchDataSinkFile
+}
+
+func (s *MatchDataFileSink) Write(ctx context.Context, journals []*MatchDataJournal) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, j := range journals {
+		line, err := json.Marshal(j)
+		if err != nil {
+			return fmt.Errorf("failed to marshal match data: %w", err)
+		}
+		line = append(line, '\n')
+
+		if err := s.rotate(time.Now().UTC(), int64(len(line))); err != nil {
+			return err
+		}
+
+		n, err := s.file.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write match data: %w", err)
+		}
+	}
+
+	if s.file != nil {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// rotate opens a new file if there is none, the day has changed, or the line would exceed the maximum size.
+func (s *MatchDataFileSink) rotate(now time.Time, n int64) error {
+	date := now.Format("20060102")
+	if s.file != nil && s.fileDate == date && (s.size == 0 || s.size+n <= s.maxBytes) {
+		return nil
+	}
+
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return fmt.Errorf("failed to close match data file: %w", err)
+		}
+		s.file = nil
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf("match_data-%s.ndjson", now.Format("20060102T150405.000000000")))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open match data file: %w", err)
+	}
+
+	s.file = f
+	s.fileDate = date
+	s.size = 0
+	return nil
+}
+
+// Read scans all of the files for the match's journals.
+func (s *MatchDataFileSink) Read(ctx context.Context, matchUUID uuid.UUID) ([]*MatchDataJournal, error) {
+	names, err := filepath.Glob(filepath.Join(s.dir, "match_data-*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := matchUUID.String() + "."
+	needle := []byte(`"match_id":"` + prefix)
+	journals := make([]*MatchDataJournal, 0)
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open match data file: %w", err)
+		}
+		reader := bufio.NewReader(f)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 && bytes.Contains(line, needle) {
+				j := &MatchDataJournal{}
+				if err := json.Unmarshal(line, j); err == nil && strings.HasPrefix(j.MatchID, prefix) {
+					journals = append(journals, j)
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		f.Close()
+	}
+
+	slices.SortStableFunc(journals, func(a, b *MatchDataJournal) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return journals, nil
+}
+
+// Scan reads all of the files for the journals in the time range, and calls fn with each as it is read. The journals
+// are in the order they were written, which is the order their matches ended.
+func (s *MatchDataFileSink) Scan(ctx context.Context, entryType string, since, until time.Time, fn func(j *MatchDataJournal) error) error {
+	names, err := filepath.Glob(filepath.Join(s.dir, "match_data-*.ndjson"))
+	if err != nil {
+		return err
+	}
+
+	needle := []byte(`"type":"` + entryType + `"`)
+	for _, name := range names {
+		if err := s.scanFile(ctx, name, needle, since, until, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MatchDataFileSink) scanFile(ctx context.Context, name string, needle []byte, since, until time.Time, fn func(j *MatchDataJournal) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open match data file: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 && bytes.Contains(line, needle) {
+			j := &MatchDataJournal{}
+			if err := json.Unmarshal(line, j); err == nil && !j.CreatedAt.Before(since) && j.CreatedAt.Before(until) {
+				if err := fn(j); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr != nil {
+			return nil
+		}
+	}
+}
+
+func (s *MatchDataFileSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// writeMatchData writes the journals to the sink, falling back to the fallback sink if it fails.
+// The journals that could not be written by either are returned so that they can be retried.
+func writeMatchData(ctx context.Context, sink, fallback MatchDataSink, journals []*MatchDataJournal) ([]*MatchDataJournal, error) {
+	if len(journals) == 0 {
+		return nil, nil
+	}
+
+	err := sink.Write(ctx, journals)
+	if err == nil {
+		return nil, nil
+	}
+
+	if fallback != nil {
+		if fallbackErr := fallback.Write(ctx, journals); fallbackErr == nil {
+			return nil, fmt.Errorf("%s sink failed, wrote to %s sink: %w", sink.Name(), fallback.Name(), err)
+		} else {
+			err = errors.Join(err, fallbackErr)
+		}
+	}
+
+	return journals, err
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type failingMatchDataSink struct{}
+
+func (failingMatchDataSink) Name() string { return "failing" }
+func (failingMatchDataSink) Write(context.Context, []*MatchDataJournal) error {
+	return errors.New("unavailable")
+}
+func (failingMatchDataSink) Close() error { return nil }
+
+func testMatchDataJournals(n int) []*MatchDataJournal {
+	journals := make([]*MatchDataJournal, 0, n)
+	for i := 0; i < n; i++ {
+		j := NewMatchDataJournal(MatchID{UUID: uuid.Must(uuid.NewV4()), Node: "testnode"})
+		j.Events = append(j.Events, &MatchDataJournalEntry{CreatedAt: j.CreatedAt, Data: map[string]any{"index": i}})
+		journals = append(journals, j)
+	}
+	return journals
+}
+
+func readMatchDataFiles(t *testing.T, dir string) (files int, journals []*MatchDataJournal) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			j := &MatchDataJournal{}
+			if err := json.Unmarshal(scanner.Bytes(), j); err != nil {
+				t.Fatalf("invalid line in %s: %v", e.Name(), err)
+			}
+			journals = append(journals, j)
+		}
+		f.Close()
+	}
+	return len(entries), journals
+}
+
+func TestMatchDataFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	// Each journal is larger than the maximum size, so each is written to its own file.
+	sink, err := NewMatchDataFileSink(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	if err := sink.Write(context.Background(), testMatchDataJournals(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	files, journals := readMatchDataFiles(t, dir)
+	if files != 3 {
+		t.Errorf("expected 3 files, got %d", files)
+	}
+	if len(journals) != 3 {
+		t.Errorf("expected 3 journals, got %d", len(journals))
+	}
+}
+
+func TestMatchDataFileSinkAppends(t *testing.T) {
+	dir := t.TempDir()
+
+	sink, err := NewMatchDataFileSink(dir, DefaultMatchDataFileMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := sink.Write(context.Background(), testMatchDataJournals(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, journals := readMatchDataFiles(t, dir)
+	if files != 1 {
+		t.Errorf("expected 1 file, got %d", files)
+	}
+	if len(journals) != 4 {
+		t.Errorf("expected 4 journals, got %d", len(journals))
+	}
+}
+
+func TestMatchDataFileSinkReadByUUID(t *testing.T) {
+	sink, err := NewMatchDataFileSink(t.TempDir(), DefaultMatchDataFileMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	// The match ran on a node other than the one that reads it.
+	matchID := MatchID{UUID: uuid.Must(uuid.NewV4()), Node: "othernode"}
+	journals := append(testMatchDataJournals(1), NewMatchDataJournal(matchID))
+
+	if err := sink.Write(context.Background(), journals); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := sink.Read(context.Background(), matchID.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].MatchID != matchID.String() {
+		t.Errorf("expected the journal of %s, got %v", matchID, got)
+	}
+}
+
+func TestNewMatchDataSink(t *testing.T) {
+	dir := t.TempDir()
+
+	// Without a sink, MongoDB or a database, match data is not persisted and no directory is created.
+	sink, err := NewMatchDataSink(context.Background(), NewRuntimeGoLogger(loggerForTest(t)), nil, map[string]string{
+		"MATCH_DATA_FILE_DIR": filepath.Join(dir, "unused"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sink != nil {
+		t.Errorf("expected no sink by default, got %s", sink.Name())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unused")); !os.IsNotExist(err) {
+		t.Error("expected the match data directory not to be created")
+	}
+
+	sink, err = NewMatchDataSink(context.Background(), NewRuntimeGoLogger(loggerForTest(t)), nil, map[string]string{
+		"MATCH_DATA_SINK":     MatchDataSinkFile,
+		"MATCH_DATA_FILE_DIR": dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sink.Name() != MatchDataSinkFile {
+		t.Errorf("expected the %s sink, got %s", MatchDataSinkFile, sink.Name())
+	}
+
+	if _, err := NewMatchDataSink(context.Background(), NewRuntimeGoLogger(loggerForTest(t)), nil, map[string]string{"MATCH_DATA_SINK": MatchDataSinkPostgres}); err == nil {
+		t.Error("expected an error for the postgres sink without a database")
+	}
+	if _, err := NewMatchDataSink(context.Background(), NewRuntimeGoLogger(loggerForTest(t)), nil, map[string]string{"MATCH_DATA_SINK": "unknown"}); err == nil {
+		t.Error("expected an error for an unknown sink")
+	}
+}
+
+func TestWriteMatchDataFallback(t *testing.T) {
+	journals := testMatchDataJournals(2)
+
+	fallback, err := NewMatchDataFileSink(t.TempDir(), DefaultMatchDataFileMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fallback.Close()
+
+	failed, err := writeMatchData(context.Background(), failingMatchDataSink{}, fallback, journals)
+	if err == nil {
+		t.Error("expected the sink failure to be reported")
+	}
+	if len(failed) != 0 {
+		t.Errorf("expected the fallback to write the journals, %d failed", len(failed))
+	}
+
+	// Without a fallback, the journals are returned to be retried.
+	failed, _ = writeMatchData(context.Background(), failingMatchDataSink{}, nil, journals)
+	if len(failed) != len(journals) {
+		t.Errorf("expected %d journals to be retried, got %d", len(journals), len(failed))
+	}
+}
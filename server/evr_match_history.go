@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageCollectionMatchHistory = "MatchHistory"
+	StorageKeyMatchHistory        = "recent"
+	MatchHistoryMaxEntries        = 25
+)
+
+var _ = VersionedStorable(&PlayerMatchHistory{})
+
+type PlayerMatchHistoryEntry struct {
+	MatchID   string     `json:"match_id"`
+	Mode      evr.Symbol `json:"mode"`
+	GroupID   string     `json:"group_id,omitempty"`
+	StartTime time.Time  `json:"start_time"`
+	Team      TeamIndex  `json:"team"`
+	EarlyQuit bool       `json:"early_quit,omitempty"`
+}
+
+// PlayerMatchHistory is the list of a player's most recent matches, newest first.
+type PlayerMatchHistory struct {
+	Matches []PlayerMatchHistoryEntry `json:"matches"`
+	version string
+}
+
+func (h PlayerMatchHistory) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionMatchHistory,
+		Key:             StorageKeyMatchHistory,
+		PermissionRead:  runtime.STORAGE_PERMISSION_OWNER_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         h.version,
+	}
+}
+
+func (h *PlayerMatchHistory) SetStorageVersion(userID, version string) {
+	h.version = version
+}
+
+// Add records the match, replacing the existing entry for it.
+func (h *PlayerMatchHistory) Add(entry PlayerMatchHistoryEntry) {
+	h.Matches = slices.DeleteFunc(h.Matches, func(e PlayerMatchHistoryEntry) bool {
+		return e.MatchID == entry.MatchID
+	})
+	h.Matches = append(h.Matches, entry)
+
+	slices.SortStableFunc(h.Matches, func(a, b PlayerMatchHistoryEntry) int {
+		return b.StartTime.Compare(a.StartTime)
+	})
+
+	if len(h.Matches) > MatchHistoryMaxEntries {
+		h.Matches = h.Matches[:MatchHistoryMaxEntries]
+	}
+}
+
+func PlayerMatchHistoryLoad(ctx context.Context, nk runtime.NakamaModule, userID string) (*PlayerMatchHistory, error) {
+	history := &PlayerMatchHistory{}
+	if err := StorageRead(ctx, nk, userID, history, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		history.version = "*" // Only create the history if it does not exist.
+	}
+	return history, nil
+}
+
+// recordMatchHistory adds the journaled matches to the history of each player in them.
+// The histories of all of the players are read and written in a single batch, retrying if any are modified concurrently.
+func recordMatchHistory(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, journals []*MatchDataJournal) {
+	entries := make(map[string][]PlayerMatchHistoryEntry)
+	for _, j := range journals {
+		timeline := NewMatchTimeline(j.MatchID, []*MatchDataJournal{j})
+
+		startTime := timeline.StartTime
+		if startTime.IsZero() {
+			startTime = j.CreatedAt
+		}
+
+		for _, p := range timeline.Players {
+			entries[p.UserID] = append(entries[p.UserID], PlayerMatchHistoryEntry{
+				MatchID:   j.MatchID,
+				Mode:      timeline.Mode,
+				GroupID:   timeline.GroupID,
+				StartTime: startTime,
+				Team:      p.Team,
+				EarlyQuit: p.EarlyQuit,
+			})
+		}
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	var err error
+	for range 3 {
+		if err = writeMatchHistory(ctx, nk, entries); err == nil || !errors.Is(err, runtime.ErrStorageRejectedVersion) {
+			break
+		}
+	}
+	if err != nil {
+		logger.WithFields(map[string]any{
+			"matches": len(journals),
+			"players": len(entries),
+			"error":   err,
+		}).Warn("Failed to write match history.")
+	}
+}
+
+func writeMatchHistory(ctx context.Context, nk runtime.NakamaModule, entries map[string][]PlayerMatchHistoryEntry) error {
+	reads := make([]*runtime.StorageRead, 0, len(entries))
+	for userID := range entries {
+		reads = append(reads, &runtime.StorageRead{
+			Collection: StorageCollectionMatchHistory,
+			Key:        StorageKeyMatchHistory,
+			UserID:     userID,
+		})
+	}
+
+	objs, err := nk.StorageRead(ctx, reads)
+	if err != nil {
+		return err
+	}
+
+	histories := make(map[string]*PlayerMatchHistory, len(objs))
+	for _, obj := range objs {
+		history := &PlayerMatchHistory{}
+		if err := json.Unmarshal([]byte(obj.GetValue()), history); err != nil {
+			return err
+		}
+		history.version = obj.GetVersion()
+		histories[obj.GetUserId()] = history
+	}
+
+	writes := make([]*runtime.StorageWrite, 0, len(entries))
+	for userID, userEntries := range entries {
+		history, ok := histories[userID]
+		if !ok {
+			history = &PlayerMatchHistory{version: "*"} // Only create the history if it does not exist.
+		}
+		for _, e := range userEntries {
+			history.Add(e)
+		}
+
+		data, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		meta := history.StorageMeta()
+		writes = append(writes, &runtime.StorageWrite{
+			Collection:      meta.Collection,
+			Key:             meta.Key,
+			UserID:          userID,
+			Value:           string(data),
+			Version:         meta.Version,
+			PermissionRead:  meta.PermissionRead,
+			PermissionWrite: meta.PermissionWrite,
+		})
+	}
+
+	_, err = nk.StorageWrite(ctx, writes)
+	return err
+}
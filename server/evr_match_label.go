@@ -49,6 +49,7 @@ type MatchLabel struct {
 	GameServer      *GameServerPresence       `json:"broadcaster,omitempty"`      // The broadcaster's data
 	SessionSettings *evr.LobbySessionSettings `json:"session_settings,omitempty"` // The session settings for the match (EVR).
 	TeamAlignments  map[string]int            `json:"team_alignments,omitempty"`  // map[userID]TeamIndex
+	PredictedDraw   float64                   `json:"predicted_draw,omitempty"`   // The predicted probability of a draw between the teams, when the match was built
 
 	server          runtime.Presence                // The broadcaster's presence
 	levelLoaded     bool                            // Whether the server has been sent the start instruction.
@@ -401,6 +402,8 @@ func (s *MatchLabel) rebuildCache() {
 				})
 			}
 		}
+		s.Players[len(s.Players)-1].IsVoiceMuted = p.IsVoiceMuted
+
 		if p.MatchmakingAt != nil {
 			s.joinTimestamps[p.SessionID.String()] = *p.MatchmakingAt
 		}
@@ -572,6 +575,7 @@ func (l *MatchLabel) PublicView() *MatchLabel {
 		Players:        make([]PlayerInfo, 0),
 		RankPercentile: l.RankPercentile,
 		RatingOrdinal:  l.RatingOrdinal,
+		PredictedDraw:  l.PredictedDraw,
 	}
 	if l.LobbyType == PrivateLobby || l.LobbyType == UnassignedLobby {
 		// Set the last bytes to FF to hide the ID
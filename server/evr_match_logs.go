@@ -11,6 +11,7 @@ import (
 
 type EventEnvelopeMatchData struct {
 	MatchID MatchID         `json:"match_uuid"`
+	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"data"`
 }
 
@@ -21,6 +22,7 @@ func NewEventEnvelopeMatchData(matchID MatchID, payload any) (*EventEnvelopeMatc
 	}
 	return &EventEnvelopeMatchData{
 		MatchID: matchID,
+		Type:    MatchDataType(payload),
 		Payload: data,
 	}, nil
 }
@@ -30,15 +32,20 @@ func (m *EventEnvelopeMatchData) MarshalEvent() (*api.Event, error) {
 		Name: EventMatchData,
 		Properties: map[string]string{
 			"match_id": m.MatchID.String(),
+			"type":     m.Type,
 			"payload":  string(m.Payload),
 		},
 		External: true,
 	}, nil
 }
 
+// MatchDataEvent journals the match data, if the match data journal is enabled.
 func MatchDataEvent(ctx context.Context, nk runtime.NakamaModule, matchID MatchID, data any) error {
 
-	return nil // Disable for now
+	if settings := ServiceSettings(); settings == nil || !settings.EnableMatchDataJournal {
+		return nil
+	}
+
 	entry, err := NewEventEnvelopeMatchData(matchID, data)
 	if err != nil {
 		return fmt.Errorf("failed to create match data event: %w", err)
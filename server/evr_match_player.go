@@ -30,6 +30,7 @@ type PlayerInfo struct {
 	GeoHash        string     `json:"geohash,omitempty"`
 	PingMillis     int        `json:"ping_ms,omitempty"` // The latency as measured from the ping check.
 	MatchmakingAt  *time.Time `json:"matchmaking_at,omitempty"`
+	IsVoiceMuted   bool       `json:"voice_muted,omitempty"` // Game servers should mute the player's voice
 }
 
 // The player joined after the round clock started
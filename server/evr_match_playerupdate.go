@@ -4,4 +4,5 @@ type MatchPlayerUpdate struct {
 	SessionID     string `json:"session_id"`
 	IsMatchmaking *bool  `json:"is_matchmaking"`
 	RoleAlignment *int   `json:"role"`
+	IsVoiceMuted  *bool  `json:"voice_muted"`
 }
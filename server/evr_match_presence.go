@@ -46,6 +46,8 @@ type EvrMatchPresence struct {
 	Rating            types.Rating `json:"rating,omitempty"`
 	PingMillis        int          `json:"ping_ms,omitempty"`
 	MatchmakingAt     *time.Time   `json:"matchmaking_at,omitempty"` // Whether the player is matchmaking
+	IsVoiceMuted      bool         `json:"voice_muted,omitempty"`    // The player has an active voice mute
+	IsSpectatorOnly   bool         `json:"spectator_only,omitempty"` // The player may only join as a spectator
 }
 
 func (p EvrMatchPresence) EntrantID(matchID MatchID) uuid.UUID {
@@ -116,6 +118,15 @@ func EntrantPresenceFromSession(session Session, partyID uuid.UUID, roleAlignmen
 		return nil, errors.New("failed to get session parameters")
 	}
 
+	// Apply the enforcement actions loaded when the lobby was authorized, or refreshed when they were changed.
+	var isVoiceMuted, isSpectatorOnly bool
+	if params.enforcementRecords != nil {
+		if records := params.enforcementRecords.Load(); records != nil && records.GroupID == groupID {
+			isVoiceMuted = records.ActiveAction(EnforcementActionVoiceMute) != nil
+			isSpectatorOnly = records.ActiveAction(EnforcementActionSpectatorOnly) != nil
+		}
+	}
+
 	return &EvrMatchPresence{
 		Node:              params.node,
 		UserID:            session.UserID(),
@@ -139,5 +150,7 @@ func EntrantPresenceFromSession(session Session, partyID uuid.UUID, roleAlignmen
 		DisableMAC:        params.disableMAC,
 		PingMillis:        ping,
 		Query:             query,
+		IsVoiceMuted:      isVoiceMuted,
+		IsSpectatorOnly:   isSpectatorOnly,
 	}, nil
 }
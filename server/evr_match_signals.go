@@ -25,6 +25,7 @@ const (
 	SignalShutdown
 	SignalPlayerUpdate
 	SignalKickEntrants
+	SignalGameServerAction
 )
 
 type SignalEnvelope struct {
@@ -89,8 +90,13 @@ type SignalShutdownPayload struct {
 	DisconnectUsers      bool `json:"disconnect_users"`
 }
 
+type SignalGameServerActionPayload struct {
+	Action GameServerAction `json:"action"`
+}
+
 type SignalKickEntrantsPayload struct {
 	UserIDs []uuid.UUID `json:"user_ids"`
+	Message string      `json:"message,omitempty"` // Displayed to the kicked entrants
 }
 
 type SignalReserveSlotsPayload struct {
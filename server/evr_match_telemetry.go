@@ -0,0 +1,280 @@
+package server
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+// The most ghost, mute and net explosion events kept for each player.
+const matchTelemetryMaxEvents = 100
+
+// MatchTelemetry is the client telemetry of a match, by player, aggregated from the remote logs in its journals.
+type MatchTelemetry struct {
+	MatchID          string                           `json:"match_id"`
+	MatchType        string                           `json:"match_type,omitempty"`
+	SessionStartedAt time.Time                        `json:"session_started_at,omitempty"`
+	Players          map[string]*MatchPlayerTelemetry `json:"players"` // By user ID
+}
+
+func (t MatchTelemetry) String() string {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+type MatchPlayerTelemetry struct {
+	UserID        string                         `json:"user_id"`
+	EvrID         string                         `json:"evr_id,omitempty"`
+	DisplayName   string                         `json:"display_name,omitempty"`
+	BuildNumber   evr.BuildNumber                `json:"build_number,omitempty"`
+	Voice         MatchVoiceTelemetry            `json:"voice"`
+	Ghosts        []*MatchGhostEvent             `json:"ghosts,omitempty"` // Ghosts and mutes, in order
+	Gear          map[string]*MatchGearTelemetry `json:"gear,omitempty"`   // By gear name
+	Repairs       MatchRepairTelemetry           `json:"repairs"`
+	NetExplosions []*MatchNetExplosion           `json:"net_explosions,omitempty"`
+	Load          *MatchLoadTelemetry            `json:"load,omitempty"`
+}
+
+type MatchVoiceTelemetry struct {
+	Samples        int     `json:"samples"`
+	MeanLoudnessDB float64 `json:"mean_loudness_db"`
+	PeakLoudnessDB float64 `json:"peak_loudness_db"`
+}
+
+type MatchGhostEvent struct {
+	Time              time.Time `json:"time"`
+	Action            string    `json:"action"` // The remote log's message type, e.g. GHOST_USER or MUTE_USER
+	Enabled           bool      `json:"enabled"`
+	TargetEvrID       string    `json:"target_evr_id,omitempty"`
+	TargetDisplayName string    `json:"target_display_name,omitempty"`
+}
+
+type MatchGearTelemetry struct {
+	Rounds    int     `json:"rounds"`
+	TotalTime float64 `json:"total_time"`
+	Kills     int64   `json:"kills"`
+}
+
+type MatchRepairTelemetry struct {
+	Count      int     `json:"count"`
+	HealAmount float64 `json:"heal_amount"`
+	NumHealed  int64   `json:"num_healed"`
+}
+
+type MatchNetExplosion struct {
+	GameTime     float64 `json:"game_time"`
+	Type         string  `json:"type"`
+	DamageAmount float64 `json:"damage_amount"`
+}
+
+type MatchLoadTelemetry struct {
+	HeadsetType         string  `json:"headset_type,omitempty"`
+	LoadTime            float64 `json:"load_time"`
+	ClientLoadTime      float64 `json:"client_load_time"`
+	ServerLoadTime      float64 `json:"server_load_time"`
+	MultiplayerLoadTime float64 `json:"multiplayer_load_time"`
+	MatchmakingTime     float64 `json:"matchmaking_time"`
+}
+
+type matchTelemetryRemoteLogs struct {
+	UserID      string            `json:"sender_user_id"`
+	BuildNumber evr.BuildNumber   `json:"build_number"`
+	Logs        []json.RawMessage `json:"logs"`
+}
+
+// isSessionlessMatchTelemetry reports whether the log is match telemetry without a session ID.
+func isSessionlessMatchTelemetry(log evr.RemoteLog) bool {
+	switch log.(type) {
+	case *evr.RemoteLogGhostUser, *evr.RemoteLogGhostAll, *evr.RemoteLogInteractionEvent, *evr.RemoteLogLoadStats:
+		return true
+	}
+	return false
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// NewMatchTelemetry aggregates the remote logs of the match's journals.
+func NewMatchTelemetry(matchID string, journals []*MatchDataJournal) *MatchTelemetry {
+	t := &MatchTelemetry{
+		MatchID: matchID,
+		Players: make(map[string]*MatchPlayerTelemetry),
+	}
+
+	for _, j := range journals {
+		for _, e := range j.Events {
+			data, err := json.Marshal(e.Data)
+			if err != nil || matchDataEntryType(e, data) != MatchDataTypeRemoteLogs {
+				continue
+			}
+			m := matchTelemetryRemoteLogs{}
+			if err := json.Unmarshal(data, &m); err != nil || m.UserID == "" {
+				continue
+			}
+			for _, raw := range m.Logs {
+				log, err := evr.RemoteLogMessageFromLogString(raw)
+				if err != nil {
+					continue
+				}
+				t.add(e.CreatedAt, m.UserID, m.BuildNumber, log)
+			}
+		}
+	}
+
+	for _, p := range t.Players {
+		slices.SortStableFunc(p.Ghosts, func(a, b *MatchGhostEvent) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+	return t
+}
+
+func (t *MatchTelemetry) player(userID string, buildNumber evr.BuildNumber) *MatchPlayerTelemetry {
+	p, ok := t.Players[userID]
+	if !ok {
+		p = &MatchPlayerTelemetry{UserID: userID}
+		t.Players[userID] = p
+	}
+	if buildNumber != 0 {
+		p.BuildNumber = buildNumber
+	}
+	return p
+}
+
+func (p *MatchPlayerTelemetry) identify(evrID, displayName string) {
+	if evrID != "" {
+		p.EvrID = evrID
+	}
+	if displayName != "" {
+		p.DisplayName = displayName
+	}
+}
+
+func (t *MatchTelemetry) add(ts time.Time, userID string, buildNumber evr.BuildNumber, log evr.RemoteLog) {
+	p := t.player(userID, buildNumber)
+
+	switch log := log.(type) {
+	case *evr.RemoteLogSessionStarted:
+		if t.SessionStartedAt.IsZero() || ts.Before(t.SessionStartedAt) {
+			t.SessionStartedAt = ts
+		}
+		t.MatchType = log.MatchType
+
+	case *evr.RemoteLogVOIPLoudness:
+		p.identify(log.PlayerInfoUserid, log.PlayerInfoDisplayname)
+		v := &p.Voice
+		v.MeanLoudnessDB = (v.MeanLoudnessDB*float64(v.Samples) + log.VoiceLoudnessDB) / float64(v.Samples+1)
+		if v.Samples == 0 || log.MaxLoudnessDB > v.PeakLoudnessDB {
+			v.PeakLoudnessDB = log.MaxLoudnessDB
+		}
+		v.Samples++
+
+	case *evr.RemoteLogGhostUser:
+		p.identify(log.PlayerUserid, log.PlayerDisplayname)
+		p.addGhost(&MatchGhostEvent{
+			Time:              ts,
+			Action:            log.MessageType(),
+			Enabled:           log.Enabled,
+			TargetEvrID:       log.OtherPlayerUserid,
+			TargetDisplayName: log.OtherPlayerDisplayname,
+		})
+
+	case *evr.RemoteLogGhostAll:
+		p.identify(log.PlayerUserid, log.PlayerDisplayname)
+		p.addGhost(&MatchGhostEvent{
+			Time:    ts,
+			Action:  log.MessageType(),
+			Enabled: log.Enabled,
+		})
+
+	case *evr.RemoteLogInteractionEvent:
+		if !strings.HasPrefix(log.MessageType(), "MUTE_") {
+			return
+		}
+		p.addGhost(&MatchGhostEvent{
+			Time:        ts,
+			Action:      log.MessageType(),
+			Enabled:     true,
+			TargetEvrID: log.UserID,
+		})
+
+	case *evr.RemoteLogRepairMatrix:
+		// The repair matrix type is also used for several post-match messages.
+		if log.MessageType() != "REPAIR_MATRIX" {
+			return
+		}
+		p.identify(log.PlayerInfoUserid, log.PlayerInfoDisplayname)
+		p.Repairs.Count++
+		p.Repairs.HealAmount += log.HealAmount
+		p.Repairs.NumHealed += log.NumHealed
+
+	case *evr.RemoteLogGearStatsPerRound:
+		p.identify(log.PlayerInfoUserid, log.PlayerInfoDisplayname)
+		if p.Gear == nil {
+			p.Gear = make(map[string]*MatchGearTelemetry)
+		}
+		for name, usage := range gearStatsUsage(log) {
+			if usage.TotalTime == 0 && usage.Kills == 0 {
+				continue
+			}
+			g, ok := p.Gear[name]
+			if !ok {
+				g = &MatchGearTelemetry{}
+				p.Gear[name] = g
+			}
+			g.Rounds++
+			g.TotalTime += usage.TotalTime
+			g.Kills += usage.Kills
+		}
+
+	case *evr.RemoteLogNetExplosion:
+		p.identify(log.PlayerInfoUserid, log.PlayerInfoDisplayname)
+		if len(p.NetExplosions) < matchTelemetryMaxEvents {
+			p.NetExplosions = append(p.NetExplosions, &MatchNetExplosion{
+				GameTime:     log.GameInfoGameTime,
+				Type:         log.ExplosionType,
+				DamageAmount: log.DamageAmount,
+			})
+		}
+
+	case *evr.RemoteLogLoadStats:
+		p.identify(log.PlayerInfoUserid, log.PlayerInfoDisplayname)
+		p.Load = &MatchLoadTelemetry{
+			HeadsetType:         log.HeadsetType,
+			LoadTime:            log.LoadTime,
+			ClientLoadTime:      log.ClientLoadTime,
+			ServerLoadTime:      log.ServerLoadTime,
+			MultiplayerLoadTime: log.MultiplayerLoadTime,
+			MatchmakingTime:     log.MatchmakingTime,
+		}
+	}
+}
+
+func (p *MatchPlayerTelemetry) addGhost(e *MatchGhostEvent) {
+	if len(p.Ghosts) < matchTelemetryMaxEvents {
+		p.Ghosts = append(p.Ghosts, e)
+	}
+}
+
+// gearStatsUsage returns the round's usage of each piece of gear.
+func gearStatsUsage(m *evr.RemoteLogGearStatsPerRound) map[string]MatchGearTelemetry {
+	return map[string]MatchGearTelemetry{
+		"assault": {TotalTime: m.GEARAssaultTotalTime, Kills: m.GEARAssaultKillCount},
+		"blaster": {TotalTime: m.GEARBlasterTotalTime, Kills: m.GEARBlasterKillCount},
+		"det":     {TotalTime: m.GEARDetTotalTime, Kills: m.GEARDetKillCount},
+		"stun":    {TotalTime: m.GEARStunTotalTime, Kills: m.GEARStunKillCount},
+		"arc":     {TotalTime: m.GEARArcTotalTime, Kills: m.GEARArcKillCount},
+		"burst":   {TotalTime: m.GEARBurstTotalTime, Kills: m.GEARBurstKillCount},
+		"heal":    {TotalTime: m.GEARHealTotalTime, Kills: m.GEARHealKillCount},
+		"sensor":  {TotalTime: m.GEARSensorTotalTime, Kills: m.GEARSensorKillCount},
+		"shield":  {TotalTime: m.GEARShieldTotalTime, Kills: m.GEARShieldKillCount},
+		"wraith":  {TotalTime: m.GEARWraithTotalTime, Kills: m.GEARWraithKillCount},
+	}
+}
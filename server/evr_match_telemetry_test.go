@@ -0,0 +1,77 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+func TestNewMatchTelemetry(t *testing.T) {
+	matchID := MatchID{UUID: uuid.Must(uuid.NewV4()), Node: "testnode"}
+	sessionUUID := "{" + matchID.UUID.String() + "}"
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	userID := uuid.Must(uuid.NewV4()).String()
+
+	logs := []evr.RemoteLog{
+		&evr.RemoteLogSessionStarted{GenericRemoteLog: evr.GenericRemoteLog{Type: "SESSION_STARTED"}, SessionUUIDStr: sessionUUID, MatchType: "Echo_Combat"},
+		&evr.RemoteLogVOIPLoudness{GenericRemoteLog: evr.GenericRemoteLog{Type: "VOIP_LOUDNESS"}, SessionUUIDStr: sessionUUID, PlayerInfoUserid: "OVR-ORG-1", PlayerInfoDisplayname: "Player", VoiceLoudnessDB: -30, MaxLoudnessDB: -10},
+		&evr.RemoteLogVOIPLoudness{GenericRemoteLog: evr.GenericRemoteLog{Type: "VOIP_LOUDNESS"}, SessionUUIDStr: sessionUUID, VoiceLoudnessDB: -20, MaxLoudnessDB: -5},
+		&evr.RemoteLogGearStatsPerRound{GenericRemoteLog: evr.GenericRemoteLog{Type: "GEAR_STATS_PER_ROUND"}, SessionUUIDStr: sessionUUID, GEARAssaultTotalTime: 60, GEARAssaultKillCount: 2},
+		&evr.RemoteLogGearStatsPerRound{GenericRemoteLog: evr.GenericRemoteLog{Type: "GEAR_STATS_PER_ROUND"}, SessionUUIDStr: sessionUUID, GEARAssaultTotalTime: 30, GEARAssaultKillCount: 1, GEARHealTotalTime: 10},
+		&evr.RemoteLogNetExplosion{GenericRemoteLog: evr.GenericRemoteLog{Type: "NET_EXPLOSION"}, SessionUUIDStr: sessionUUID, ExplosionType: "det", DamageAmount: 40},
+		&evr.RemoteLogRepairMatrix{GenericRemoteLog: evr.GenericRemoteLog{Type: "REPAIR_MATRIX"}, SessionUUIDStr: sessionUUID, HealAmount: 25, NumHealed: 2},
+		&evr.RemoteLogRepairMatrix{GenericRemoteLog: evr.GenericRemoteLog{Type: "POST_MATCH_EARNED_AWARD"}, SessionUUIDStr: sessionUUID, HealAmount: 1000},
+		&evr.RemoteLogGhostUser{GenericRemoteLog: evr.GenericRemoteLog{Type: "GHOST_USER"}, Enabled: true, OtherPlayerUserid: "OVR-ORG-2", OtherPlayerDisplayname: "Other"},
+		&evr.RemoteLogLoadStats{GenericRemoteLog: evr.GenericRemoteLog{Type: "LOAD_STATS"}, HeadsetType: "Quest 3", LoadTime: 12.5},
+	}
+
+	j := NewMatchDataJournal(matchID)
+	for i, log := range logs {
+		j.Events = append(j.Events, testJournalEntry(t, start.Add(time.Duration(i)*time.Second), true, MatchDataRemoteLogSet{
+			UserID:      userID,
+			SessionID:   matchID.String(),
+			BuildNumber: evr.StandaloneBuildNumber,
+			Logs:        []evr.RemoteLog{log},
+		}))
+	}
+
+	telemetry := NewMatchTelemetry(matchID.String(), []*MatchDataJournal{j})
+
+	if telemetry.MatchType != "Echo_Combat" || !telemetry.SessionStartedAt.Equal(start) {
+		t.Errorf("unexpected session: %s at %v", telemetry.MatchType, telemetry.SessionStartedAt)
+	}
+
+	p, ok := telemetry.Players[userID]
+	if !ok {
+		t.Fatalf("expected telemetry for %s", userID)
+	}
+	if p.EvrID != "OVR-ORG-1" || p.DisplayName != "Player" || p.BuildNumber != evr.StandaloneBuildNumber {
+		t.Errorf("unexpected player: %s %s %d", p.EvrID, p.DisplayName, p.BuildNumber)
+	}
+	if p.Voice.Samples != 2 || p.Voice.MeanLoudnessDB != -25 || p.Voice.PeakLoudnessDB != -5 {
+		t.Errorf("unexpected voice telemetry: %+v", p.Voice)
+	}
+	if g := p.Gear["assault"]; g == nil || g.Rounds != 2 || g.TotalTime != 90 || g.Kills != 3 {
+		t.Errorf("unexpected assault telemetry: %+v", g)
+	}
+	if g := p.Gear["heal"]; g == nil || g.Rounds != 1 {
+		t.Errorf("unexpected heal telemetry: %+v", g)
+	}
+	if _, ok := p.Gear["blaster"]; ok {
+		t.Errorf("expected no telemetry for unused gear")
+	}
+	if len(p.NetExplosions) != 1 || p.NetExplosions[0].Type != "det" {
+		t.Errorf("unexpected net explosions: %+v", p.NetExplosions)
+	}
+	if p.Repairs.Count != 1 || p.Repairs.HealAmount != 25 || p.Repairs.NumHealed != 2 {
+		t.Errorf("unexpected repairs: %+v", p.Repairs)
+	}
+	if len(p.Ghosts) != 1 || p.Ghosts[0].Action != "GHOST_USER" || p.Ghosts[0].TargetEvrID != "OVR-ORG-2" || !p.Ghosts[0].Enabled {
+		t.Errorf("unexpected ghosts: %+v", p.Ghosts)
+	}
+	if p.Load == nil || p.Load.LoadTime != 12.5 || p.Load.HeadsetType != "Quest 3" {
+		t.Errorf("unexpected load telemetry: %+v", p.Load)
+	}
+}
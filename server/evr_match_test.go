@@ -1218,3 +1218,34 @@ func TestEvrMatch_processJoin(t *testing.T) {
 }
 
 */
+
+func TestEvrMatch_MatchSignal_GameServerAction(t *testing.T) {
+	logger := NewRuntimeGoLogger(NewJSONLogger(os.Stdout, zapcore.ErrorLevel, JSONFormat))
+	nk := &RuntimeGoNakamaModule{sessionRegistry: NewLocalSessionRegistry(metrics)}
+
+	tests := []struct {
+		name     string
+		state    *MatchLabel
+		action   GameServerAction
+		expected error
+	}{
+		{"invalid action", &MatchLabel{LobbyType: UnassignedLobby, GameServer: &GameServerPresence{}}, "reboot", ErrGameServerInvalidAction},
+		{"restart while in match", &MatchLabel{LobbyType: PublicLobby, GameServer: &GameServerPresence{}}, GameServerActionRestartParking, ErrGameServerInMatch},
+		{"restart while draining", &MatchLabel{LobbyType: UnassignedLobby, GameServer: &GameServerPresence{Draining: true}}, GameServerActionRestartParking, ErrGameServerDraining},
+		{"drain without session", &MatchLabel{LobbyType: PublicLobby, GameServer: &GameServerPresence{}}, GameServerActionDrain, ErrGameServerPresenceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := NewSignalEnvelope(SystemUserID, SignalGameServerAction, SignalGameServerActionPayload{Action: tt.action})
+			_, data := (&EvrMatch{}).MatchSignal(context.Background(), logger, nil, nk, nil, 0, tt.state, signal.String())
+			response := SignalResponseFromString(data)
+			if response.Success || response.Message != tt.expected.Error() {
+				t.Errorf("expected %q, got %s", tt.expected, data)
+			}
+			if tt.state.GameServer.Draining != (tt.action == GameServerActionRestartParking && tt.expected == ErrGameServerDraining) {
+				t.Error("expected the drain state to be unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+const (
+	MatchTimelineEventStarted    = "match_started"
+	MatchTimelineEventJoin       = "player_join"
+	MatchTimelineEventLeave      = "player_leave"
+	MatchTimelineEventEarlyQuit  = "early_quit"
+	MatchTimelineEventTeamSwitch = "team_switch"
+	MatchTimelineEventGoal       = "goal"
+	MatchTimelineEventRoundOver  = "round_over"
+)
+
+// MatchTimelineEvent is a single event in a match, in the order it occurred.
+type MatchTimelineEvent struct {
+	Time        time.Time  `json:"time"`
+	RoundClock  float64    `json:"round_clock_secs,omitempty"` // The round clock time of the event, when known
+	Type        string     `json:"type"`
+	UserID      string     `json:"user_id,omitempty"`
+	EvrID       evr.EvrId  `json:"evr_id,omitempty"`
+	DisplayName string     `json:"display_name,omitempty"`
+	Team        *TeamIndex `json:"team,omitempty"`
+	FromTeam    *TeamIndex `json:"from_team,omitempty"` // The previous team (team switches only)
+	Reason      string     `json:"reason,omitempty"`
+	GoalType    string     `json:"goal_type,omitempty"`
+	Points      int        `json:"points,omitempty"`
+	BlueScore   int        `json:"blue_score,omitempty"`
+	OrangeScore int        `json:"orange_score,omitempty"`
+}
+
+// MatchTimelinePlayer summarizes a player's participation in a match.
+type MatchTimelinePlayer struct {
+	UserID      string    `json:"user_id"`
+	EvrID       evr.EvrId `json:"evr_id"`
+	DisplayName string    `json:"display_name"`
+	Team        TeamIndex `json:"team"`
+	JoinedAt    time.Time `json:"joined_at"`
+	LeftAt      time.Time `json:"left_at,omitempty"`
+	EarlyQuit   bool      `json:"early_quit,omitempty"`
+	Goals       int       `json:"goals,omitempty"`
+	Points      int       `json:"points,omitempty"`
+}
+
+// MatchTimeline is the ordered history of a match, reconstructed from its match data journals.
+type MatchTimeline struct {
+	MatchID       string                 `json:"match_id"`
+	Mode          evr.Symbol             `json:"mode,omitempty"`
+	Level         evr.Symbol             `json:"level,omitempty"`
+	GroupID       string                 `json:"group_id,omitempty"`
+	StartTime     time.Time              `json:"start_time,omitempty"`
+	EndTime       time.Time              `json:"end_time,omitempty"`
+	BlueScore     int                    `json:"blue_score"`
+	OrangeScore   int                    `json:"orange_score"`
+	PredictedDraw float64                `json:"predicted_draw,omitempty"` // The predicted probability of a draw, to compare with the score
+	Players       []*MatchTimelinePlayer `json:"players"`
+	Events        []*MatchTimelineEvent  `json:"events"`
+}
+
+func (t MatchTimeline) String() string {
+	data, _ := json.Marshal(t)
+	return string(data)
+}
+
+// HasPlayer reports whether the user joined the match.
+func (t *MatchTimeline) HasPlayer(userID string) bool {
+	return t.Player(userID) != nil
+}
+
+func (t *MatchTimeline) Player(userID string) *MatchTimelinePlayer {
+	for _, p := range t.Players {
+		if p.UserID == userID {
+			return p
+		}
+	}
+	return nil
+}
+
+// matchDataRemoteLogs is a MatchDataRemoteLogSet with the logs left undecoded.
+type matchDataRemoteLogs struct {
+	UserID string            `json:"sender_user_id"`
+	Logs   []json.RawMessage `json:"logs"`
+}
+
+type matchTimelineBuilder struct {
+	timeline   *MatchTimeline
+	teams      map[string]TeamIndex // map[userID]TeamIndex
+	userIDs    map[evr.EvrId]string // map[evrID]userID
+	goals      map[string]struct{}  // The goals already added (each player sends their own copy)
+	rounds     map[int64]struct{}   // The rounds already added
+	goalScores [2]int               // The score, from the goals
+	roundOver  *evr.RemoteLogRoundOver
+	lastState  *MatchLabel
+}
+
+// NewMatchTimeline reconstructs the timeline of a match from its journals.
+func NewMatchTimeline(matchID string, journals []*MatchDataJournal) *MatchTimeline {
+	b := &matchTimelineBuilder{
+		timeline: &MatchTimeline{
+			MatchID: matchID,
+			Players: make([]*MatchTimelinePlayer, 0),
+			Events:  make([]*MatchTimelineEvent, 0),
+		},
+		teams:   make(map[string]TeamIndex),
+		userIDs: make(map[evr.EvrId]string),
+		goals:   make(map[string]struct{}),
+		rounds:  make(map[int64]struct{}),
+	}
+
+	entries := make([]*MatchDataJournalEntry, 0)
+	for _, j := range journals {
+		entries = append(entries, j.Events...)
+	}
+	slices.SortStableFunc(entries, func(a, b *MatchDataJournalEntry) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	for _, e := range entries {
+		b.add(e)
+	}
+
+	return b.finish()
+}
+
+func (b *matchTimelineBuilder) add(e *MatchDataJournalEntry) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return
+	}
+
+	switch matchDataEntryType(e, data) {
+	case MatchDataTypeStarted:
+		m := MatchDataStarted{}
+		if err := json.Unmarshal(data, &m); err != nil || m.State == nil {
+			return
+		}
+		b.updateState(e.CreatedAt, m.State)
+		b.timeline.Events = append(b.timeline.Events, &MatchTimelineEvent{
+			Time: e.CreatedAt,
+			Type: MatchTimelineEventStarted,
+		})
+
+	case MatchDataTypePlayerJoin:
+		m := MatchDataPlayerJoin{}
+		if err := json.Unmarshal(data, &m); err != nil || m.Presence == nil {
+			return
+		}
+		b.join(e.CreatedAt, m.State, m.Presence)
+		if m.State != nil {
+			b.updateState(e.CreatedAt, m.State)
+		}
+
+	case MatchDataTypePlayerLeave:
+		m := MatchDataPlayerLeave{}
+		if err := json.Unmarshal(data, &m); err != nil || m.Presence == nil {
+			return
+		}
+		b.leave(e.CreatedAt, m.State, m.Presence, m.Reason)
+		if m.State != nil {
+			b.updateState(e.CreatedAt, m.State)
+		}
+
+	case MatchDataTypeRemoteLogs:
+		m := matchDataRemoteLogs{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return
+		}
+		for _, raw := range m.Logs {
+			log, err := evr.RemoteLogMessageFromLogString(raw)
+			if err != nil {
+				continue
+			}
+			switch log := log.(type) {
+			case *evr.RemoteLogGoal:
+				b.goal(e.CreatedAt, log)
+			case *evr.RemoteLogRoundOver:
+				b.round(e.CreatedAt, log)
+			}
+		}
+	}
+}
+
+// matchDataEntryType returns the type of the entry. Entries journaled without a type are identified by their fields.
+func matchDataEntryType(e *MatchDataJournalEntry, data []byte) string {
+	if e.Type != "" {
+		return e.Type
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return ""
+	}
+	has := func(k string) bool {
+		_, ok := fields[k]
+		return ok
+	}
+	switch {
+	case has("logs"):
+		return MatchDataTypeRemoteLogs
+	case has("reason") && has("presence"):
+		return MatchDataTypePlayerLeave
+	case has("presence"):
+		return MatchDataTypePlayerJoin
+	case has("state"):
+		return MatchDataTypeStarted
+	}
+	return ""
+}
+
+// updateState records the match details, and any team changes shown in the label.
+func (b *matchTimelineBuilder) updateState(ts time.Time, state *MatchLabel) {
+	t := b.timeline
+	t.Mode = state.Mode
+	t.Level = state.Level
+	if state.GroupID != nil {
+		t.GroupID = state.GroupID.String()
+	}
+	if !state.StartTime.IsZero() {
+		t.StartTime = state.StartTime
+	}
+	if state.PredictedDraw > 0 {
+		t.PredictedDraw = state.PredictedDraw
+	}
+
+	for _, p := range state.Players {
+		if p.UserID == "" {
+			continue
+		}
+		b.setTeam(ts, state, p.UserID, p.EvrID, p.DisplayName, p.Team)
+	}
+
+	b.lastState = state
+}
+
+func (b *matchTimelineBuilder) setTeam(ts time.Time, state *MatchLabel, userID string, evrID evr.EvrId, displayName string, team TeamIndex) {
+	prev, ok := b.teams[userID]
+	b.teams[userID] = team
+	if p := b.timeline.Player(userID); p != nil {
+		p.Team = team
+	}
+	if !ok || prev == team {
+		return
+	}
+	b.timeline.Events = append(b.timeline.Events, &MatchTimelineEvent{
+		Time:        ts,
+		RoundClock:  roundClockSecondsAt(state, ts),
+		Type:        MatchTimelineEventTeamSwitch,
+		UserID:      userID,
+		EvrID:       evrID,
+		DisplayName: displayName,
+		Team:        &team,
+		FromTeam:    &prev,
+	})
+}
+
+func (b *matchTimelineBuilder) join(ts time.Time, state *MatchLabel, presence *EvrMatchPresence) {
+	userID := presence.GetUserId()
+	team := TeamIndex(presence.RoleAlignment)
+	if state != nil {
+		for _, p := range state.Players {
+			if p.UserID == userID {
+				team = p.Team
+				break
+			}
+		}
+	}
+
+	b.userIDs[presence.EvrID] = userID
+
+	p := b.timeline.Player(userID)
+	if p == nil {
+		p = &MatchTimelinePlayer{
+			UserID:      userID,
+			EvrID:       presence.EvrID,
+			DisplayName: presence.DisplayName,
+			Team:        team,
+			JoinedAt:    ts,
+		}
+		b.timeline.Players = append(b.timeline.Players, p)
+	}
+	p.LeftAt = time.Time{}
+
+	b.timeline.Events = append(b.timeline.Events, &MatchTimelineEvent{
+		Time:        ts,
+		RoundClock:  roundClockSecondsAt(state, ts),
+		Type:        MatchTimelineEventJoin,
+		UserID:      userID,
+		EvrID:       presence.EvrID,
+		DisplayName: presence.DisplayName,
+		Team:        &team,
+	})
+
+	// A player that rejoins on the other team has switched teams.
+	b.setTeam(ts, state, userID, presence.EvrID, presence.DisplayName, team)
+}
+
+func (b *matchTimelineBuilder) leave(ts time.Time, state *MatchLabel, presence *EvrMatchPresence, reason string) {
+	userID := presence.GetUserId()
+
+	eventType := MatchTimelineEventLeave
+	if isEarlyQuit(state, ts) {
+		eventType = MatchTimelineEventEarlyQuit
+	}
+
+	event := &MatchTimelineEvent{
+		Time:        ts,
+		RoundClock:  roundClockSecondsAt(state, ts),
+		Type:        eventType,
+		UserID:      userID,
+		EvrID:       presence.EvrID,
+		DisplayName: presence.DisplayName,
+		Reason:      reason,
+	}
+	if team, ok := b.teams[userID]; ok {
+		event.Team = &team
+	}
+	b.timeline.Events = append(b.timeline.Events, event)
+
+	// The player may have joined before the first journal.
+	p := b.timeline.Player(userID)
+	if p == nil {
+		p = &MatchTimelinePlayer{
+			UserID:      userID,
+			EvrID:       presence.EvrID,
+			DisplayName: presence.DisplayName,
+			Team:        TeamIndex(presence.RoleAlignment),
+		}
+		if event.Team != nil {
+			p.Team = *event.Team
+		}
+		b.timeline.Players = append(b.timeline.Players, p)
+	}
+	p.LeftAt = ts
+	p.EarlyQuit = p.EarlyQuit || eventType == MatchTimelineEventEarlyQuit
+}
+
+func (b *matchTimelineBuilder) goal(ts time.Time, goal *evr.RemoteLogGoal) {
+	key := fmt.Sprintf("%.1f:%s:%s", goal.GameInfoGameTime, goal.GoalType, goal.PlayerInfoEvrID.String())
+	if _, ok := b.goals[key]; ok {
+		return
+	}
+	b.goals[key] = struct{}{}
+
+	team := TeamIndex(goal.PlayerInfoTeamID)
+	points := GoalTypeToPoints(goal.GoalType)
+	if team == BlueTeam || team == OrangeTeam {
+		b.goalScores[team] += points
+	}
+
+	userID := b.userIDs[goal.PlayerInfoEvrID]
+	if p := b.timeline.Player(userID); p != nil {
+		p.Goals++
+		p.Points += points
+	}
+
+	b.timeline.Events = append(b.timeline.Events, &MatchTimelineEvent{
+		Time:        ts,
+		RoundClock:  goal.GameInfoGameTime,
+		Type:        MatchTimelineEventGoal,
+		UserID:      userID,
+		EvrID:       goal.PlayerInfoEvrID,
+		DisplayName: goal.PlayerInfoDisplayName,
+		Team:        &team,
+		GoalType:    goal.GoalType,
+		Points:      points,
+		BlueScore:   b.goalScores[BlueTeam],
+		OrangeScore: b.goalScores[OrangeTeam],
+	})
+}
+
+func (b *matchTimelineBuilder) round(ts time.Time, round *evr.RemoteLogRoundOver) {
+	if _, ok := b.rounds[round.GameInfoRoundNumber]; ok {
+		return
+	}
+	b.rounds[round.GameInfoRoundNumber] = struct{}{}
+	b.roundOver = round
+
+	b.timeline.Events = append(b.timeline.Events, &MatchTimelineEvent{
+		Time:        ts,
+		RoundClock:  round.GameInfoGameTime,
+		Type:        MatchTimelineEventRoundOver,
+		BlueScore:   int(round.GameInfoBlueMatchScore),
+		OrangeScore: int(round.GameInfoOrangeMatchScore),
+	})
+}
+
+// finish sets the final score; from the round over log if there is one, otherwise from the goals or the last label.
+func (b *matchTimelineBuilder) finish() *MatchTimeline {
+	t := b.timeline
+
+	switch {
+	case b.roundOver != nil:
+		t.BlueScore = int(b.roundOver.GameInfoBlueMatchScore)
+		t.OrangeScore = int(b.roundOver.GameInfoOrangeMatchScore)
+	case len(b.goals) > 0:
+		t.BlueScore = b.goalScores[BlueTeam]
+		t.OrangeScore = b.goalScores[OrangeTeam]
+	case b.lastState != nil && b.lastState.GameState != nil:
+		t.BlueScore = b.lastState.GameState.BlueScore
+		t.OrangeScore = b.lastState.GameState.OrangeScore
+	}
+
+	slices.SortStableFunc(t.Events, func(a, b *MatchTimelineEvent) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	if n := len(t.Events); n > 0 {
+		t.EndTime = t.Events[n-1].Time
+	}
+
+	return t
+}
+
+// isEarlyQuit reports whether a player leaving at the given time quit the match before it was over.
+// This matches the conditions under which an early quit is counted against the player.
+func isEarlyQuit(state *MatchLabel, ts time.Time) bool {
+	if state == nil || state.Mode != evr.ModeArenaPublic || state.GameState == nil || state.GameState.MatchOver {
+		return false
+	}
+	return !state.StartTime.IsZero() && ts.Sub(state.StartTime) >= 60*time.Second
+}
+
+// roundClockSecondsAt returns the round clock time at the given time, according to the label.
+func roundClockSecondsAt(state *MatchLabel, ts time.Time) float64 {
+	if state == nil || state.GameState == nil || state.GameState.RoundClock == nil {
+		return 0
+	}
+	rc := state.GameState.RoundClock
+
+	elapsed := rc.Elapsed
+	if rc.PausedAt.IsZero() && !rc.UpdatedAt.IsZero() && ts.After(rc.UpdatedAt) {
+		elapsed += ts.Sub(rc.UpdatedAt)
+	}
+	if rc.Duration > 0 && elapsed > rc.Duration {
+		elapsed = rc.Duration
+	}
+	return elapsed.Seconds()
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+// testJournalEntry creates the entry as it is read back from a sink.
+func testJournalEntry(t *testing.T, ts time.Time, withType bool, data any) *MatchDataJournalEntry {
+	t.Helper()
+	b, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	e := &MatchDataJournalEntry{CreatedAt: ts, Data: m}
+	if withType {
+		e.Type = MatchDataType(data)
+	}
+	return e
+}
+
+func TestNewMatchTimeline(t *testing.T) {
+	matchID := MatchID{UUID: uuid.Must(uuid.NewV4()), Node: "testnode"}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	blue := &EvrMatchPresence{
+		UserID:      uuid.Must(uuid.NewV4()),
+		EvrID:       evr.EvrId{PlatformCode: evr.OVR_ORG, AccountId: 1},
+		DisplayName: "bluey",
+	}
+	orange := &EvrMatchPresence{
+		UserID:        uuid.Must(uuid.NewV4()),
+		EvrID:         evr.EvrId{PlatformCode: evr.OVR_ORG, AccountId: 2},
+		DisplayName:   "orangey",
+		RoleAlignment: int(OrangeTeam),
+	}
+
+	label := func(players ...PlayerInfo) *MatchLabel {
+		return &MatchLabel{
+			ID:        matchID,
+			Mode:      evr.ModeArenaPublic,
+			StartTime: start,
+			Players:   players,
+			GameState: &GameState{},
+		}
+	}
+	bluePlayer := PlayerInfo{UserID: blue.UserID.String(), EvrID: blue.EvrID, DisplayName: blue.DisplayName, Team: BlueTeam}
+	orangePlayer := PlayerInfo{UserID: orange.UserID.String(), EvrID: orange.EvrID, DisplayName: orange.DisplayName, Team: OrangeTeam}
+
+	goal := &evr.RemoteLogGoal{
+		GenericRemoteLog:      evr.GenericRemoteLog{Type: "GOAL"},
+		GameInfoGameTime:      42,
+		GoalType:              "INSIDE SHOT",
+		PlayerInfoDisplayName: blue.DisplayName,
+		PlayerInfoTeamID:      int64(BlueTeam),
+		PlayerInfoEvrID:       blue.EvrID,
+	}
+
+	journal := NewMatchDataJournal(matchID)
+	journal.Events = []*MatchDataJournalEntry{
+		testJournalEntry(t, start, true, MatchDataStarted{State: label()}),
+		testJournalEntry(t, start.Add(time.Second), true, MatchDataPlayerJoin{State: label(bluePlayer), Presence: blue}),
+		// Entries journaled without a type are identified by their fields.
+		testJournalEntry(t, start.Add(2*time.Second), false, MatchDataPlayerJoin{State: label(bluePlayer, orangePlayer), Presence: orange}),
+		// Each player sends their own copy of the goal.
+		testJournalEntry(t, start.Add(50*time.Second), true, MatchDataRemoteLogSet{UserID: blue.UserID.String(), Logs: []evr.RemoteLog{goal}}),
+		testJournalEntry(t, start.Add(51*time.Second), true, MatchDataRemoteLogSet{UserID: orange.UserID.String(), Logs: []evr.RemoteLog{goal}}),
+		// The label of a later entry shows the orange player on the blue team.
+		testJournalEntry(t, start.Add(60*time.Second), true, MatchDataPlayerJoin{State: label(bluePlayer, PlayerInfo{UserID: orange.UserID.String(), DisplayName: orange.DisplayName, Team: BlueTeam}), Presence: blue}),
+		testJournalEntry(t, start.Add(90*time.Second), true, MatchDataPlayerLeave{State: label(bluePlayer), Presence: orange, Reason: "leave"}),
+	}
+
+	timeline := NewMatchTimeline(matchID.String(), []*MatchDataJournal{journal})
+
+	if timeline.Mode != evr.ModeArenaPublic {
+		t.Errorf("unexpected mode: %s", timeline.Mode)
+	}
+	if timeline.BlueScore != 2 || timeline.OrangeScore != 0 {
+		t.Errorf("unexpected score: %d-%d", timeline.BlueScore, timeline.OrangeScore)
+	}
+
+	want := []string{
+		MatchTimelineEventStarted,
+		MatchTimelineEventJoin,
+		MatchTimelineEventJoin,
+		MatchTimelineEventGoal,
+		MatchTimelineEventJoin,
+		MatchTimelineEventTeamSwitch,
+		MatchTimelineEventEarlyQuit,
+	}
+	if len(timeline.Events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %s", len(want), len(timeline.Events), timeline.String())
+	}
+	for i, e := range timeline.Events {
+		if e.Type != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], e.Type)
+		}
+	}
+
+	if e := timeline.Events[3]; e.RoundClock != 42 || e.UserID != blue.UserID.String() {
+		t.Errorf("unexpected goal event: %+v", e)
+	}
+	if e := timeline.Events[5]; e.UserID != orange.UserID.String() || *e.FromTeam != OrangeTeam || *e.Team != BlueTeam {
+		t.Errorf("unexpected team switch event: %+v", e)
+	}
+
+	if p := timeline.Player(orange.UserID.String()); p == nil || !p.EarlyQuit {
+		t.Error("expected the orange player to have quit early")
+	}
+	if p := timeline.Player(blue.UserID.String()); p == nil || p.Goals != 1 || p.Points != 2 {
+		t.Errorf("unexpected blue player: %+v", p)
+	}
+}
+
+func TestPlayerMatchHistoryAdd(t *testing.T) {
+	now := time.Now()
+	h := &PlayerMatchHistory{}
+
+	for i := 0; i < MatchHistoryMaxEntries+5; i++ {
+		h.Add(PlayerMatchHistoryEntry{MatchID: uuid.Must(uuid.NewV4()).String(), StartTime: now.Add(time.Duration(i) * time.Minute)})
+	}
+	if len(h.Matches) != MatchHistoryMaxEntries {
+		t.Fatalf("expected %d matches, got %d", MatchHistoryMaxEntries, len(h.Matches))
+	}
+	if !h.Matches[0].StartTime.After(h.Matches[1].StartTime) {
+		t.Error("expected the newest match first")
+	}
+
+	// Adding a match again replaces it.
+	entry := h.Matches[3]
+	entry.EarlyQuit = true
+	h.Add(entry)
+	if len(h.Matches) != MatchHistoryMaxEntries || !h.Matches[3].EarlyQuit {
+		t.Error("expected the match to be replaced")
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"database/sql"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -55,9 +56,19 @@ func NewSkillBasedMatchmaker() *SkillBasedMatchmaker {
 
 // Function to be used as a matchmaker function in Nakama (RegisterMatchmakerOverride)
 func (m *SkillBasedMatchmaker) EvrMatchmakerFn(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, candidates [][]runtime.MatchmakerEntry) [][]runtime.MatchmakerEntry {
+	var settings GlobalMatchmakingSettings
+	if s := ServiceSettings(); s != nil {
+		settings = s.Matchmaking
+	}
+	matches, _ := m.matchmake(ctx, logger, nk, settings, candidates)
+	return matches
+}
+
+// matchmake makes the matches from the candidates with the settings, returning the matches and the number of candidates removed by each filter.
+func (m *SkillBasedMatchmaker) matchmake(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, settings GlobalMatchmakingSettings, candidates [][]runtime.MatchmakerEntry) ([][]runtime.MatchmakerEntry, map[string]int) {
 	if len(candidates) == 0 || len(candidates[0]) == 0 {
 		logger.Error("No candidates found. Matchmaker cannot run.")
-		return nil
+		return nil, nil
 	}
 
 	startTime := time.Now()
@@ -73,13 +84,13 @@ func (m *SkillBasedMatchmaker) EvrMatchmakerFn(ctx context.Context, logger runti
 	groupID, ok := candidates[0][0].GetProperties()["group_id"].(string)
 	if !ok || groupID == "" {
 		logger.Error("Group ID not found in entry properties.")
-		return nil
+		return nil, nil
 	}
 
 	modestr, ok := candidates[0][0].GetProperties()["game_mode"].(string)
 	if !ok || modestr == "" {
 		logger.Error("Mode not found in entry properties. Matchmaker cannot run.")
-		return nil
+		return nil, nil
 	}
 
 	var (
@@ -88,7 +99,7 @@ func (m *SkillBasedMatchmaker) EvrMatchmakerFn(ctx context.Context, logger runti
 		originalCount = len(candidates)
 	)
 
-	candidates, matches, filterCounts = m.processPotentialMatches(candidates)
+	candidates, matches, filterCounts = m.processPotentialMatches(settings, candidates)
 
 	// Extract all players from the candidates
 	playerSet := make(map[string]struct{}, 0)
@@ -116,11 +127,14 @@ func (m *SkillBasedMatchmaker) EvrMatchmakerFn(ctx context.Context, logger runti
 		return p, !ok
 	})
 
-	nk.MetricsCounterAdd("matchmaker_candidate_count", nil, int64(len(candidates)))
-	nk.MetricsCounterAdd("matchmaker_match_count", nil, int64(len(matches)))
-	nk.MetricsCounterAdd("matchmaker_ticket_count", nil, int64(len(ticketSet)))
-	nk.MetricsCounterAdd("matchmaker_unmatched_player_count", nil, int64(len(unmatchedPlayers)))
-	nk.MetricsCounterAdd("matchmaker_matched_player_count", nil, int64(len(matchedPlayers)))
+	// The simulator runs the matchmaker without a nakama module.
+	if nk != nil {
+		nk.MetricsCounterAdd("matchmaker_candidate_count", nil, int64(len(candidates)))
+		nk.MetricsCounterAdd("matchmaker_match_count", nil, int64(len(matches)))
+		nk.MetricsCounterAdd("matchmaker_ticket_count", nil, int64(len(ticketSet)))
+		nk.MetricsCounterAdd("matchmaker_unmatched_player_count", nil, int64(len(unmatchedPlayers)))
+		nk.MetricsCounterAdd("matchmaker_matched_player_count", nil, int64(len(matchedPlayers)))
+	}
 
 	logger.WithFields(map[string]interface{}{
 		"mode":                 modestr,
@@ -139,10 +153,10 @@ func (m *SkillBasedMatchmaker) EvrMatchmakerFn(ctx context.Context, logger runti
 		m.StoreLatestResult(candidates, matches)
 	}
 
-	return matches
+	return matches, filterCounts
 }
 
-func (m *SkillBasedMatchmaker) processPotentialMatches(candidates [][]runtime.MatchmakerEntry) ([][]runtime.MatchmakerEntry, [][]runtime.MatchmakerEntry, map[string]int) {
+func (m *SkillBasedMatchmaker) processPotentialMatches(settings GlobalMatchmakingSettings, candidates [][]runtime.MatchmakerEntry) ([][]runtime.MatchmakerEntry, [][]runtime.MatchmakerEntry, map[string]int) {
 
 	// Write the candidates to a json filed called /tmp/candidates.json
 	filterCounts := make(map[string]int)
@@ -150,6 +164,17 @@ func (m *SkillBasedMatchmaker) processPotentialMatches(candidates [][]runtime.Ma
 	// Filter out players who are too far away from each other
 	filterCounts["max_rtt"] = m.filterWithinMaxRTT(candidates)
 
+	// Filter out players who are too far apart in skill. The ticket queries already apply the ranges that were set when they were created,
+	// and this is stricter than they are (it does not widen the range at the edges, nor exempt fallback tickets), so it is opt-in.
+	if settings.EnableRangeFilter {
+		if settings.EnableRankPercentileRange {
+			filterCounts["rank_percentile"] = m.filterWithinRange(candidates, "rank_percentile", settings.RankPercentile.MaxDelta)
+		}
+		if settings.EnableOrdinalRange {
+			filterCounts["rating_ordinal"] = m.filterWithinRange(candidates, "rating_ordinal", settings.OrdinalRange)
+		}
+	}
+
 	// predict the outcome of the matches
 	predictions := make([]PredictedMatch, 0, len(candidates))
 	for c := range predictCandidateOutcomes(candidates) {
@@ -221,6 +246,34 @@ OuterLoop:
 	return filteredCount
 }
 
+// Ensure that the property of everyone in the match is within the maximum delta of each other
+func (m *SkillBasedMatchmaker) filterWithinRange(candidates [][]runtime.MatchmakerEntry, key string, maxDelta float64) int {
+	if maxDelta <= 0 {
+		return 0
+	}
+
+	var filteredCount int
+	for i, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+
+		lowest, highest := math.Inf(1), math.Inf(-1)
+		for _, entry := range candidate {
+			if v, ok := entry.GetProperties()[key].(float64); ok && v != 0 {
+				lowest, highest = min(lowest, v), max(highest, v)
+			}
+		}
+
+		if !math.IsInf(lowest, 0) && highest-lowest > maxDelta {
+			candidates[i] = nil
+			filteredCount++
+		}
+	}
+
+	return filteredCount
+}
+
 func (m *SkillBasedMatchmaker) assembleUniqueMatches(sortedCandidates []PredictedMatch) [][]runtime.MatchmakerEntry {
 
 	matches := make([][]runtime.MatchmakerEntry, 0, len(sortedCandidates))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -32,6 +33,11 @@ func CalculateSmoothedPlayerRankPercentile(ctx context.Context, logger *zap.Logg
 		return 0.0, fmt.Errorf("failed to get active percentile: %w", err)
 	}
 
+	// Apply the seasonal soft reset, so the recalculation does not undo it.
+	seasonStart, now := ratingSeasonStart.Load(), time.Now().UTC()
+	dampingPercentile = SeasonAdjustedRankPercentile(dampingPercentile, settings.ResetScheduleDamper, ServiceSettings().Matchmaking, seasonStart, now)
+	activePercentile = SeasonAdjustedRankPercentile(activePercentile, settings.ResetSchedule, ServiceSettings().Matchmaking, seasonStart, now)
+
 	percentile := activePercentile + (dampingPercentile-activePercentile)*settings.DampeningFactor
 
 	return percentile, nil
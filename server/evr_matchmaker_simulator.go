@@ -0,0 +1,336 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/intinig/go-openskill/rating"
+	"github.com/intinig/go-openskill/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MatchmakerCandidateSnapshot is a recording of the matchmaker candidates, as returned by the matchmaker/candidates RPC.
+type MatchmakerCandidateSnapshot struct {
+	Candidates [][]*MatchmakerEntry `json:"candidates"`
+	Matches    [][]*MatchmakerEntry `json:"matches"`
+}
+
+func LoadMatchmakerCandidateSnapshot(path string) (*MatchmakerCandidateSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &MatchmakerCandidateSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal candidate snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+func (s *MatchmakerCandidateSnapshot) RuntimeCandidates() [][]runtime.MatchmakerEntry {
+	candidates := make([][]runtime.MatchmakerEntry, 0, len(s.Candidates))
+	for _, c := range s.Candidates {
+		entries := make([]runtime.MatchmakerEntry, 0, len(c))
+		for _, e := range c {
+			entries = append(entries, e)
+		}
+		candidates = append(candidates, entries)
+	}
+	return candidates
+}
+
+type MatchmakerSimulationDistribution struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+func newMatchmakerSimulationDistribution(values []float64) MatchmakerSimulationDistribution {
+	d := MatchmakerSimulationDistribution{Count: len(values)}
+	if len(values) == 0 {
+		return d
+	}
+	values = slices.Clone(values)
+	slices.Sort(values)
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	percentile := func(p float64) float64 {
+		return values[int(math.Ceil(p*float64(len(values))))-1]
+	}
+
+	d.Mean = sum / float64(len(values))
+	d.P50 = percentile(0.50)
+	d.P90 = percentile(0.90)
+	d.P99 = percentile(0.99)
+	d.Max = values[len(values)-1]
+	return d
+}
+
+func (d MatchmakerSimulationDistribution) String() string {
+	return fmt.Sprintf("n=%d mean=%.2f p50=%.2f p90=%.2f p99=%.2f max=%.2f", d.Count, d.Mean, d.P50, d.P90, d.P99, d.Max)
+}
+
+type MatchmakerSimulationReport struct {
+	Snapshot             string                           `json:"snapshot,omitempty"`
+	CandidateCount       int                              `json:"candidate_count"`
+	FilteredCount        int                              `json:"filtered_count"` // Candidates removed by the matchmaker's filters
+	FilterCounts         map[string]int                   `json:"filter_counts"`  // Candidates removed by each filter
+	MatchCount           int                              `json:"match_count"`
+	PlayerCount          int                              `json:"player_count"`
+	MatchedPlayerCount   int                              `json:"matched_player_count"`
+	StrengthDelta        MatchmakerSimulationDistribution `json:"strength_delta"`   // The difference between the teams' summed mu
+	DrawProbability      MatchmakerSimulationDistribution `json:"draw_probability"` // The predicted draw probability of each match
+	RTT                  MatchmakerSimulationDistribution `json:"rtt_ms"`           // Each matched player's RTT to the best common server
+	WaitTime             MatchmakerSimulationDistribution `json:"wait_secs"`        // The time matched players had been waiting
+	UnmatchedWaitTime    MatchmakerSimulationDistribution `json:"unmatched_wait_secs"`
+	DivisionCount        MatchmakerSimulationDistribution `json:"division_count"` // The number of divisions in each match
+	MixedDivisionMatches int                              `json:"mixed_division_matches"`
+}
+
+func (r *MatchmakerSimulationReport) String() string {
+	var b strings.Builder
+	if r.Snapshot != "" {
+		fmt.Fprintf(&b, "Snapshot:         %s\n", r.Snapshot)
+	}
+	fmt.Fprintf(&b, "Candidates:       %d (%d filtered %v)\n", r.CandidateCount, r.FilteredCount, r.FilterCounts)
+	fmt.Fprintf(&b, "Matches:          %d\n", r.MatchCount)
+	fmt.Fprintf(&b, "Players matched:  %d/%d\n", r.MatchedPlayerCount, r.PlayerCount)
+	fmt.Fprintf(&b, "Strength delta:   %s\n", r.StrengthDelta)
+	fmt.Fprintf(&b, "Draw probability: %s\n", r.DrawProbability)
+	fmt.Fprintf(&b, "RTT (ms):         %s\n", r.RTT)
+	fmt.Fprintf(&b, "Wait (s):         %s\n", r.WaitTime)
+	fmt.Fprintf(&b, "Unmatched wait:   %s\n", r.UnmatchedWaitTime)
+	fmt.Fprintf(&b, "Divisions:        %s (%d mixed)\n", r.DivisionCount, r.MixedDivisionMatches)
+	return b.String()
+}
+
+// SimulateMatchmaker runs the matchmaker over the candidates as if the settings were in effect, and reports on the quality of the matches made.
+// The candidates have already passed the queries of the settings that were live when they were recorded, so the settings can only narrow them.
+func SimulateMatchmaker(ctx context.Context, logger runtime.Logger, settings GlobalMatchmakingSettings, candidates [][]runtime.MatchmakerEntry) *MatchmakerSimulationReport {
+	report := &MatchmakerSimulationReport{
+		CandidateCount: len(candidates),
+	}
+
+	applyMatchmakerSimulationTicketSettings(settings, candidates)
+
+	// Collect the players and the time they started matchmaking.
+	createTimes := make(map[string]int64)
+	var latest int64
+	for _, c := range candidates {
+		for _, e := range c {
+			createTime := matchmakerEntryCreateTime(e)
+			createTimes[e.GetPresence().GetSessionId()] = createTime
+			latest = max(latest, createTime)
+		}
+	}
+	report.PlayerCount = len(createTimes)
+
+	var matches [][]runtime.MatchmakerEntry
+	if len(candidates) > 0 {
+		matches, report.FilterCounts = NewSkillBasedMatchmaker().matchmake(ctx, logger, nil, settings, slices.Clone(candidates))
+	}
+	for _, n := range report.FilterCounts {
+		report.FilteredCount += n
+	}
+	report.MatchCount = len(matches)
+
+	var (
+		strengthDeltas = make([]float64, 0, len(matches))
+		draws          = make([]float64, 0, len(matches))
+		divisionCounts = make([]float64, 0, len(matches))
+		rtts           = make([]float64, 0)
+		waits          = make([]float64, 0)
+		unmatchedWaits = make([]float64, 0)
+	)
+
+	for _, m := range matches {
+		// The matchmaker orders the first team before the second.
+		teamA, teamB := CandidateList(m[:len(m)/2]), CandidateList(m[len(m)/2:])
+		strengthDeltas = append(strengthDeltas, math.Abs(teamA.Strength()-teamB.Strength()))
+		draws = append(draws, rating.PredictDraw([]types.Team{teamA.Ratings(), teamB.Ratings()}, nil))
+
+		divisions := matchmakerSimulationDivisions(m)
+		divisionCounts = append(divisionCounts, float64(divisions))
+		if divisions > 1 {
+			report.MixedDivisionMatches++
+		}
+
+		rtts = append(rtts, matchmakerSimulationRTTs(m)...)
+
+		for _, e := range m {
+			sessionID := e.GetPresence().GetSessionId()
+			if createTime, ok := createTimes[sessionID]; ok {
+				waits = append(waits, float64(latest-createTime)/1e9)
+				delete(createTimes, sessionID)
+			}
+			report.MatchedPlayerCount++
+		}
+	}
+
+	for _, createTime := range createTimes {
+		unmatchedWaits = append(unmatchedWaits, float64(latest-createTime)/1e9)
+	}
+
+	report.StrengthDelta = newMatchmakerSimulationDistribution(strengthDeltas)
+	report.DrawProbability = newMatchmakerSimulationDistribution(draws)
+	report.DivisionCount = newMatchmakerSimulationDistribution(divisionCounts)
+	report.RTT = newMatchmakerSimulationDistribution(rtts)
+	report.WaitTime = newMatchmakerSimulationDistribution(waits)
+	report.UnmatchedWaitTime = newMatchmakerSimulationDistribution(unmatchedWaits)
+
+	return report
+}
+
+// applyMatchmakerSimulationTicketSettings sets the ticket properties that are derived from the settings when a ticket is created.
+func applyMatchmakerSimulationTicketSettings(settings GlobalMatchmakingSettings, candidates [][]runtime.MatchmakerEntry) {
+	if settings.MaxServerRTT <= 0 {
+		return
+	}
+	for _, c := range candidates {
+		for _, e := range c {
+			e.GetProperties()["max_rtt"] = float64(settings.MaxServerRTT)
+		}
+	}
+}
+
+// matchmakerEntryCreateTime returns the time the entry's ticket was created, in unix nanoseconds.
+func matchmakerEntryCreateTime(e runtime.MatchmakerEntry) int64 {
+	if entry, ok := e.(*MatchmakerEntry); ok && entry.CreateTime > 0 {
+		return entry.CreateTime
+	}
+	if ts, ok := e.GetProperties()["timestamp"].(float64); ok {
+		return int64(ts) * 1e9
+	}
+	return 0
+}
+
+func matchmakerSimulationDivisions(match []runtime.MatchmakerEntry) int {
+	divisions := make(map[string]struct{})
+	for _, e := range match {
+		s, _ := e.GetProperties()["divisions"].(string)
+		for _, d := range strings.Split(s, ",") {
+			if d != "" {
+				divisions[d] = struct{}{}
+			}
+		}
+	}
+	return len(divisions)
+}
+
+// matchmakerSimulationRTTs returns each player's RTT to the common server with the lowest worst-case RTT.
+func matchmakerSimulationRTTs(match []runtime.MatchmakerEntry) []float64 {
+	var (
+		best      []float64
+		bestWorst = math.Inf(1)
+	)
+
+	for k := range match[0].GetProperties() {
+		if !strings.HasPrefix(k, RTTPropertyPrefix) {
+			continue
+		}
+		rtts := make([]float64, 0, len(match))
+		worst := 0.0
+		for _, e := range match {
+			rtt, ok := e.GetProperties()[k].(float64)
+			if !ok {
+				break
+			}
+			rtts = append(rtts, rtt)
+			worst = max(worst, rtt)
+		}
+		if len(rtts) == len(match) && worst < bestWorst {
+			best, bestWorst = rtts, worst
+		}
+	}
+	return best
+}
+
+// MatchmakerSimulatorCmd runs the matchmaker over recorded candidate snapshots with alternative settings.
+func MatchmakerSimulatorCmd(ctx context.Context, logger *zap.Logger, args []string) error {
+	var (
+		settingsPath string
+		jsonOutput   bool
+		verbose      bool
+	)
+
+	flags := flag.NewFlagSet("evr-mm-sim", flag.ContinueOnError)
+	flags.StringVar(&settingsPath, "settings", "", "Path to a JSON file of the matchmaking settings (the \"matchmaking\" object of the global settings).")
+	flags.BoolVar(&jsonOutput, "json", false, "Output the reports as JSON.")
+	flags.BoolVar(&verbose, "verbose", false, "Log the matchmaker output.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: nakama evr-mm-sim [flags] <snapshot.json>...\n\n")
+		fmt.Fprintf(flags.Output(), "Snapshots are recorded from the matchmaker/candidates RPC.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no snapshots provided")
+	}
+
+	// Start from the defaults, so the settings file only needs the values being tuned.
+	data := &ServiceSettingsData{}
+	FixDefaultServiceSettings(data)
+	settings := data.Matchmaking
+
+	if settingsPath != "" {
+		b, err := os.ReadFile(settingsPath)
+		if err != nil {
+			return fmt.Errorf("failed to read settings: %w", err)
+		}
+		if err := json.Unmarshal(b, &settings); err != nil {
+			return fmt.Errorf("failed to unmarshal settings: %w", err)
+		}
+	}
+
+	if !verbose {
+		logger = logger.WithOptions(zap.IncreaseLevel(zapcore.WarnLevel))
+	}
+	runtimeLogger := NewRuntimeGoLogger(logger)
+
+	reports := make([]*MatchmakerSimulationReport, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		snapshot, err := LoadMatchmakerCandidateSnapshot(path)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+
+		report := SimulateMatchmaker(ctx, runtimeLogger, settings, snapshot.RuntimeCandidates())
+		report.Snapshot = path
+		reports = append(reports, report)
+	}
+
+	return writeMatchmakerSimulationReports(os.Stdout, reports, jsonOutput)
+}
+
+func writeMatchmakerSimulationReports(w io.Writer, reports []*MatchmakerSimulationReport, jsonOutput bool) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+	for _, r := range reports {
+		if _, err := fmt.Fprintln(w, r.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
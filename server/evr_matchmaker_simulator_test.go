@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+func TestSimulateMatchmaker(t *testing.T) {
+	entries := generateMatchmakerEntries(10)
+	now := time.Now().UTC()
+	for i, e := range entries {
+		e.CreateTime = now.Add(-time.Duration(i) * time.Minute).UnixNano()
+		e.Properties["divisions"] = "green"
+		e.Properties["max_rtt"] = float64(300)
+		e.Properties["rating_ordinal"] = e.Properties["rating_mu"].(float64) - 3*e.Properties["rating_sigma"].(float64)
+	}
+	entries[0].Properties["divisions"] = "green,bronze"
+
+	// Record a snapshot in the format of the matchmaker/candidates RPC.
+	snapshot := MatchmakerCandidateSnapshot{Candidates: allCombinations(entries, 8)}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "candidates.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func() [][]runtime.MatchmakerEntry {
+		s, err := LoadMatchmakerCandidateSnapshot(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.RuntimeCandidates()
+	}
+
+	logger := NewRuntimeGoLogger(loggerForTest(t))
+	settings := GlobalMatchmakingSettings{}
+
+	report := SimulateMatchmaker(context.Background(), logger, settings, load())
+	if report.CandidateCount != 45 || report.FilteredCount != 0 {
+		t.Fatalf("unexpected candidate counts: %+v", report)
+	}
+	if report.MatchCount != 1 || report.MatchedPlayerCount != 8 || report.PlayerCount != 10 {
+		t.Fatalf("unexpected match counts: %+v", report)
+	}
+	if report.RTT.Count != 8 || report.RTT.Max != 30 {
+		t.Errorf("expected every player to use the closest common server: %s", report.RTT)
+	}
+	if report.WaitTime.Count != 8 || report.UnmatchedWaitTime.Count != 2 {
+		t.Errorf("unexpected wait times: %s, %s", report.WaitTime, report.UnmatchedWaitTime)
+	}
+	if report.DrawProbability.Count != 1 || report.DrawProbability.Max <= 0 {
+		t.Errorf("unexpected draw probability: %s", report.DrawProbability)
+	}
+
+	// A lower maximum RTT excludes every server.
+	settings.MaxServerRTT = 20
+	if report := SimulateMatchmaker(context.Background(), logger, settings, load()); report.MatchCount != 0 {
+		t.Errorf("expected no matches within the maximum RTT, got %d", report.MatchCount)
+	}
+
+	// An ordinal range of zero width filters every candidate.
+	settings = GlobalMatchmakingSettings{EnableRangeFilter: true, EnableOrdinalRange: true, OrdinalRange: 0.0001}
+	if report := SimulateMatchmaker(context.Background(), logger, settings, load()); report.FilteredCount != 45 || report.MatchCount != 0 {
+		t.Errorf("expected every candidate to be filtered: %+v", report)
+	}
+}
+
+func TestSimulateMatchmakerSettingsChangeMatches(t *testing.T) {
+	entries := generateMatchmakerEntries(10)
+	for i, e := range entries {
+		e.Properties["divisions"] = "green"
+		e.Properties["rank_percentile"] = 0.1 + float64(i%2)*0.05
+	}
+	// The matchmaker otherwise prefers the first candidate, which includes the two top ranked players.
+	entries[0].Properties["rank_percentile"] = 0.9
+	entries[1].Properties["rank_percentile"] = 0.9
+
+	candidates := func() [][]runtime.MatchmakerEntry {
+		snapshot := MatchmakerCandidateSnapshot{Candidates: allCombinations(entries, 8)}
+		return snapshot.RuntimeCandidates()
+	}
+
+	matched := func(matches [][]runtime.MatchmakerEntry, e *MatchmakerEntry) bool {
+		for _, m := range matches {
+			for _, p := range m {
+				if p.GetPresence().GetSessionId() == e.Presence.GetSessionId() {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	logger := NewRuntimeGoLogger(loggerForTest(t))
+	sbmm := NewSkillBasedMatchmaker()
+
+	matches, _ := sbmm.matchmake(context.Background(), logger, nil, GlobalMatchmakingSettings{}, candidates())
+	if len(matches) != 1 || !matched(matches, entries[0]) || matched(matches, entries[9]) {
+		t.Fatalf("expected the top ranked players to be matched, got %d matches", len(matches))
+	}
+
+	// Limiting the rank percentile range excludes the top ranked players, so the remaining players are matched instead.
+	settings := GlobalMatchmakingSettings{
+		EnableRangeFilter:         true,
+		EnableRankPercentileRange: true,
+		RankPercentile:            RankPercentileSettings{MaxDelta: 0.2},
+	}
+	matches, filterCounts := sbmm.matchmake(context.Background(), logger, nil, settings, candidates())
+	if len(matches) != 1 || matched(matches, entries[0]) || !matched(matches, entries[9]) {
+		t.Fatalf("expected the rank percentile range to change the match, got %d matches", len(matches))
+	}
+	if filterCounts["rank_percentile"] == 0 {
+		t.Errorf("expected candidates to be filtered by rank percentile: %v", filterCounts)
+	}
+}
@@ -126,7 +126,7 @@ func testEvrMatchmakerOverrideFn(ctx context.Context, candidateMatches [][]*Matc
 
 	globalSettings := &ServiceSettingsData{}
 	FixDefaultServiceSettings(globalSettings)
-	filteredCandidates, returnedEntries, _ := sbmm.processPotentialMatches(runtimeCombinations)
+	filteredCandidates, returnedEntries, _ := sbmm.processPotentialMatches(GlobalMatchmakingSettings{}, runtimeCombinations)
 	log.Printf("Processing %d candidate matches in %s", len(runtimeCombinations), time.Since(startTime))
 	_ = filteredCandidates
 	combinations := make([][]*MatchmakerEntry, len(returnedEntries))
@@ -356,7 +356,7 @@ func TestOverrideFn(t *testing.T) {
 	startTime := time.Now()
 	globalSettings := &ServiceSettingsData{}
 	FixDefaultServiceSettings(globalSettings)
-	_, returnedEntries, _ := sbmm.processPotentialMatches(runtimeCombinations)
+	_, returnedEntries, _ := sbmm.processPotentialMatches(GlobalMatchmakingSettings{}, runtimeCombinations)
 	t.Logf("Matched %d candidate matches in %s", len(returnedEntries), time.Since(startTime))
 
 	t.Errorf("autofail")
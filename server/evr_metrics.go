@@ -112,13 +112,27 @@ func NewEVRMetrics(metrics Metrics) *EVRMetrics {
 }
 
 func (m *EVRMetrics) CountWebsocketOpened(delta int64) {
-	m.CountWebsocketOpened(1)
-	m.CustomCounter("session_evr_closed", nil, 1)
+	m.Metrics.CountWebsocketOpened(delta)
+	m.CustomCounter("session_evr_opened", nil, delta)
 }
 
 func (m *EVRMetrics) CountWebsocketClosed(delta int64) {
-	m.CountWebsocketClosed(1)
-	m.CustomCounter("session_evr_closed", nil, 1)
+	m.Metrics.CountWebsocketClosed(delta)
+	m.CustomCounter("session_evr_closed", nil, delta)
+}
+
+func (m *EVRMetrics) CountClientHealthEvent(key ClientHealthKey, event ClientHealthEvent) {
+	tags := key.AsMap()
+	tags["event"] = string(event)
+	m.CustomCounter("client_health_event_count", tags, 1)
+}
+
+// RecordClientLoadTime records a client's load times, in seconds.
+func (m *EVRMetrics) RecordClientLoadTime(key ClientHealthKey, loadTime, clientLoadTime, serverLoadTime float64) {
+	tags := key.AsMap()
+	m.CustomTimer("client_health_load_time", tags, secondsToDuration(loadTime))
+	m.CustomTimer("client_health_client_load_time", tags, secondsToDuration(clientLoadTime))
+	m.CustomTimer("client_health_server_load_time", tags, secondsToDuration(serverLoadTime))
 }
 
 func ListMatchStates(ctx context.Context, nk runtime.NakamaModule, query string) ([]*MatchLabelMeta, error) {
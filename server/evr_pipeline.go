@@ -59,6 +59,7 @@ type EvrPipeline struct {
 	userRemoteLogJournalRegistry *UserLogJouralRegistry
 	guildGroupRegistry           *GuildGroupRegistry
 	ipInfoCache                  *IPInfoCache
+	mmdbClient                   *MMDBClient
 
 	placeholderEmail string
 	linkDeviceURL    string
@@ -123,7 +124,7 @@ func NewEvrPipeline(logger *zap.Logger, startupLogger *zap.Logger, db *sql.DB, p
 
 	runtimeLogger := NewRuntimeGoLogger(logger)
 
-	statisticsQueue := NewStatisticsQueue(runtimeLogger, nk)
+	statisticsQueue := NewStatisticsQueue(runtimeLogger, db, nk)
 	profileRegistry := NewProfileRegistry(nk, db, runtimeLogger, metrics, sessionRegistry)
 	guildGroupRegistry := NewGuildGroupRegistry(ctx, runtimeLogger, nk, db)
 	lobbyBuilder := NewLobbyBuilder(logger, nk, sessionRegistry, matchRegistry, tracker, metrics)
@@ -159,7 +160,21 @@ func NewEvrPipeline(logger *zap.Logger, startupLogger *zap.Logger, db *sql.DB, p
 		logger.Fatal("Failed to create IPAPI client", zap.Error(err))
 	}
 
-	ipInfoCache, err := NewIPInfoCache(logger, metrics, ipapiClient, ipqsClient)
+	// The local MaxMind databases are used after the remote providers, as a fallback. They have no VPN or fraud data,
+	// so they are only used first if MMDB_PRIMARY is set.
+	ipInfoProviders := []IPInfoProvider{ipapiClient, ipqsClient}
+	var mmdbClient *MMDBClient
+	if path := vars["MMDB_CITY_PATH"]; path != "" {
+		if mmdbClient, err = NewMMDBClient(logger, metrics, path, vars["MMDB_ASN_PATH"]); err != nil {
+			logger.Error("Failed to create MaxMind client", zap.Error(err))
+		} else if vars["MMDB_PRIMARY"] == "true" {
+			ipInfoProviders = append([]IPInfoProvider{mmdbClient}, ipInfoProviders...)
+		} else {
+			ipInfoProviders = append(ipInfoProviders, mmdbClient)
+		}
+	}
+
+	ipInfoCache, err := NewIPInfoCache(logger, metrics, ipInfoProviders...)
 	if err != nil {
 		logger.Fatal("Failed to create IP info cache", zap.Error(err))
 	}
@@ -196,6 +211,31 @@ func NewEvrPipeline(logger *zap.Logger, startupLogger *zap.Logger, db *sql.DB, p
 		}
 	}
 
+	globalClientHealthRegistry.SetMetrics(NewEVRMetrics(metrics))
+	globalClientHealthRegistry.Start(ctx, runtimeLogger, nk, config.GetName())
+
+	// Notify the operator when their game server is quarantined, or recovers.
+	globalBroadcasterRegistry.SetNotifier(func(h BroadcasterHealth) {
+		message := fmt.Sprintf("Game server `%s` has recovered (health score %.2f), and will be allocated matches again.", h.Endpoint, h.Score)
+		if h.Quarantined {
+			message = fmt.Sprintf("Game server `%s` has been quarantined, and will not be allocated matches until it recovers (health score %.2f, loss %.0f%%, jitter %.0fms, events %v).", h.Endpoint, h.Score, h.Loss*100, h.JitterMs, h.Events)
+		}
+		logger.Warn("Game server health changed", zap.Any("health", h))
+
+		if appBot == nil {
+			return
+		}
+		if err := appBot.LogServiceAuditMessage(message); err != nil {
+			logger.Warn("Failed to log game server health", zap.Error(err))
+		}
+		if discordID, err := GetDiscordIDByUserID(ctx, db, h.OperatorID); err != nil {
+			logger.Warn("Failed to get operator discord ID", zap.String("operator_id", h.OperatorID), zap.Error(err))
+		} else if _, err := SendUserMessage(ctx, dg, discordID, message); err != nil {
+			logger.Warn("Failed to notify operator", zap.String("operator_id", h.OperatorID), zap.Error(err))
+		}
+	})
+	go globalBroadcasterRegistry.Start(ctx)
+
 	internalIP, externalIP, err := DetermineServiceIPs(ctx)
 	if err != nil {
 		logger.Fatal("Unable to determine service IPs", zap.Error(err))
@@ -224,6 +264,7 @@ func NewEvrPipeline(logger *zap.Logger, startupLogger *zap.Logger, db *sql.DB, p
 		userRemoteLogJournalRegistry: userRemoteLogJournalRegistry,
 		guildGroupRegistry:           guildGroupRegistry,
 		ipInfoCache:                  ipInfoCache,
+		mmdbClient:                   mmdbClient,
 
 		placeholderEmail: config.GetRuntime().Environment["PLACEHOLDER_EMAIL_DOMAIN"],
 		linkDeviceURL:    config.GetRuntime().Environment["LINK_DEVICE_URL"],
@@ -270,7 +311,12 @@ func DetermineServiceIPs(ctx context.Context) (intIP net.IP, extIP net.IP, err e
 	return intIP, extIP, nil
 }
 
-func (p *EvrPipeline) Stop() {}
+func (p *EvrPipeline) Stop() {
+	p.statisticsQueue.Stop()
+	if p.mmdbClient != nil {
+		p.mmdbClient.Stop()
+	}
+}
 
 func (p *EvrPipeline) MessageCacheStore(key string, message evr.Message, ttl time.Duration) {
 	p.messageCache.Store(key, message)
@@ -286,6 +332,12 @@ func (p *EvrPipeline) MessageCacheLoad(key string) evr.Message {
 	return nil
 }
 
+// unexpectedServerMessage handles server-to-client messages that were sent by a client.
+func (p *EvrPipeline) unexpectedServerMessage(ctx context.Context, logger *zap.Logger, session *sessionWS, in evr.Message) error {
+	logger.Warn("Received server-only message from client", zap.String("type", fmt.Sprintf("%T", in)), zap.Any("message", in))
+	return nil
+}
+
 func (p *EvrPipeline) ProcessRequestEVR(logger *zap.Logger, session Session, in evr.Message) bool {
 
 	// Handle legacy messages
@@ -392,6 +444,9 @@ func (p *EvrPipeline) ProcessRequestEVR(logger *zap.Logger, session Session, in
 		pipelineFn = p.userServerProfileUpdateRequest
 	case *evr.GenericMessage:
 		pipelineFn = p.genericMessage
+	case *evr.GenericMessageNotify, *evr.MatchEnded, *evr.SNSLobbySmiteEntrant:
+		// These are only ever sent by the server.
+		pipelineFn = p.unexpectedServerMessage
 
 	// Match service
 	case *evr.LobbyFindSessionRequest:
@@ -3,10 +3,10 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
-	"github.com/gofrs/uuid/v5"
-	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
 	"github.com/heroiclabs/nakama/v3/server/evr"
 	"go.uber.org/zap"
 )
@@ -14,47 +14,81 @@ import (
 func (p *EvrPipeline) configRequest(ctx context.Context, logger *zap.Logger, session *sessionWS, in evr.Message) error {
 	message := in.(*evr.ConfigRequest)
 
+	params, ok := LoadParams(ctx)
+	if !ok {
+		return errors.New("session parameters not found")
+	}
+
+	// Serve the most specific published resource for the client's guild, build and experiment bucket.
+	scope := ConfigResourceScope{
+		GroupID:     params.accountMetadata.GetActiveGroupID().String(),
+		BuildNumber: params.BuildNumber(),
+	}
+	if experiment, err := ConfigExperimentLoadCached(ctx, p.nk, message.Type); err != nil {
+		logger.Warn("Failed to load config experiment", zap.Error(err))
+	} else {
+		scope.Bucket = experiment.Bucket(session.UserID().String())
+	}
+
+	cacheKey := scope.key(message.Type, message.ID)
+
+	var resource map[string]interface{}
+	if v, _, found := configResourceCache.Get(cacheKey); found {
+		resource = v.(map[string]interface{})
+	} else {
+		var err error
+		if resource, err = ConfigResourceEffective(ctx, NewRuntimeGoLogger(logger), p.nk, message.Type, message.ID, scope); err != nil {
+			session.SendEvrUnrequire(evr.NewConfigFailure(message.Type, message.ID))
+			return err
+		}
+		configResourceCache.Set(cacheKey, resource, configResourceCacheTTL)
+	}
+
+	// Send the resource to the client.
+	if err := session.SendEvrUnrequire(evr.NewConfigSuccess(message.Type, message.ID, resource)); err != nil {
+		return fmt.Errorf("failed to send SNSConfigSuccess: %w", err)
+	}
+	return nil
+}
+
+// ConfigResourceEffective returns the published resource for the scope, the stored global resource, or the default resource.
+func ConfigResourceEffective(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, configType, id string, scope ConfigResourceScope) (map[string]interface{}, error) {
+	if r, err := ConfigResourceResolve(ctx, nk, configType, id, scope); err != nil {
+		logger.WithField("error", err).Warn("Failed to resolve config resource")
+	} else if r != nil {
+		return r.Current().Resource, nil
+	}
+
 	// Retrieve the requested object.
-	objs, err := StorageReadObjects(ctx, logger, session.pipeline.db, uuid.Nil, []*api.ReadStorageObjectId{
+	objs, err := nk.StorageRead(ctx, []*runtime.StorageRead{
 		{
-			Collection: "Config:" + message.Type,
-			Key:        message.Type,
-			UserId:     uuid.Nil.String(),
+			Collection: "Config:" + configType,
+			Key:        configType,
+			UserID:     SystemUserID,
 		},
 	})
-
-	// Send an error if the object could not be retrieved.
 	if err != nil {
-		logger.Warn("failed to read objects", zap.Error(err))
-		session.SendEvrUnrequire(evr.NewConfigFailure(message.Type, message.ID))
-		return fmt.Errorf("failed to read objects: %w", err)
+		logger.WithField("error", err).Warn("failed to read objects")
+		return nil, fmt.Errorf("failed to read objects: %w", err)
 	}
 
 	var jsonResource string
-	if len(objs.Objects) != 0 {
+	if len(objs) != 0 {
 		// Use the retrieved object.
-		jsonResource = objs.Objects[0].Value
+		jsonResource = objs[0].GetValue()
 	} else {
 		// Attempt to pull a default config resource.
-		jsonResource = evr.GetDefaultConfigResource(message.Type, message.ID)
+		jsonResource = evr.GetDefaultConfigResource(configType, id)
 	}
 	if jsonResource == "" {
 		logger.Warn("resource not found")
-		session.SendEvrUnrequire(evr.NewConfigFailure(message.Type, message.ID))
-		return fmt.Errorf("resource not found: %s", message.ID)
+		return nil, fmt.Errorf("resource not found: %s", id)
 	}
 
 	// Parse the JSON resource
 	resource := make(map[string]interface{})
 	if err := json.Unmarshal([]byte(jsonResource), &resource); err != nil {
-
-		session.SendEvrUnrequire(evr.NewConfigFailure(message.Type, message.ID))
-		return fmt.Errorf("failed to parse %s json: %w", message.ID, err)
+		return nil, fmt.Errorf("failed to parse %s json: %w", id, err)
 	}
-
-	// Send the resource to the client.
-	if err := session.SendEvrUnrequire(evr.NewConfigSuccess(message.Type, message.ID, resource)); err != nil {
-		return fmt.Errorf("failed to send SNSConfigSuccess: %w", err)
-	}
-	return nil
+	return resource, nil
 }
@@ -203,7 +203,7 @@ func (p *EvrPipeline) gameserverRegistrationRequest(ctx context.Context, logger
 		logger.Warn("Failed to get IPQS data", zap.Error(err))
 	}
 
-	if slices.Contains(regionCodes, "default") {
+	if slices.Contains(regionCodes, "default") && ipInfo != nil {
 		regionCodes = append(regionCodes,
 			LocationToRegionCode(ipInfo.CountryCode(), ipInfo.Region(), ipInfo.City()),
 			LocationToRegionCode(ipInfo.CountryCode(), ipInfo.Region(), ""),
@@ -266,10 +266,17 @@ func (p *EvrPipeline) gameserverRegistrationRequest(ctx context.Context, logger
 	}
 
 	// Create a new parking match
-	if _, err = p.newParkingMatch(logger, session, config); err != nil {
+	if _, err = newParkingMatch(logger, p.nk, session, config); err != nil {
 		return errFailedRegistration(session, logger, err, evr.BroadcasterRegistration_Failure)
 	}
 
+	// Track the server's health until it disconnects.
+	globalBroadcasterRegistry.Register(config.Endpoint.ExternalAddress(), session.UserID(), session.ID())
+	go func() {
+		<-session.Context().Done()
+		globalBroadcasterRegistry.Disconnected(session.ID())
+	}()
+
 	if ServiceSettings().EnableContinuousGameserverHealthCheck {
 		go HealthCheckStart(session.Context(), logger, p.nk, session, p.internalIP, config.Endpoint.ExternalIP, int(config.Endpoint.Port), 500*time.Millisecond)
 	}
@@ -278,7 +285,12 @@ func (p *EvrPipeline) gameserverRegistrationRequest(ctx context.Context, logger
 	return session.SendEvrUnrequire(evr.NewBroadcasterRegistrationSuccess(config.ServerID, config.Endpoint.ExternalIP))
 }
 
-func (p *EvrPipeline) newParkingMatch(logger *zap.Logger, session *sessionWS, config *GameServerPresence) (*MatchID, error) {
+func newParkingMatch(logger *zap.Logger, nk *RuntimeGoNakamaModule, session *sessionWS, config *GameServerPresence) (*MatchID, error) {
+	// A draining server does not host another match.
+	if config.Draining {
+		return nil, ErrGameServerDraining
+	}
+
 	data, err := json.Marshal(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal game server config: %w", err)
@@ -289,18 +301,18 @@ func (p *EvrPipeline) newParkingMatch(logger *zap.Logger, session *sessionWS, co
 	}
 
 	// Create the match
-	matchIDStr, err := p.nk.matchRegistry.CreateMatch(context.Background(), p.runtime.matchCreateFunction, EvrMatchmakerModule, params)
+	matchIDStr, err := nk.MatchCreate(context.Background(), EvrMatchmakerModule, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create parking match: %w", err)
 	}
 
 	matchID := MatchIDFromStringOrNil(matchIDStr)
 
-	if err := UpdateGameServerBySessionID(p.nk, session.userID, session.id, matchID); err != nil {
+	if err := UpdateGameServerBySessionID(nk, session.userID, session.id, matchID); err != nil {
 		return nil, fmt.Errorf("failed to update game server by session ID: %w", err)
 	}
 
-	found, allowed, _, reason, _, _ := p.nk.matchRegistry.JoinAttempt(session.Context(), matchID.UUID, matchID.Node, session.UserID(), session.ID(), session.Username(), session.Expiry(), session.Vars(), session.ClientIP(), session.ClientPort(), p.node, nil)
+	found, allowed, _, reason, _, _ := nk.matchRegistry.JoinAttempt(session.Context(), matchID.UUID, matchID.Node, session.UserID(), session.ID(), session.Username(), session.Expiry(), session.Vars(), session.ClientIP(), session.ClientPort(), nk.node, nil)
 	if !found {
 		return nil, fmt.Errorf("match not found: %s", matchID.String())
 	}
@@ -314,7 +326,7 @@ func (p *EvrPipeline) newParkingMatch(logger *zap.Logger, session *sessionWS, co
 		Username: session.Username(),
 		Format:   session.Format(),
 	}
-	if success, _ := p.nk.tracker.Track(session.Context(), session.ID(), stream, session.UserID(), m); success {
+	if success, _ := nk.tracker.Track(session.Context(), session.ID(), stream, session.UserID(), m); success {
 		// Kick the user from any other matches they may be part of.
 		// WARNING This cannot be used during transition. It will kick the player from their current match.
 		//p.tracker.UntrackLocalByModes(session.ID(), matchStreamModes, stream)
@@ -426,7 +438,10 @@ func HealthCheckStart(ctx context.Context, logger *zap.Logger, nk runtime.Nakama
 		// Read the response from the broadcaster
 		if _, err := conn.Read(response); err != nil {
 			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				// Count the lost ping against the server's health, and keep monitoring it.
 				logger.Warn("ping request timed out", zap.String("remote_addr", remoteAddr.String()), zap.Int("timeout_ms", int(timeout.Milliseconds())))
+				globalBroadcasterRegistry.RecordPing(remoteAddr.String(), 0, true)
+				continue
 			}
 			logger.Error("could not read ping response from %v", zap.Error(err))
 			return
@@ -445,6 +460,7 @@ func HealthCheckStart(ctx context.Context, logger *zap.Logger, nk runtime.Nakama
 		}
 
 		nk.MetricsTimerRecord("gameserver_rtt_duration", tags, rtt)
+		globalBroadcasterRegistry.RecordPing(remoteAddr.String(), rtt, false)
 	}
 }
 
@@ -621,16 +637,18 @@ func isPrivateIP(ip net.IP) bool {
 	return false
 }
 
-func (p *EvrPipeline) gameserverLobbySessionStarted(_ context.Context, logger *zap.Logger, _ *sessionWS, in evr.Message) error {
+func (p *EvrPipeline) gameserverLobbySessionStarted(_ context.Context, logger *zap.Logger, session *sessionWS, in evr.Message) error {
 	request := in.(*evr.EchoToolsLobbySessionStartedV1)
 
 	logger.Info("Game session started", zap.String("mid", request.LobbySessionID.String()))
+	globalBroadcasterRegistry.SessionStarted(session.ID(), request.LobbySessionID)
 
 	return nil
 }
 
 func (p *EvrPipeline) gameserverLobbySessionEnded(ctx context.Context, logger *zap.Logger, session *sessionWS, in evr.Message) error {
 	request := in.(*evr.EchoToolsLobbySessionEndedV1)
+	globalBroadcasterRegistry.SessionEnded(session.ID())
 	if err := p.nk.StreamUserLeave(StreamModeMatchAuthoritative, request.LobbySessionID.String(), "", p.node, session.UserID().String(), session.ID().String()); err != nil {
 		logger.Warn("Failed to leave match stream", zap.Error(err))
 	}
@@ -658,7 +676,10 @@ func (p *EvrPipeline) gameserverLobbySessionEnded(ctx context.Context, logger *z
 			session.Close("Failed to get broadcaster presence", runtime.PresenceReasonUnknown)
 		}
 
-		if _, err := p.newParkingMatch(logger, session, config); err != nil {
+		if _, err := newParkingMatch(logger, p.nk, session, config); errors.Is(err, ErrGameServerDraining) {
+			logger.Info("Game server drained, disconnecting")
+			session.Close("Game server drained", runtime.PresenceReasonDisconnect)
+		} else if err != nil {
 			logger.Error("Failed to create new parking match", zap.Error(err))
 			session.Close("Failed to get broadcaster presence", runtime.PresenceReasonUnknown)
 		}
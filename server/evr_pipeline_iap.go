@@ -7,13 +7,24 @@ import (
 	"go.uber.org/zap"
 )
 
-// ReconcileIAP reconciles an in-app purchase. This is a stub implementation.
+// ReconcileIAP returns the echo points balance and owned store items recorded in the entitlement ledger.
 func (p *EvrPipeline) reconcileIAP(ctx context.Context, logger *zap.Logger, session *sessionWS, in evr.Message) error {
 	request := in.(*evr.ReconcileIAP)
 
-	if err := session.SendEvr(
-		evr.NewReconcileIAPResult(request.EvrId),
-	); err != nil {
+	result := evr.NewReconcileIAPResult(request.EvrId)
+
+	if entitlements, err := IAPEntitlementsLoad(ctx, p.nk, session.UserID().String()); err != nil {
+		// The client still expects a result, so it is sent without the entitlements.
+		logger.Warn("Failed to load IAP entitlements", zap.String("evr_id", request.EvrId.String()), zap.Error(err))
+	} else {
+		result.IAPData.Balance.Currency.EchoPoints.Value = entitlements.EchoPoints
+		result.IAPData.SKUs = entitlements.SKUs
+		if entitlements.TransactionID > 0 {
+			result.IAPData.TransactionId = entitlements.TransactionID
+		}
+	}
+
+	if err := session.SendEvr(result); err != nil {
 		return err
 	}
 	return nil
@@ -814,11 +814,25 @@ func (p *EvrPipeline) loggedInUserProfileRequest(ctx context.Context, logger *za
 	}
 
 	// Check if the user is required to go through community values
+	isCommunityValuesDue := false
 	if records, err := EnforcementCommunityValuesSearch(ctx, p.nk, groupID, userID); err != nil {
 		logger.Warn("Failed to search for community values", zap.Error(err))
 	} else if len(records) > 0 {
+		isCommunityValuesDue = true
+	}
+
+	// Require the community values again if the guild has updated them since they were accepted.
+	if required, err := CommunityValuesRequired(ctx, p.nk, params.accountMetadata.CommunityValuesAccepted, groupID); err != nil {
+		logger.Warn("Failed to check the community values version", zap.Error(err))
+	} else if required {
+		isCommunityValuesDue = true
+	}
+
+	// The client sets the version once the player has completed them, which is only an acceptance if they were due.
+	if isCommunityValuesDue {
 		clientProfile.Social.CommunityValuesVersion = 0
 	}
+	params.isCommunityValuesDue.Store(isCommunityValuesDue)
 
 	return session.SendEvr(evr.NewLoggedInUserProfileSuccess(request.EvrID, clientProfile, serverProfile))
 }
@@ -850,7 +864,8 @@ func (p *EvrPipeline) handleClientProfileUpdate(ctx context.Context, logger *zap
 		return fmt.Errorf("guild group not found: %s", groupID)
 	}
 
-	hasCompleted := update.Social.CommunityValuesVersion != 0
+	// Only the change from the required (zero) version that was sent to the client is an acceptance.
+	hasCompleted := update.Social.CommunityValuesVersion != 0 && params.isCommunityValuesDue.CompareAndSwap(true, false)
 
 	if hasCompleted {
 
@@ -861,7 +876,7 @@ func (p *EvrPipeline) handleClientProfileUpdate(ctx context.Context, logger *zap
 
 			if records, ok := records[groupID]; ok {
 				records.CommunityValuesCompletedAt = time.Now().UTC()
-				records.IsCommunityValuesRequired = false
+				records.UpdateSummary()
 
 				if _, err := StorageWrite(ctx, p.nk, userID, records); err != nil {
 					logger.Warn("Failed to write community values", zap.Error(err))
@@ -889,6 +904,20 @@ func (p *EvrPipeline) handleClientProfileUpdate(ctx context.Context, logger *zap
 		CombatAbility:      update.CombatAbility,
 	}
 
+	// The community values acceptance is tracked by the server for each guild, as the client only has one version.
+	if hasCompleted {
+		version, err := CommunityValuesVersion(ctx, p.nk, groupID)
+		if err != nil {
+			logger.Warn("Failed to load the community values version", zap.Error(err))
+		}
+		if version == 0 {
+			version = time.Now().UTC().Unix()
+		}
+		if metadata.CommunityValuesAccepted == nil {
+			metadata.CommunityValuesAccepted = make(map[string]int64)
+		}
+		metadata.CommunityValuesAccepted[groupID] = version
+	}
 	metadata.LegalConsents = update.LegalConsents
 	metadata.GhostedPlayers = update.GhostedPlayers.Players
 	metadata.MutedPlayers = update.MutedPlayers.Players
@@ -927,7 +956,7 @@ func (p *EvrPipeline) documentRequest(ctx context.Context, logger *zap.Logger, s
 	var document evr.Document
 	var err error
 	switch request.Type {
-	case "eula":
+	case DocumentTypeEULA:
 
 		if !params.IsVR() {
 
@@ -952,7 +981,32 @@ func (p *EvrPipeline) documentRequest(ctx context.Context, logger *zap.Logger, s
 		return session.SendEvrUnrequire(message)
 
 	default:
-		return fmt.Errorf("unknown document: %s,%s", request.Language, request.Type)
+		if !slices.Contains(DocumentTypes, request.Type) {
+			return session.SendEvrUnrequire(evr.NewDocumentFailureWithArgs(fmt.Sprintf("unknown document: %s,%s", request.Language, request.Type)))
+		}
+
+		// Guilds may publish their own community values.
+		groupID := ""
+		if request.Type == DocumentTypeCommunityValues {
+			groupID = params.accountMetadata.GetActiveGroupID().String()
+		}
+
+		key := fmt.Sprintf("document:%s:%s:%s", request.Type, request.Language, groupID)
+		message := p.MessageCacheLoad(key)
+
+		if message == nil {
+			registryDocument, err := DocumentLoad(ctx, p.nk, request.Type, request.Language, groupID)
+			if err != nil {
+				if status.Code(err) == codes.NotFound {
+					return session.SendEvrUnrequire(evr.NewDocumentFailureWithArgs(fmt.Sprintf("document not found: %s,%s", request.Language, request.Type)))
+				}
+				return fmt.Errorf("failed to get %s document: %w", request.Type, err)
+			}
+			message = evr.NewDocumentSuccess(registryDocument.EvrDocument())
+			p.MessageCacheStore(key, message, time.Minute*1)
+		}
+
+		return session.SendEvrUnrequire(message)
 	}
 }
 
@@ -1030,19 +1084,89 @@ func (p *EvrPipeline) genericMessage(ctx context.Context, logger *zap.Logger, se
 	request := in.(*evr.GenericMessage)
 	logger.Debug("Received generic message", zap.Any("message", request))
 
-	/*
+	senderUserID := session.UserID().String()
+
+	// Decline back to the sender, so that the client does not wait for the other player.
+	nack := func(reason error) error {
+		logger.Debug("Rejected generic message", zap.String("other_evr_id", request.OtherEvrID.String()), zap.Error(reason))
+		return session.SendEvr(evr.NewGenericMessageNotify(partyInviteNackMessageType, request.Session, request.RoomID, request.PartyData))
+	}
 
-		msg := evr.NewGenericMessageNotify(request.MessageType, request.Session, request.RoomID, request.PartyData)
+	targetUserID, err := GetUserIDByDeviceID(ctx, p.db, request.OtherEvrID.String())
+	if err != nil || targetUserID == "" {
+		return nack(ErrPartyInviteOffline)
+	}
 
-		if err := otherSession.SendEvr(msg); err != nil {
-			return fmt.Errorf("failed to send generic message: %w", err)
+	// Route the message to the other player's login session.
+	presences, err := p.nk.StreamUserList(StreamModeService, targetUserID, "", StreamLabelLoginService, false, true)
+	if err != nil {
+		return fmt.Errorf("failed to list login sessions: %w", err)
+	}
+	var otherSession *sessionWS
+	for _, presence := range presences {
+		if s, ok := p.nk.sessionRegistry.Get(uuid.FromStringOrNil(presence.GetSessionId())).(*sessionWS); ok && s != nil {
+			otherSession = s
+			break
 		}
+	}
+	if otherSession == nil {
+		return nack(ErrPartyInviteOffline)
+	}
+
+	// Only invites, and the replies that accept or decline them, are relayed.
+	var isNack bool
+	switch request.MessageType {
+	case partyInviteMessageType:
+	case partyInviteNackMessageType:
+		isNack = true
+	default:
+		return nack(ErrPartyInviteUnexpected)
+	}
 
-		if err := session.SendEvr(msg); err != nil {
-			return fmt.Errorf("failed to send generic message success: %w", err)
+	if !isNack {
+		params, ok := LoadParams(ctx)
+		if !ok {
+			return errors.New("session parameters not found")
+		}
+		if err := p.partyInviteAuthorize(ctx, senderUserID, targetUserID, params.accountMetadata.GetActiveGroupID().String()); err != nil {
+			if errors.Is(err, ErrPartyInviteBlocked) || errors.Is(err, ErrPartyInviteNotFriend) || errors.Is(err, ErrPartyInviteNotMember) {
+				return nack(err)
+			}
+			return fmt.Errorf("failed to authorize party invite: %w", err)
 		}
+	}
+
+	msg := evr.NewGenericMessageNotify(request.MessageType, request.Session, request.RoomID, request.PartyData)
+
+	if err := otherSession.SendEvr(msg); err != nil {
+		return fmt.Errorf("failed to send generic message: %w", err)
+	}
+
+	if err := session.SendEvr(msg); err != nil {
+		return fmt.Errorf("failed to send generic message success: %w", err)
+	}
+
+	// A member data reply to the other player's pending invite accepts it, and a nack declines it.
+	pending, err := partyInviteTake(ctx, p.nk, targetUserID, senderUserID)
+	if err != nil {
+		return fmt.Errorf("failed to load party invites: %w", err)
+	}
+
+	switch {
+	case isNack:
+		// The player declined the other player's invite.
+
+	case pending:
+		if err := p.partyInviteAccept(ctx, logger, targetUserID, senderUserID); err != nil {
+			logger.Warn("Failed to accept party invite", zap.Error(err))
+		}
+
+	default:
+		if err := partyInviteAdd(ctx, p.nk, senderUserID, targetUserID); err != nil {
+			return fmt.Errorf("failed to store party invite: %w", err)
+		}
+	}
 
-	*/
 	return nil
 }
 
@@ -1128,6 +1252,15 @@ func (p *EvrPipeline) processUserServerProfileUpdate(ctx context.Context, logger
 		return nil
 	}
 
+	// Completing the match forgives early quits.
+	if policy, _, ok := earlyQuitModePolicy(label.Mode); ok {
+		if err := EarlyQuitStatisticsUpdate(ctx, p.nk, playerInfo.UserID, func(s *EarlyQuitStatistics) {
+			s.RecordCompletedMatch(policy, label.Mode, time.Now().UTC())
+		}); err != nil {
+			logger.Warn("Failed to record completed match", zap.Error(err))
+		}
+	}
+
 	serviceSettings := ServiceSettings()
 
 	validModes := []evr.Symbol{evr.ModeArenaPublic, evr.ModeCombatPublic}
@@ -1135,10 +1268,16 @@ func (p *EvrPipeline) processUserServerProfileUpdate(ctx context.Context, logger
 	if serviceSettings.UseSkillBasedMatchmaking() && slices.Contains(validModes, label.Mode) {
 
 		// Determine winning team
-		blueWins := playerInfo.Team == BlueTeam && payload.IsWinner()
-		ratings := CalculateNewPlayerRatings(label.Players, blueWins)
+		blueWins := (playerInfo.Team == BlueTeam) == payload.IsWinner()
+
+		ratingSystem := RatingSystemByName(RatingSystemOpenSkill)
+		if gg := p.guildGroupRegistry.Get(groupIDStr); gg != nil {
+			ratingSystem = RatingSystemByName(gg.RatingSystemName(label.Mode))
+		}
+
+		ratings := ratingSystem.Rate(NewRatedMatchResult(label, blueWins, serviceSettings.Matchmaking.RatingWeighting))
 		if rating, ok := ratings[playerInfo.SessionID]; ok {
-			if err := MatchmakingRatingStore(ctx, p.nk, playerInfo.UserID, playerInfo.DiscordID, playerInfo.DisplayName, groupIDStr, label.Mode, rating); err != nil {
+			if err := MatchmakingRatingStore(ctx, p.nk, playerInfo.UserID, playerInfo.DiscordID, playerInfo.DisplayName, groupIDStr, label.Mode, ratingSystem.Name(), rating); err != nil {
 				logger.Warn("Failed to record percentile to leaderboard", zap.Error(err))
 			}
 		} else {
@@ -1159,7 +1298,7 @@ func (p *EvrPipeline) processUserServerProfileUpdate(ctx context.Context, logger
 		return nil
 	}
 
-	return p.updatePlayerStats(ctx, playerInfo.UserID, groupIDStr, playerInfo.DisplayName, payload.Update, label.Mode)
+	return p.updatePlayerStats(ctx, label.ID, playerInfo.UserID, groupIDStr, playerInfo.DisplayName, payload.Update, label.Mode)
 }
 
 func (p *EvrPipeline) otherUserProfileRequest(ctx context.Context, logger *zap.Logger, session *sessionWS, in evr.Message) error {
@@ -0,0 +1,499 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/internal/cronexpr"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"github.com/intinig/go-openskill/types"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageCollectionRatingDecay      = "RatingDecay"
+	StorageKeyRatingDecayState        = "state"
+	StorageCollectionRatingAdjustment = "RatingAdjustments"
+	StorageKeyRatingAdjustment        = "history"
+
+	RatingAdjustmentReasonDecay  = "decay"
+	RatingAdjustmentReasonSeason = "season"
+
+	ratingDecayCheckInterval = 5 * time.Minute
+)
+
+var (
+	_ = VersionedStorable(&RatingDecayState{})
+	_ = VersionedStorable(&RatingAdjustmentHistory{})
+
+	// ratingSeasonStart is the time of the last seasonal soft reset, refreshed from the state by each node's decay loop.
+	ratingSeasonStart = atomic.NewTime(time.Time{})
+)
+
+// RatingDecayState is the time of the last run of each job, stored on the system user so only one node runs each job.
+type RatingDecayState struct {
+	LastDecay       time.Time `json:"last_decay"`
+	LastSeasonReset time.Time `json:"last_season_reset"`
+	SeasonStart     time.Time `json:"season_start,omitempty"` // The time the ratings were last soft reset
+	version         string
+}
+
+func (s RatingDecayState) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionRatingDecay,
+		Key:             StorageKeyRatingDecayState,
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         s.version,
+	}
+}
+
+func (s *RatingDecayState) SetStorageVersion(userID, version string) {
+	s.version = version
+}
+
+// RatingAdjustment records a change to a player's rating that was not the result of a match.
+type RatingAdjustment struct {
+	Time      time.Time  `json:"time"`
+	Reason    string     `json:"reason"`
+	GroupID   string     `json:"group_id"`
+	Mode      evr.Symbol `json:"mode"`
+	Statistic string     `json:"statistic"` // The mu statistic of the rating system, or the rank percentile
+	Before    [2]float64 `json:"before"`    // The mu and sigma, or the percentile, before the adjustment
+	After     [2]float64 `json:"after"`
+}
+
+type RatingAdjustmentHistory struct {
+	Adjustments []RatingAdjustment `json:"adjustments"`
+	version     string
+}
+
+func (h RatingAdjustmentHistory) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionRatingAdjustment,
+		Key:             StorageKeyRatingAdjustment,
+		PermissionRead:  runtime.STORAGE_PERMISSION_OWNER_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         h.version,
+	}
+}
+
+func (h *RatingAdjustmentHistory) SetStorageVersion(userID, version string) {
+	h.version = version
+}
+
+// Add appends the adjustments, keeping at most maxEntries of the most recent.
+func (h *RatingAdjustmentHistory) Add(maxEntries int, adjustments ...RatingAdjustment) {
+	h.Adjustments = append(h.Adjustments, adjustments...)
+	if maxEntries > 0 && len(h.Adjustments) > maxEntries {
+		h.Adjustments = h.Adjustments[len(h.Adjustments)-maxEntries:]
+	}
+}
+
+// inflateSigma adds the inflation to sigma in quadrature, without exceeding the maximum. A sigma already above the maximum is kept.
+func inflateSigma(sigma, inflation, maxSigma float64) float64 {
+	inflated := math.Sqrt(sigma*sigma + inflation*inflation)
+	if maxSigma > 0 {
+		inflated = min(inflated, maxSigma)
+	}
+	return max(sigma, inflated)
+}
+
+// DecayRating inflates the sigma of a player that has not been rated in the inactivity period.
+func DecayRating(r types.Rating, settings RatingDecaySettings, ratedAt, now time.Time) types.Rating {
+	if now.Sub(ratedAt) < time.Duration(settings.InactivityDays)*24*time.Hour {
+		return r
+	}
+	r.Sigma = inflateSigma(r.Sigma, settings.SigmaInflation, settings.MaxSigma)
+	return r
+}
+
+// SeasonResetRating compresses mu toward the mean, and inflates sigma.
+func SeasonResetRating(r types.Rating, mean float64, settings RatingDecaySettings) types.Rating {
+	r.Mu = mean + (r.Mu-mean)*(1-settings.SeasonCompression)
+	r.Sigma = inflateSigma(r.Sigma, settings.SeasonSigmaInflation, settings.MaxSigma)
+	return r
+}
+
+// SeasonResetRankPercentile compresses the percentile toward the default.
+func SeasonResetRankPercentile(percentile, defaultPercentile float64, settings RatingDecaySettings) float64 {
+	return defaultPercentile + (percentile-defaultPercentile)*(1-settings.SeasonCompression)
+}
+
+// SeasonAdjustedRankPercentile applies the seasonal soft reset to a percentile recalculated from the statistics of the reset schedule,
+// while those leaderboards may still hold records from before the season started.
+func SeasonAdjustedRankPercentile(percentile float64, resetSchedule evr.ResetSchedule, settings GlobalMatchmakingSettings, seasonStart, now time.Time) float64 {
+	decaySettings := settings.RatingDecay
+	if decaySettings.SeasonSchedule == "" || decaySettings.SeasonCompression <= 0 || seasonStart.IsZero() {
+		return percentile
+	}
+	if cron := ResetScheduleToCron(resetSchedule); cron != "" {
+		if schedule, err := cronexpr.Parse(cron); err == nil && ratingDecayDue(schedule, seasonStart, now) {
+			// The leaderboard has reset since the season started.
+			return percentile
+		}
+	}
+	return SeasonResetRankPercentile(percentile, settings.RankPercentile.Default, decaySettings)
+}
+
+// ratingDecayDue returns true if the schedule has fired since the last run.
+func ratingDecayDue(schedule *cronexpr.Expression, last, now time.Time) bool {
+	next := schedule.Next(last)
+	return !next.IsZero() && !next.After(now)
+}
+
+// RatingDecayLoop runs the inactivity decay and the seasonal soft reset on their schedules.
+func RatingDecayLoop(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule) {
+	ticker := time.NewTicker(ratingDecayCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		settings := ServiceSettings()
+		if settings == nil {
+			continue
+		}
+
+		if err := ratingDecayRunDue(ctx, logger, nk, settings.Matchmaking, time.Now().UTC()); err != nil {
+			logger.WithField("error", err).Error("Failed to run the rating decay")
+		}
+	}
+}
+
+// ratingDecayRunDue claims, and runs, the jobs whose schedule has fired since they last ran.
+func ratingDecayRunDue(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, settings GlobalMatchmakingSettings, now time.Time) error {
+	decaySettings := settings.RatingDecay
+	if decaySettings.Schedule == "" && decaySettings.SeasonSchedule == "" {
+		return nil
+	}
+
+	state := &RatingDecayState{}
+	if err := StorageRead(ctx, nk, SystemUserID, state, false); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return fmt.Errorf("failed to read the rating decay state: %w", err)
+		}
+		state.version = "*"
+	}
+
+	if decaySettings.SeasonSchedule != "" {
+		ratingSeasonStart.Store(state.SeasonStart)
+	} else {
+		ratingSeasonStart.Store(time.Time{})
+	}
+
+	var decay, season, changed bool
+
+	if decaySettings.Schedule != "" {
+		schedule, err := cronexpr.Parse(decaySettings.Schedule)
+		if err != nil {
+			return fmt.Errorf("invalid rating decay schedule: %w", err)
+		}
+		if state.LastDecay.IsZero() {
+			// The first run is on the next scheduled time.
+			state.LastDecay, changed = now, true
+		} else if decay = ratingDecayDue(schedule, state.LastDecay, now); decay {
+			state.LastDecay, changed = now, true
+		}
+	}
+
+	if decaySettings.SeasonSchedule != "" {
+		schedule, err := cronexpr.Parse(decaySettings.SeasonSchedule)
+		if err != nil {
+			return fmt.Errorf("invalid season reset schedule: %w", err)
+		}
+		if state.LastSeasonReset.IsZero() {
+			// Enabling the seasons does not reset the ratings immediately.
+			state.LastSeasonReset, changed = now, true
+		} else if season = ratingDecayDue(schedule, state.LastSeasonReset, now); season {
+			state.LastSeasonReset, state.SeasonStart, changed = now, now, true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	// The write fails if another node has claimed the run.
+	if _, err := StorageWrite(ctx, nk, SystemUserID, state); err != nil {
+		return nil
+	}
+
+	if season {
+		// The rank percentile recalculation compresses the historical percentile from now on.
+		ratingSeasonStart.Store(state.SeasonStart)
+		if err := RatingDecayApply(ctx, logger, nk, settings, RatingAdjustmentReasonSeason, now); err != nil {
+			return err
+		}
+	} else if decay {
+		// The season reset already inflates every player's sigma.
+		if err := RatingDecayApply(ctx, logger, nk, settings, RatingAdjustmentReasonDecay, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RatingDecayApply decays, or soft resets, the ratings on every all-time rating and rank percentile leaderboard.
+func RatingDecayApply(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, settings GlobalMatchmakingSettings, reason string, now time.Time) error {
+	startTime := time.Now()
+
+	// The mu statistic of each rating system, and its sigma statistic.
+	sigmaStatIDs := make(map[string]string, len(ratingSystems))
+	for name := range ratingSystems {
+		mu, sigma := RatingStatisticIDs(name)
+		sigmaStatIDs[mu] = sigma
+	}
+
+	adjustments := make(map[string][]RatingAdjustment)
+
+	var cursor string
+	for {
+		list, err := nk.LeaderboardList(100, cursor)
+		if err != nil {
+			return fmt.Errorf("failed to list leaderboards: %w", err)
+		}
+
+		for _, leaderboard := range list.Leaderboards {
+			meta, err := LeaderboardMetaFromID(leaderboard.Id)
+			if err != nil || meta.ResetSchedule != evr.ResetScheduleAllTime {
+				continue
+			}
+
+			var boardAdjustments []userRatingAdjustment
+			if sigmaStatID, ok := sigmaStatIDs[meta.StatName]; ok {
+				boardAdjustments, err = ratingDecayApplyBoard(ctx, nk, settings.RatingDecay, meta, sigmaStatID, reason, now)
+			} else if meta.StatName == RankPercentileStatisticID && reason == RatingAdjustmentReasonSeason {
+				boardAdjustments, err = rankPercentileSeasonResetBoard(ctx, nk, settings, meta, now)
+			}
+			if err != nil {
+				logger.WithFields(map[string]any{
+					"leaderboard": leaderboard.Id,
+					"error":       err,
+				}).Warn("Failed to adjust the ratings")
+				continue
+			}
+
+			for _, a := range boardAdjustments {
+				adjustments[a.userID] = append(adjustments[a.userID], a.RatingAdjustment)
+			}
+		}
+
+		if cursor = list.Cursor; cursor == "" {
+			break
+		}
+	}
+
+	for userID, userAdjustments := range adjustments {
+		if err := RatingAdjustmentHistoryAdd(ctx, nk, userID, settings.RatingDecay.AuditHistoryMaxEntries, userAdjustments...); err != nil {
+			logger.WithFields(map[string]any{
+				"user_id": userID,
+				"error":   err,
+			}).Warn("Failed to record the rating adjustments")
+		}
+	}
+
+	logger.WithFields(map[string]any{
+		"reason":   reason,
+		"players":  len(adjustments),
+		"duration": time.Since(startTime),
+	}).Info("Adjusted the ratings")
+
+	return nil
+}
+
+type userRatingAdjustment struct {
+	RatingAdjustment
+	userID string
+}
+
+// leaderboardRecordsListAll returns every record on the leaderboard, keyed by owner ID.
+func leaderboardRecordsListAll(ctx context.Context, nk runtime.NakamaModule, boardID string) (map[string]*api.LeaderboardRecord, error) {
+	records := make(map[string]*api.LeaderboardRecord)
+	var cursor string
+	for {
+		chunk, _, nextCursor, _, err := nk.LeaderboardRecordsList(ctx, boardID, nil, 1000, cursor, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range chunk {
+			records[r.OwnerId] = r
+		}
+		if nextCursor == "" || len(chunk) == 0 {
+			return records, nil
+		}
+		cursor = nextCursor
+	}
+}
+
+// leaderboardRecordRatedAt returns the time the player was last rated, falling back to the time the record was updated.
+func leaderboardRecordRatedAt(r *api.LeaderboardRecord) time.Time {
+	metadata := struct {
+		RatedAt int64 `json:"rated_at"`
+	}{}
+	if err := json.Unmarshal([]byte(r.Metadata), &metadata); err == nil && metadata.RatedAt > 0 {
+		return time.Unix(metadata.RatedAt, 0).UTC()
+	}
+	return r.UpdateTime.AsTime()
+}
+
+// leaderboardRecordRewrite writes the new value to the record, keeping its username and metadata.
+func leaderboardRecordRewrite(ctx context.Context, nk runtime.NakamaModule, boardID string, r *api.LeaderboardRecord, value float64) error {
+	score, err := Float64ToScore(value)
+	if err != nil {
+		return fmt.Errorf("failed to convert float64 to int64 pair: %w", err)
+	}
+	metadata := make(map[string]any)
+	if r.Metadata != "" {
+		if err := json.Unmarshal([]byte(r.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to unmarshal the record metadata: %w", err)
+		}
+	}
+	if _, ok := metadata["rated_at"]; !ok {
+		// Keep the time of the last rating, instead of the time of this write.
+		metadata["rated_at"] = leaderboardRecordRatedAt(r).Unix()
+	}
+	if _, err := nk.LeaderboardRecordWrite(ctx, boardID, r.OwnerId, r.Username.GetValue(), score, 0, metadata, nil); err != nil {
+		return fmt.Errorf("failed to write the record: %w", err)
+	}
+	return nil
+}
+
+func ratingDecayApplyBoard(ctx context.Context, nk runtime.NakamaModule, settings RatingDecaySettings, meta LeaderboardMeta, sigmaStatID, reason string, now time.Time) ([]userRatingAdjustment, error) {
+	muBoardID := meta.ID()
+	sigmaBoardID := StatisticBoardID(meta.GroupID, meta.Mode, sigmaStatID, evr.ResetScheduleAllTime)
+
+	muRecords, err := leaderboardRecordsListAll(ctx, nk, muBoardID)
+	if err != nil {
+		return nil, err
+	}
+	sigmaRecords, err := leaderboardRecordsListAll(ctx, nk, sigmaBoardID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[string]types.Rating, len(muRecords))
+	mean := 0.0
+	for ownerID, muRecord := range muRecords {
+		sigmaRecord, ok := sigmaRecords[ownerID]
+		if !ok {
+			continue
+		}
+		r := types.Rating{Mu: ScoreToFloat64(muRecord.Score), Sigma: ScoreToFloat64(sigmaRecord.Score)}
+		if r.Mu == 0 || r.Sigma == 0 {
+			continue
+		}
+		ratings[ownerID] = r
+		mean += r.Mu
+	}
+	if len(ratings) == 0 {
+		return nil, nil
+	}
+	mean /= float64(len(ratings))
+
+	adjustments := make([]userRatingAdjustment, 0, len(ratings))
+	var errs []error
+	for ownerID, before := range ratings {
+		var after types.Rating
+		switch reason {
+		case RatingAdjustmentReasonSeason:
+			after = SeasonResetRating(before, mean, settings)
+		default:
+			after = DecayRating(before, settings, leaderboardRecordRatedAt(muRecords[ownerID]), now)
+		}
+		if after == before {
+			continue
+		}
+
+		if err := leaderboardRecordRewrite(ctx, nk, muBoardID, muRecords[ownerID], after.Mu); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := leaderboardRecordRewrite(ctx, nk, sigmaBoardID, sigmaRecords[ownerID], after.Sigma); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		adjustments = append(adjustments, userRatingAdjustment{
+			RatingAdjustment: RatingAdjustment{
+				Time:      now,
+				Reason:    reason,
+				GroupID:   meta.GroupID,
+				Mode:      meta.Mode,
+				Statistic: meta.StatName,
+				Before:    [2]float64{before.Mu, before.Sigma},
+				After:     [2]float64{after.Mu, after.Sigma},
+			},
+			userID: ownerID,
+		})
+	}
+	return adjustments, errors.Join(errs...)
+}
+
+func rankPercentileSeasonResetBoard(ctx context.Context, nk runtime.NakamaModule, settings GlobalMatchmakingSettings, meta LeaderboardMeta, now time.Time) ([]userRatingAdjustment, error) {
+	boardID := meta.ID()
+	records, err := leaderboardRecordsListAll(ctx, nk, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments := make([]userRatingAdjustment, 0, len(records))
+	var errs []error
+	for ownerID, record := range records {
+		before := ScoreToFloat64(record.Score)
+		after := SeasonResetRankPercentile(before, settings.RankPercentile.Default, settings.RatingDecay)
+		if after == before {
+			continue
+		}
+		if err := leaderboardRecordRewrite(ctx, nk, boardID, record, after); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		adjustments = append(adjustments, userRatingAdjustment{
+			RatingAdjustment: RatingAdjustment{
+				Time:      now,
+				Reason:    RatingAdjustmentReasonSeason,
+				GroupID:   meta.GroupID,
+				Mode:      meta.Mode,
+				Statistic: meta.StatName,
+				Before:    [2]float64{before},
+				After:     [2]float64{after},
+			},
+			userID: ownerID,
+		})
+	}
+	return adjustments, errors.Join(errs...)
+}
+
+// RatingAdjustmentHistoryAdd appends the adjustments to the player's audit history.
+func RatingAdjustmentHistoryAdd(ctx context.Context, nk runtime.NakamaModule, userID string, maxEntries int, adjustments ...RatingAdjustment) error {
+	var err error
+	for range 3 {
+		history := &RatingAdjustmentHistory{}
+		if err = StorageRead(ctx, nk, userID, history, false); err != nil {
+			if status.Code(err) != codes.NotFound {
+				return err
+			}
+			history.version = "*"
+		}
+
+		history.Add(maxEntries, adjustments...)
+
+		if _, err = StorageWrite(ctx, nk, userID, history); err == nil {
+			return nil
+		}
+	}
+	return errors.Join(errors.New("failed to update the rating adjustment history"), err)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/heroiclabs/nakama/v3/internal/cronexpr"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"github.com/intinig/go-openskill/types"
+)
+
+func testRatingDecaySettings() RatingDecaySettings {
+	return RatingDecaySettings{
+		InactivityDays:       14,
+		SigmaInflation:       0.5,
+		MaxSigma:             8.333,
+		SeasonCompression:    0.25,
+		SeasonSigmaInflation: 2,
+	}
+}
+
+func TestDecayRating(t *testing.T) {
+	settings := testRatingDecaySettings()
+	now := time.Date(2025, 6, 1, 4, 0, 0, 0, time.UTC)
+	r := types.Rating{Mu: 30, Sigma: 3}
+
+	if got := DecayRating(r, settings, now.Add(-24*time.Hour), now); got != r {
+		t.Errorf("expected an active player's rating to be unchanged, got %+v", got)
+	}
+
+	got := DecayRating(r, settings, now.Add(-15*24*time.Hour), now)
+	if got.Mu != r.Mu {
+		t.Errorf("expected mu to be unchanged, got %f", got.Mu)
+	}
+	if want := math.Sqrt(9 + 0.25); math.Abs(got.Sigma-want) > 1e-9 {
+		t.Errorf("expected sigma %f, got %f", want, got.Sigma)
+	}
+
+	// Sigma is capped, but never reduced.
+	if got := DecayRating(types.Rating{Mu: 30, Sigma: 8.32}, settings, time.Time{}, now); got.Sigma != 8.333 {
+		t.Errorf("expected sigma to be capped, got %f", got.Sigma)
+	}
+	if got := DecayRating(types.Rating{Mu: 30, Sigma: 9}, settings, time.Time{}, now); got.Sigma != 9 {
+		t.Errorf("expected sigma to be kept, got %f", got.Sigma)
+	}
+}
+
+func TestSeasonResetRating(t *testing.T) {
+	settings := testRatingDecaySettings()
+
+	got := SeasonResetRating(types.Rating{Mu: 30, Sigma: 3}, 22, settings)
+	if got.Mu != 28 {
+		t.Errorf("expected mu 28, got %f", got.Mu)
+	}
+	if got.Sigma <= 3 {
+		t.Errorf("expected sigma to be inflated, got %f", got.Sigma)
+	}
+
+	if got := SeasonResetRating(types.Rating{Mu: 14, Sigma: 3}, 22, settings); got.Mu != 16 {
+		t.Errorf("expected mu 16, got %f", got.Mu)
+	}
+
+	if got := SeasonResetRankPercentile(0.9, 0.5, settings); math.Abs(got-0.8) > 1e-9 {
+		t.Errorf("expected percentile 0.8, got %f", got)
+	}
+}
+
+func TestRatingDecayDue(t *testing.T) {
+	schedule := cronexpr.MustParse("0 4 * * *")
+	last := time.Date(2025, 6, 1, 4, 0, 0, 0, time.UTC)
+
+	if ratingDecayDue(schedule, last, last.Add(23*time.Hour)) {
+		t.Error("expected the job not to be due")
+	}
+	if !ratingDecayDue(schedule, last, last.Add(24*time.Hour)) {
+		t.Error("expected the job to be due")
+	}
+}
+
+func TestRatingAdjustmentHistoryAdd(t *testing.T) {
+	h := &RatingAdjustmentHistory{}
+	for i := range 5 {
+		h.Add(3, RatingAdjustment{Before: [2]float64{float64(i)}})
+	}
+	if len(h.Adjustments) != 3 || h.Adjustments[0].Before[0] != 2 {
+		t.Errorf("expected the 3 most recent adjustments, got %+v", h.Adjustments)
+	}
+}
+
+func TestSeasonAdjustedRankPercentile(t *testing.T) {
+	settings := GlobalMatchmakingSettings{
+		RankPercentile: RankPercentileSettings{
+			ResetSchedule:       evr.ResetScheduleDaily,
+			ResetScheduleDamper: evr.ResetScheduleAllTime,
+			DampeningFactor:     0.5,
+			Default:             0.5,
+		},
+		RatingDecay: testRatingDecaySettings(),
+	}
+	settings.RatingDecay.SeasonSchedule = "0 0 1 */3 *"
+
+	seasonStart := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := seasonStart.Add(time.Hour)
+
+	// The season reset compresses the stored percentile.
+	reset := SeasonResetRankPercentile(0.9, settings.RankPercentile.Default, settings.RatingDecay)
+
+	// Recalculating from statistics that predate the season gives the same percentile.
+	recalculate := func(now time.Time) float64 {
+		damping := SeasonAdjustedRankPercentile(0.9, settings.RankPercentile.ResetScheduleDamper, settings, seasonStart, now)
+		active := SeasonAdjustedRankPercentile(0.9, settings.RankPercentile.ResetSchedule, settings, seasonStart, now)
+		return active + (damping-active)*settings.RankPercentile.DampeningFactor
+	}
+	if got := recalculate(now); math.Abs(got-reset) > 1e-9 {
+		t.Errorf("expected the recalculation to keep the reset percentile %f, got %f", reset, got)
+	}
+
+	// Once the daily leaderboards reset, only the all-time percentile is compressed.
+	if got := recalculate(seasonStart.Add(24 * time.Hour)); got <= reset || got >= 0.9 {
+		t.Errorf("expected the active percentile to be uncompressed, got %f", got)
+	}
+
+	// Nothing is compressed before the first season.
+	if got := SeasonAdjustedRankPercentile(0.9, evr.ResetScheduleAllTime, settings, time.Time{}, now); got != 0.9 {
+		t.Errorf("expected no compression before the first season, got %f", got)
+	}
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/intinig/go-openskill/types"
+)
+
+const (
+	RatingSystemOpenSkill = "openskill"
+	RatingSystemGlicko2   = "glicko2"
+	RatingSystemElo       = "elo"
+
+	Glicko2RatingMuStatisticID    = "Glicko2RatingMu"
+	Glicko2RatingSigmaStatisticID = "Glicko2RatingSigma"
+	EloRatingMuStatisticID        = "EloRatingMu"
+	EloRatingSigmaStatisticID     = "EloRatingSigma"
+
+	MinimumParticipationWeight = 0.1 // The weight of a player that joined at the end of the round
+	MarginOfVictoryReference   = 20  // The goal point margin that weights the rating change by 1.5
+)
+
+// RatingSystem calculates the new ratings of the players from the outcome of a match.
+// Every system uses the mu/sigma scale of the default OpenSkill rating, so the matchmaker can compare the ratings of any system.
+type RatingSystem interface {
+	Name() string
+	// Rate returns the new ratings of the competitors, keyed by session ID.
+	Rate(result RatedMatchResult) map[string]types.Rating
+}
+
+var ratingSystems = map[string]RatingSystem{
+	RatingSystemOpenSkill: OpenSkillRatingSystem{},
+	RatingSystemGlicko2:   Glicko2RatingSystem{Tau: 0.5, Volatility: 0.06},
+	RatingSystemElo:       EloRatingSystem{K: 32},
+}
+
+// RatingSystemByName returns the named rating system, defaulting to OpenSkill.
+func RatingSystemByName(name string) RatingSystem {
+	if s, ok := ratingSystems[name]; ok {
+		return s
+	}
+	return ratingSystems[RatingSystemOpenSkill]
+}
+
+// RatingStatisticIDs returns the IDs of the statistics that the system's ratings are stored under.
+func RatingStatisticIDs(system string) (mu string, sigma string) {
+	switch system {
+	case RatingSystemGlicko2:
+		return Glicko2RatingMuStatisticID, Glicko2RatingSigmaStatisticID
+	case RatingSystemElo:
+		return EloRatingMuStatisticID, EloRatingSigmaStatisticID
+	default:
+		return SkillRatingMuStatisticID, SkillRatingSigmaStatisticID
+	}
+}
+
+type RatedMatchResult struct {
+	Weighting     RatingWeightingSettings
+	Players       []PlayerInfo
+	BlueWins      bool
+	BlueScore     int           // The goal points of the blue team, for margin of victory weighting
+	OrangeScore   int           // The goal points of the orange team
+	RoundDuration time.Duration // The length of the round, for weighting partial participation
+}
+
+func NewRatedMatchResult(label *MatchLabel, blueWins bool, weighting RatingWeightingSettings) RatedMatchResult {
+	result := RatedMatchResult{
+		Weighting: weighting,
+		Players:   label.Players,
+		BlueWins:  blueWins,
+	}
+	if label.GameState != nil {
+		result.BlueScore = label.GameState.BlueScore
+		result.OrangeScore = label.GameState.OrangeScore
+		if label.GameState.RoundClock != nil {
+			result.RoundDuration = label.GameState.RoundClock.Duration
+		}
+	}
+	return result
+}
+
+// Competitors returns a copy of the players that are on blue or orange.
+func (r RatedMatchResult) Competitors() []PlayerInfo {
+	return slices.DeleteFunc(slices.Clone(r.Players), func(p PlayerInfo) bool {
+		return !p.IsCompetitor()
+	})
+}
+
+func (r RatedMatchResult) IsWinner(p PlayerInfo) bool {
+	return (p.Team == BlueTeam) == r.BlueWins
+}
+
+// Weight returns the weight of the player's rating change. When enabled, it is reduced for players that joined after the round started,
+// and scaled by the margin of victory from 0.5 for a one point margin to 1.5 at the reference margin. A margin of zero, such as a forfeit, is not weighted.
+func (r RatedMatchResult) Weight(p PlayerInfo) float64 {
+	weight := 1.0
+	if r.Weighting.EnableParticipation && r.RoundDuration > 0 && p.JoinTime > 0 {
+		played := r.RoundDuration - time.Duration(p.JoinTime)*time.Millisecond
+		weight = min(max(float64(played)/float64(r.RoundDuration), MinimumParticipationWeight), 1.0)
+	}
+	if margin := math.Abs(float64(r.BlueScore - r.OrangeScore)); r.Weighting.EnableMarginOfVictory && margin > 0 {
+		weight *= 0.5 + math.Log(margin)/math.Log(MarginOfVictoryReference)
+	}
+	return weight
+}
+
+// weightedRating scales the change from the old rating to the new one by the weight.
+func weightedRating(old, new types.Rating, weight float64) types.Rating {
+	if weight == 1.0 {
+		return new
+	}
+	new.Mu = old.Mu + weight*(new.Mu-old.Mu)
+	new.Sigma = max(old.Sigma+weight*(new.Sigma-old.Sigma), 0.01)
+	return new
+}
+
+// teamAverage returns the mean mu, and the root mean square sigma, of the team.
+func teamAverage(players []PlayerInfo) (mu float64, sigma float64) {
+	if len(players) == 0 {
+		r := NewDefaultRating()
+		return r.Mu, r.Sigma
+	}
+	for _, p := range players {
+		r := p.Rating()
+		mu += r.Mu
+		sigma += r.Sigma * r.Sigma
+	}
+	return mu / float64(len(players)), math.Sqrt(sigma / float64(len(players)))
+}
+
+type OpenSkillRatingSystem struct{}
+
+func (OpenSkillRatingSystem) Name() string {
+	return RatingSystemOpenSkill
+}
+
+func (OpenSkillRatingSystem) Rate(result RatedMatchResult) map[string]types.Rating {
+	players := result.Competitors()
+	ratings := CalculateNewPlayerRatings(players, result.BlueWins)
+	for _, p := range players {
+		if r, ok := ratings[p.SessionID]; ok {
+			ratings[p.SessionID] = weightedRating(p.Rating(), r, result.Weight(p))
+		}
+	}
+	return ratings
+}
+
+// Glicko2RatingSystem rates each player against the average of the opposing team.
+// Only mu and sigma are stored, so each rating period starts from the initial volatility.
+type Glicko2RatingSystem struct {
+	Tau        float64 // Constrains the change in volatility
+	Volatility float64 // The initial volatility
+}
+
+const (
+	glicko2Scale          = 173.7178
+	glicko2DefaultRD      = 350.0
+	glicko2ConvergenceEps = 0.000001
+)
+
+func (Glicko2RatingSystem) Name() string {
+	return RatingSystemGlicko2
+}
+
+func (s Glicko2RatingSystem) Rate(result RatedMatchResult) map[string]types.Rating {
+	players := result.Competitors()
+	teams := map[TeamIndex][]PlayerInfo{}
+	for _, p := range players {
+		teams[p.Team] = append(teams[p.Team], p)
+	}
+
+	// Convert from the OpenSkill scale, where the default sigma is the default Glicko RD.
+	defaultRating := NewDefaultRating()
+	unit := defaultRating.Sigma / (glicko2DefaultRD / glicko2Scale)
+	toGlicko := func(mu, sigma float64) (float64, float64) {
+		return (mu - defaultRating.Mu) / unit, sigma / unit
+	}
+
+	ratings := make(map[string]types.Rating, len(players))
+	for _, p := range players {
+		opponents := teams[OrangeTeam]
+		if p.Team == OrangeTeam {
+			opponents = teams[BlueTeam]
+		}
+		if len(opponents) == 0 {
+			continue
+		}
+
+		old := p.Rating()
+		mu, phi := toGlicko(old.Mu, old.Sigma)
+		muJ, phiJ := toGlicko(teamAverage(opponents))
+
+		score := 0.0
+		if result.IsWinner(p) {
+			score = 1.0
+		}
+
+		g := 1 / math.Sqrt(1+3*phiJ*phiJ/(math.Pi*math.Pi))
+		e := 1 / (1 + math.Exp(-g*(mu-muJ)))
+		v := 1 / (g * g * e * (1 - e))
+		delta := v * g * (score - e)
+
+		sigma := s.volatility(phi, v, delta)
+		phiStar := math.Sqrt(phi*phi + sigma*sigma)
+		newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+		newMu := mu + newPhi*newPhi*g*(score-e)
+
+		r := old
+		r.Mu = newMu*unit + defaultRating.Mu
+		r.Sigma = newPhi * unit
+		ratings[p.SessionID] = weightedRating(old, r, result.Weight(p))
+	}
+	return ratings
+}
+
+// volatility finds the new volatility with the Illinois algorithm.
+func (s Glicko2RatingSystem) volatility(phi, v, delta float64) float64 {
+	a := math.Log(s.Volatility * s.Volatility)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-d)/(2*d*d) - (x-a)/(s.Tau*s.Tau)
+	}
+
+	A, B := a, 0.0
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*s.Tau) < 0 {
+			k++
+		}
+		B = a - k*s.Tau
+	}
+
+	fA, fB := f(A), f(B)
+	for i := 0; math.Abs(B-A) > glicko2ConvergenceEps && i < 100; i++ {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
+
+// EloRatingSystem rates each player against the average of the opposing team. Sigma is left unchanged.
+type EloRatingSystem struct {
+	K float64 // The maximum change, in Elo points
+}
+
+const eloScale = 400.0
+
+func (EloRatingSystem) Name() string {
+	return RatingSystemElo
+}
+
+func (s EloRatingSystem) Rate(result RatedMatchResult) map[string]types.Rating {
+	players := result.Competitors()
+	teams := map[TeamIndex][]PlayerInfo{}
+	for _, p := range players {
+		teams[p.Team] = append(teams[p.Team], p)
+	}
+
+	// Elo points are converted to the OpenSkill scale, where the default sigma is the default Glicko RD.
+	unit := NewDefaultRating().Sigma / glicko2DefaultRD
+
+	ratings := make(map[string]types.Rating, len(players))
+	for _, p := range players {
+		opponents := teams[OrangeTeam]
+		if p.Team == OrangeTeam {
+			opponents = teams[BlueTeam]
+		}
+		if len(opponents) == 0 {
+			continue
+		}
+
+		old := p.Rating()
+		opponentMu, _ := teamAverage(opponents)
+		expected := 1 / (1 + math.Pow(10, (opponentMu-old.Mu)/(eloScale*unit)))
+
+		score := 0.0
+		if result.IsWinner(p) {
+			score = 1.0
+		}
+
+		r := old
+		r.Mu += result.Weight(p) * s.K * unit * (score - expected)
+		ratings[p.SessionID] = r
+	}
+	return ratings
+}
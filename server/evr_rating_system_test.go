@@ -0,0 +1,108 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testRatedMatchResult() RatedMatchResult {
+	return RatedMatchResult{
+		Players: []PlayerInfo{
+			{SessionID: "1", Team: BlueTeam, RatingMu: 25, RatingSigma: 8.333},
+			{SessionID: "2", Team: BlueTeam, RatingMu: 22, RatingSigma: 6},
+			{SessionID: "3", Team: OrangeTeam, RatingMu: 27, RatingSigma: 5},
+			{SessionID: "4", Team: OrangeTeam, RatingMu: 24, RatingSigma: 7},
+			{SessionID: "5", Team: SocialLobbyParticipant, RatingMu: 25, RatingSigma: 8.333},
+		},
+		BlueWins: true,
+	}
+}
+
+func TestRatingSystemRate(t *testing.T) {
+	for _, name := range []string{RatingSystemOpenSkill, RatingSystemGlicko2, RatingSystemElo} {
+		t.Run(name, func(t *testing.T) {
+			system := RatingSystemByName(name)
+			if system.Name() != name {
+				t.Fatalf("expected %s, got %s", name, system.Name())
+			}
+
+			result := testRatedMatchResult()
+			ratings := system.Rate(result)
+			if len(ratings) != 4 {
+				t.Fatalf("expected 4 ratings, got %d", len(ratings))
+			}
+			if _, ok := ratings["5"]; ok {
+				t.Error("expected the spectator to be unrated")
+			}
+
+			// OpenSkill rates each player on their own score, so only the team's total change is compared.
+			var winnerGain, loserGain float64
+			for _, p := range result.Competitors() {
+				if result.IsWinner(p) {
+					winnerGain += ratings[p.SessionID].Mu - p.RatingMu
+				} else {
+					loserGain += ratings[p.SessionID].Mu - p.RatingMu
+				}
+			}
+			if winnerGain <= 0 || loserGain >= 0 {
+				t.Errorf("expected the winners to gain and the losers to lose, got %f and %f", winnerGain, loserGain)
+			}
+		})
+	}
+}
+
+func TestRatedMatchResultWeight(t *testing.T) {
+	result := testRatedMatchResult()
+	result.RoundDuration = 5 * time.Minute
+	result.Players[1].JoinTime = (4 * time.Minute).Milliseconds()
+	result.BlueScore, result.OrangeScore = 20, 0
+
+	// Weighting is disabled by default.
+	if w := result.Weight(result.Players[1]); w != 1.0 {
+		t.Errorf("expected no weighting by default, got %f", w)
+	}
+
+	result.Weighting = RatingWeightingSettings{EnableParticipation: true}
+	if w := result.Weight(result.Players[0]); w != 1.0 {
+		t.Errorf("expected a full weight, got %f", w)
+	}
+	if w := result.Weight(result.Players[1]); w < 0.19 || w > 0.21 {
+		t.Errorf("expected a weight of 0.2 for the late joiner, got %f", w)
+	}
+
+	// A late joiner's rating changes less.
+	ratings := RatingSystemByName(RatingSystemElo).Rate(result)
+	if ratings["2"].Mu-result.Players[1].RatingMu >= ratings["1"].Mu-result.Players[0].RatingMu {
+		t.Error("expected the late joiner to gain less")
+	}
+
+	// A blowout weighs more than a close game.
+	result.Weighting = RatingWeightingSettings{EnableMarginOfVictory: true}
+	result.BlueScore, result.OrangeScore = 1, 0
+	if w := result.Weight(result.Players[0]); w != 0.5 {
+		t.Errorf("expected a one point margin to weigh 0.5, got %f", w)
+	}
+	result.BlueScore = 20
+	if w := result.Weight(result.Players[0]); math.Abs(w-1.5) > 1e-9 {
+		t.Errorf("expected the reference margin to weigh 1.5, got %f", w)
+	}
+
+	// A margin of zero is not weighted.
+	result.BlueScore, result.OrangeScore = 3, 3
+	if w := result.Weight(result.Players[0]); w != 1.0 {
+		t.Errorf("expected a zero margin to be unweighted, got %f", w)
+	}
+}
+
+func TestRatingStatisticIDs(t *testing.T) {
+	if mu, sigma := RatingStatisticIDs(RatingSystemOpenSkill); mu != SkillRatingMuStatisticID || sigma != SkillRatingSigmaStatisticID {
+		t.Errorf("unexpected OpenSkill statistics: %s, %s", mu, sigma)
+	}
+	if mu, _ := RatingStatisticIDs(RatingSystemGlicko2); mu != Glicko2RatingMuStatisticID {
+		t.Errorf("unexpected Glicko-2 statistic: %s", mu)
+	}
+	if RatingSystemByName("unknown").Name() != RatingSystemOpenSkill {
+		t.Error("expected the default to be OpenSkill")
+	}
+}
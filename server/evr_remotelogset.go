@@ -89,10 +89,18 @@ func (p *EvrPipeline) processRemoteLogSets(ctx context.Context, logger *zap.Logg
 		entries = append(entries, parsed)
 	}
 
+	var buildNumber evr.BuildNumber
+	if params, ok := LoadParams(session.Context()); ok {
+		buildNumber = params.BuildNumber()
+	}
+
 	// Send the remote logs to the match data event.
 	if matchDatas := make(map[MatchID][]evr.RemoteLog, len(entries)); len(entries) > 0 {
+		sessionless := make([]evr.RemoteLog, 0)
 		for _, e := range entries {
-			if m, ok := e.(evr.SessionIdentifier); ok {
+			if isSessionlessMatchTelemetry(e) {
+				sessionless = append(sessionless, e)
+			} else if m, ok := e.(evr.SessionIdentifier); ok {
 				if m.SessionUUID().IsNil() {
 					continue
 				}
@@ -110,11 +118,19 @@ func (p *EvrPipeline) processRemoteLogSets(ctx context.Context, logger *zap.Logg
 			}
 		}
 
+		// The logs without a session are for the match the player is in.
+		if len(sessionless) > 0 {
+			if matchID, _, err := GetMatchIDBySessionID(p.nk, session.id); err == nil {
+				matchDatas[matchID] = append(matchDatas[matchID], sessionless...)
+			}
+		}
+
 		for matchID, matchData := range matchDatas {
 			entry := MatchDataRemoteLogSet{
-				UserID:    session.userID.String(),
-				SessionID: matchID.String(),
-				Logs:      matchData,
+				UserID:      session.userID.String(),
+				SessionID:   matchID.String(),
+				BuildNumber: buildNumber,
+				Logs:        matchData,
 			}
 			if err := MatchDataEvent(ctx, p.nk, matchID, entry); err != nil {
 				logger.Error("Failed to process match data event", zap.Error(err))
@@ -152,6 +168,15 @@ func (p *EvrPipeline) processRemoteLogSets(ctx context.Context, logger *zap.Logg
 
 		case *evr.RemoteLogDisconnectedDueToTimeout:
 			logger.Warn("Disconnected due to timeout", zap.String("username", session.Username()), zap.String("evr_id", evrID.String()), zap.Any("remote_log_message", msg))
+			key := clientHealthKey(session, msg.SessionUUID())
+			if key.GameServer == "" {
+				key.GameServer = msg.ServerAddress
+			}
+			globalClientHealthRegistry.RecordEvent(key, ClientHealthEventTimeout)
+
+		case *evr.RemoteLogR15NetGameErrorMessage:
+			logger.Warn("Net game error", zap.String("username", session.Username()), zap.String("evr_id", evrID.String()), zap.String("error_message", msg.ErrorMessage))
+			globalClientHealthRegistry.RecordEvent(clientHealthKey(session, uuid.Nil), ClientHealthEventNetGameError)
 
 		case *evr.RemoteLogUserDisconnected:
 
@@ -203,12 +228,16 @@ func (p *EvrPipeline) processRemoteLogSets(ctx context.Context, logger *zap.Logg
 
 			update.FromGoal(msg)
 
-		case *evr.RemoteLogGhostUser:
-			// This is a ghost user message.
-
-		case *evr.RemoteLogVOIPLoudness:
+		case *evr.RemoteLogVOIPLoudness, *evr.RemoteLogGhostUser, *evr.RemoteLogGhostAll, *evr.RemoteLogInteractionEvent, *evr.RemoteLogRepairMatrix, *evr.RemoteLogSessionStarted, *evr.RemoteLogGearStatsPerRound, *evr.RemoteLogNetExplosion:
+			// These are aggregated into the match telemetry from the match data journal.
+			p.nk.MetricsCounterAdd("remotelog_match_telemetry_count", map[string]string{
+				"message_type":  msg.MessageType(),
+				"build_version": fmt.Sprintf("%d", buildNumber),
+			}, 1)
 
-		case *evr.RemoteLogSessionStarted:
+		case *evr.RemoteLogLoadStats:
+			// The load times are recorded by the client health registry.
+			globalClientHealthRegistry.RecordLoad(clientHealthKey(session, uuid.Nil), msg.LoadTime, msg.ClientLoadTime, msg.ServerLoadTime)
 
 		case *evr.RemoteLogPauseSettings:
 			if request.EvrID.PlatformCode == 0 || request.EvrID.AccountId == 0 {
@@ -296,9 +325,8 @@ func (p *EvrPipeline) processRemoteLogSets(ctx context.Context, logger *zap.Logg
 				return fmt.Errorf("failed to cache profile: %w", err)
 			}
 
-		case *evr.RemoteLogRepairMatrix:
-
 		case *evr.RemoteLogServerConnectionFailed:
+			globalClientHealthRegistry.RecordEvent(clientHealthKey(session, msg.SessionUUID()), ClientHealthEventConnectionFailed)
 
 			params, ok := LoadParams(session.Context())
 			if !ok {
@@ -356,6 +384,7 @@ func (p *EvrPipeline) processRemoteLogSets(ctx context.Context, logger *zap.Logg
 				logger.Error("Failed to marshal message content", zap.Error(err))
 			}
 			p.appBot.LogUserErrorMessage(ctx, label.GetGroupID().String(), fmt.Sprintf("```json\n%s\n```", string(contentData)), false)
+			globalBroadcasterRegistry.ConnectionFailed(label.GameServer.Endpoint.ExternalAddress(), session.userID.String())
 
 			logger.Warn("Server connection failed", zap.String("username", session.Username()), zap.String("match_id", msg.SessionUUID().String()), zap.String("evr_id", evrID.String()), zap.Any("remote_log_message", msg))
 
@@ -391,6 +420,25 @@ func (p *EvrPipeline) processRemoteLogSets(ctx context.Context, logger *zap.Logg
 	return nil
 }
 
+// clientHealthKey returns the session's client health bucket. The game server is that of the lobby the session last
+// joined, if it is the match, or if the match is not known.
+func clientHealthKey(session *sessionWS, matchUUID uuid.UUID) ClientHealthKey {
+	key := ClientHealthKey{
+		HeadsetType: normalizeHeadsetType(""),
+	}
+	if params, ok := LoadParams(session.Context()); ok {
+		key.BuildNumber = params.BuildNumber()
+		key.HeadsetType = params.DeviceType()
+		if params.ipInfo != nil {
+			key.Region = LocationToRegionCode(params.ipInfo.CountryCode(), params.ipInfo.Region(), "")
+		}
+		if gs := params.lobbyGameServer.Load(); gs != nil && (matchUUID.IsNil() || gs.MatchID.UUID == matchUUID) {
+			key.GameServer = gs.Address
+		}
+	}
+	return key
+}
+
 type MatchGameStateUpdate struct {
 	CurrentGameClock time.Duration `json:"current_game_clock,omitempty"`
 	PauseDuration    time.Duration `json:"pause_duration,omitempty"`
@@ -68,6 +68,24 @@ func InitializeEvrRuntimeModule(ctx context.Context, logger runtime.Logger, db *
 
 	rpcHandler := NewRPCHandler(ctx, db, dg)
 
+	matchDataSink, err := NewMatchDataSink(ctx, logger, db, vars)
+	if err != nil {
+		return fmt.Errorf("unable to create match data sink: %w", err)
+	}
+
+	// Journals are written to local files if the sink fails.
+	matchDataFallback, err := NewMatchDataFallbackSink(vars)
+	if err != nil {
+		return fmt.Errorf("unable to create fallback match data sink: %w", err)
+	}
+
+	// The match data sink is also used to read back match timelines.
+	matchDataReader, _ := matchDataSink.(MatchDataReader)
+	if matchDataReader != nil {
+		globalMatchDataReader.Store(matchDataReader)
+	}
+	matchDataScanner, _ := matchDataSink.(MatchDataScanner)
+
 	// Register RPC's for device linking
 	rpcs := map[string]func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error){
 		"account/search":                AccountSearchRPC,
@@ -83,10 +101,31 @@ func InitializeEvrRuntimeModule(ctx context.Context, logger runtime.Logger, db *
 		"match/prepare":                 PrepareMatchRPC,
 		"match/terminate":               shutdownMatchRpc,
 		"match/build":                   BuildMatchRPC,
+		"match/timeline":                MatchTimelineRPCFactory(matchDataReader),
+		"match/telemetry":               MatchTelemetryRPCFactory(matchDataReader),
+		"statistics/recompute":          StatisticsRecomputeRPCFactory(matchDataScanner),
+		"client/health":                 ClientHealthRPC,
 		"player/setnextmatch":           SetNextMatchRPC,
 		"player/statistics":             PlayerStatisticsRPC,
 		"player/kick":                   KickPlayerRPC,
 		"player/profile":                UserServerProfileRPC,
+		"player/earlyquit":              EarlyQuitStatusRPC,
+		"enforcement/appeal":            rpcHandler.EnforcementAppealFileRPC,
+		"enforcement/appeals":           EnforcementAppealsListRPC,
+		"enforcement/appeal/review":     rpcHandler.EnforcementAppealReviewRPC,
+		"enforcement/audit":             EnforcementAuditTrailRPC,
+		"iap/grant":                     IAPLedgerRPCFactory(IAPLedgerActionGrant),
+		"iap/revoke":                    IAPLedgerRPCFactory(IAPLedgerActionRevoke),
+		"iap/entitlements":              IAPEntitlementsRPC,
+		"iap/purchase":                  IAPPurchaseRPC,
+		"document/publish":              DocumentPublishRPC,
+		"config/publish":                ConfigResourcePublishRPC,
+		"config/history":                ConfigResourceHistoryRPC,
+		"config/diff":                   ConfigResourceDiffRPC,
+		"config/rollback":               ConfigResourceRollbackRPC,
+		"config/experiment":             ConfigExperimentRPC,
+		"gameserver/list":               GameServerListRPC,
+		"gameserver/action":             GameServerActionRPC,
 		"link":                          LinkingAppRpc,
 		"evr/servicestatus":             rpcHandler.ServiceStatusRPC,
 		"importloadouts":                ImportLoadoutsRpc,
@@ -97,6 +136,11 @@ func InitializeEvrRuntimeModule(ctx context.Context, logger runtime.Logger, db *
 		"server/score":                  ServerScoreRPC,
 		"server/scores":                 ServerScoresRPC,
 		"forcecheck":                    CheckForceUserRPC,
+		"developer/applications":        DeveloperApplicationsRPC,
+		"developer/token/rotate":        DeveloperApplicationTokenRotateRPC,
+		"developer/token/revoke":        DeveloperApplicationTokenRevokeRPC,
+		"developer/signingkey/rotate":   DeveloperSigningKeyRotateRPC,
+		"developer/scopes/set":          DeveloperApplicationScopesSetRPC,
 		//"/v1/storage/game/sourcedb/rad15/json/r14/loading_tips.json": StorageLoadingTipsRPC,
 	}
 
@@ -131,7 +175,7 @@ func InitializeEvrRuntimeModule(ctx context.Context, logger runtime.Logger, db *
 		return err
 	}
 
-	appAcceptorFn := NewAppAPIAcceptor(ctx, logger, db, nk, initializer)
+	appAcceptorFn := NewAppAPIAcceptor(ctx, logger, db, nk, initializer, rpcHandler)
 
 	// Register HTTP Handler for the evr/api service
 	if err := initializer.RegisterHttp("/apievr/v1/{id:.*}", appAcceptorFn, http.MethodGet, http.MethodPost); err != nil {
@@ -154,16 +198,6 @@ func InitializeEvrRuntimeModule(ctx context.Context, logger runtime.Logger, db *
 		}
 	*/
 
-	/*
-		mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(vars["MONGO_URI"]).SetTimeout(3*time.Second))
-		if err != nil {
-			logger.WithField("error", err).Error("Failed to connect to MongoDB")
-			return
-		}
-		if err := mongoClient.Ping(ctx, nil); err != nil {
-			logger.WithField("error", err).Error("Failed to ping MongoDB")
-		}
-	*/
 	ctx = context.WithValue(ctx, runtime.RUNTIME_CTX_ENV, vars)
 
 	botToken, ok = vars["DISCORD_BOT_TOKEN"]
@@ -180,7 +214,7 @@ func InitializeEvrRuntimeModule(ctx context.Context, logger runtime.Logger, db *
 	}
 
 	// Register the event dispatch
-	eventDispatch, err := NewEventDispatch(ctx, logger, db, nk, initializer, nil, dg)
+	eventDispatch, err := NewEventDispatch(ctx, logger, db, nk, initializer, matchDataSink, matchDataFallback, dg)
 	if err != nil {
 		return fmt.Errorf("unable to create event dispatch: %w", err)
 	}
@@ -198,6 +232,9 @@ func InitializeEvrRuntimeModule(ctx context.Context, logger runtime.Logger, db *
 	// Migrate any system level data
 	go MigrateSystem(ctx, logger, db, nk)
 
+	// Decay the ratings of inactive players, and soft reset the ratings each season
+	go RatingDecayLoop(ctx, logger, nk)
+
 	// Update the metrics with match data
 	go func() {
 		<-time.After(15 * time.Second)
@@ -265,6 +302,7 @@ func RegisterIndexes(initializer runtime.Initializer) error {
 		&LoginHistory{},
 		&DeveloperApplications{},
 		&GuildEnforcementRecords{},
+		&EnforcementAppeal{},
 		&MatchmakingSettings{},
 		&VRMLPlayerSummary{},
 	}
@@ -572,6 +610,7 @@ func KickPlayerFromMatch(ctx context.Context, nk runtime.NakamaModule, matchID M
 
 		signal := SignalKickEntrantsPayload{
 			UserIDs: []uuid.UUID{uuid.FromStringOrNil(userID)},
+			Message: "You have been removed from the match.",
 		}
 
 		data := NewSignalEnvelope(userID, SignalKickEntrants, signal).String()
@@ -3,61 +3,87 @@ package server
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"net"
 	"net/http"
 	"strings"
 	"time"
 
+	grpcgw "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/heroiclabs/nakama-common/runtime"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	DEVAPP_CTX_APP_ID = "dev_app_id"
+
+	AppAPIPathPrefix = "/apievr/v1"
+
+	// How long an authenticated token is trusted before it is checked against storage again.
+	// Revocations and rotations on any node take effect within this time.
+	AppAPIAuthCacheTTL = 30 * time.Second
 )
 
+// appAPIAuthEntry is a cached result of ApplicationTokenAuthenticate.
+type appAPIAuthEntry struct {
+	app       *DeveloperApplication
+	claims    *ApplicationTokenClaims
+	expiresAt time.Time
+}
+
+// appAPIRoute is a single endpoint of the developer API, and the scope required to call it.
+type appAPIRoute struct {
+	scope     string
+	handlerFn func(context.Context, http.ResponseWriter, *http.Request)
+}
+
 type AppAPI struct {
-	ctx      context.Context
-	logger   runtime.Logger
-	db       *sql.DB
-	nk       runtime.NakamaModule
-	handlers map[string]func(context.Context, http.ResponseWriter, *http.Request)
+	ctx          context.Context
+	logger       runtime.Logger
+	db           *sql.DB
+	nk           runtime.NakamaModule
+	rpcHandler   *RPCHandler
+	routes       map[string]appAPIRoute
+	rateLimiters *MapOf[string, *rate.Limiter]
+	authCache    *MapOf[string, appAPIAuthEntry]
 }
 
-func NewAppAPI(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, initializer runtime.Initializer) *AppAPI {
-	return &AppAPI{
-		ctx:    ctx,
-		logger: logger,
-		db:     db,
-		nk:     nk,
-		handlers: map[string]func(context.Context, http.ResponseWriter, *http.Request){
-			"/": func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-				// Respond to the request
-				w.Write([]byte("Hello, World!"))
-			},
-		},
+func NewAppAPI(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, initializer runtime.Initializer, rpcHandler *RPCHandler) *AppAPI {
+	api := &AppAPI{
+		ctx:          ctx,
+		logger:       logger,
+		db:           db,
+		nk:           nk,
+		rpcHandler:   rpcHandler,
+		rateLimiters: &MapOf[string, *rate.Limiter]{},
+		authCache:    &MapOf[string, appAPIAuthEntry]{},
+	}
+
+	api.routes = map[string]appAPIRoute{
+		"/matches":              {DeveloperScopeMatchesRead, api.matchesHandler},
+		"/players/statistics":   {DeveloperScopeStatsRead, api.playerStatisticsHandler},
+		"/leaderboards/records": {DeveloperScopeLeaderboardsRead, api.leaderboardRecordsHandler},
+		"/guilds":               {DeveloperScopeGuildsRead, api.guildHandler},
+		"/guilds/appeals":       {DeveloperScopeGuildModerate, api.guildAppealsHandler},
 	}
+
+	return api
 }
 
-func NewAppAPIAcceptor(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, initializer runtime.Initializer) func(http.ResponseWriter, *http.Request) {
-	appAPI := NewAppAPI(ctx, logger, db, nk, initializer)
+func NewAppAPIAcceptor(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, initializer runtime.Initializer, rpcHandler *RPCHandler) func(http.ResponseWriter, *http.Request) {
+	appAPI := NewAppAPI(ctx, logger, db, nk, initializer, rpcHandler)
 
 	_nk := nk.(*RuntimeGoNakamaModule)
-	signingKey := _nk.config.GetSession().EncryptionKey
+	sessionKey := _nk.config.GetSession().EncryptionKey
 	config := _nk.config
 	node := config.GetName()
 	env := config.GetRuntime().Environment
 
-	var authErrorBody string
-
-	if data, err := json.Marshal(APIErrorMessage{Code: 0, Message: "Missing or invalid token"}); err != nil {
-		panic(err)
-	} else {
-		authErrorBody = string(data)
-	}
-
 	// This handler will be attached to the API Gateway server.
 	return func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
 
 		// Check authentication.
 		var token string
@@ -65,7 +91,7 @@ func NewAppAPIAcceptor(ctx context.Context, logger runtime.Logger, db *sql.DB, n
 			// Attempt header based authentication.
 			const prefix = "Bearer "
 			if !strings.HasPrefix(auth[0], prefix) {
-				http.Error(w, authErrorBody, 401)
+				_ = RESTError(w, APIErrorMessage{Code: ErrCodeInvalidAuthenticationToken, Message: "Missing or invalid token"}, http.StatusUnauthorized)
 				return
 			}
 			token = auth[0][len(prefix):]
@@ -74,12 +100,17 @@ func NewAppAPIAcceptor(ctx context.Context, logger runtime.Logger, db *sql.DB, n
 			token = r.URL.Query().Get("token")
 		}
 		if token == "" {
-			http.Error(w, authErrorBody, 401)
+			_ = RESTError(w, APIErrorMessage{Code: ErrCodeInvalidAuthenticationToken, Message: "Missing or invalid token"}, http.StatusUnauthorized)
 			return
 		}
-		userID, username, vars, expiry, _, _, ok := parseToken([]byte(signingKey), token)
-		if !ok || expiry < time.Now().Unix() {
-			http.Error(w, authErrorBody, 401)
+
+		app, claims, err := appAPI.authenticate(r.Context(), sessionKey, token)
+		if err != nil {
+			code, httpCode := appAPIErrorCodes(err)
+			if httpCode == http.StatusInternalServerError {
+				logger.WithField("error", err).Error("Failed to authenticate developer application.")
+			}
+			_ = RESTError(w, APIErrorMessage{Code: code, Message: "Missing or invalid token"}, httpCode)
 			return
 		}
 
@@ -89,31 +120,160 @@ func NewAppAPIAcceptor(ctx context.Context, logger runtime.Logger, db *sql.DB, n
 			clientIP = r.RemoteAddr
 			clientPort = ""
 		}
-		appID, ok := vars[DEVAPP_CTX_APP_ID]
-		if !ok {
-			http.Error(w, authErrorBody, 401)
-		}
-		ctx := NewDeveloperAppContext(r.Context(), node, "", env, r.Header, r.URL.Query(), userID.String(), username, vars, clientIP, clientPort, appID)
 
 		// Get the api path from the request
-		path := r.URL.Path
+		path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, AppAPIPathPrefix), "/")
+
+		// Record which application called what.
+		rw := &appAPIResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		defer func() {
+			appAPI.audit(app, claims, path, clientIP, rw.statusCode, time.Since(startTime))
+		}()
 
-		// Get the handler function for the api path
-		handlerFn, ok := appAPI.handlers[path]
+		route, ok := appAPI.routes[path]
 		if !ok {
-			http.Error(w, "Not Found", 404)
+			_ = RESTError(rw, APIErrorMessage{Code: ErrCodeGeneralError, Message: "Not Found"}, http.StatusNotFound)
 			return
 		}
 
+		// The token may be limited to a subset of the application's scopes.
+		if !app.HasScope(route.scope) || !claims.HasScope(route.scope) {
+			_ = RESTError(rw, APIErrorMessage{Code: ErrCodeMissingRequiredOAuth2Scope, Message: "Missing required scope: " + route.scope}, http.StatusForbidden)
+			return
+		}
+
+		if !appAPI.allow(app) {
+			rw.Header().Set("Retry-After", "60")
+			_ = RESTError(rw, APIErrorMessage{Code: ErrCodeGeneralError, Message: "Rate limit exceeded"}, http.StatusTooManyRequests)
+			return
+		}
+
+		vars := map[string]string{DEVAPP_CTX_APP_ID: app.ID.String()}
+		ctx := NewDeveloperAppContext(r.Context(), node, "", env, r.Header, r.URL.Query(), claims.UserID, "", vars, clientIP, clientPort, app.ID.String())
+
 		// Call the handler function
-		handlerFn(ctx, w, r)
+		route.handlerFn(ctx, rw, r)
 	}
 }
 
-// func(http.ResponseWriter, *http.Request) handler
-func (api *AppAPI) handlerFn(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+// authenticate returns the application and claims of the token, reading them from storage at most once per AppAPIAuthCacheTTL.
+func (api *AppAPI) authenticate(ctx context.Context, sessionKey, token string) (*DeveloperApplication, *ApplicationTokenClaims, error) {
+	now := time.Now()
 
-	// Respond to the request
-	w.Write([]byte("Hello, World!"))
+	if e, ok := api.authCache.Load(token); ok {
+		if now.Before(e.expiresAt) && now.Unix() < e.claims.ExpiresAt {
+			return e.app, e.claims, nil
+		}
+		api.authCache.Delete(token)
+	}
+
+	app, claims, err := ApplicationTokenAuthenticate(ctx, api.nk, sessionKey, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Remove the entries that have expired.
+	api.authCache.Range(func(k string, e appAPIAuthEntry) bool {
+		if !now.Before(e.expiresAt) {
+			api.authCache.Delete(k)
+		}
+		return true
+	})
+
+	api.authCache.Store(token, appAPIAuthEntry{app: app, claims: claims, expiresAt: now.Add(AppAPIAuthCacheTTL)})
+
+	return app, claims, nil
+}
+
+// allow reports whether the application is within its rate limit.
+func (api *AppAPI) allow(app *DeveloperApplication) bool {
+	limit := rate.Limit(float64(app.RequestsPerMinute()) / 60)
+	burst := max(1, app.RequestsPerMinute()/6)
+
+	limiter, _ := api.rateLimiters.LoadOrStore(app.ID.String(), rate.NewLimiter(limit, burst))
+
+	// The limit may have been changed by the application owner.
+	if limiter.Limit() != limit {
+		limiter.SetLimit(limit)
+		limiter.SetBurst(burst)
+	}
+
+	return limiter.Allow()
+}
+
+func (api *AppAPI) audit(app *DeveloperApplication, claims *ApplicationTokenClaims, path, clientIP string, statusCode int, latency time.Duration) {
+	tags := map[string]string{
+		"app_id": app.ID.String(),
+		"path":   path,
+		"status": http.StatusText(statusCode),
+	}
+	api.nk.MetricsCounterAdd("devapp_api_request_count", tags, 1)
+	api.nk.MetricsTimerRecord("devapp_api_request_latency", tags, latency)
+
+	api.logger.WithFields(map[string]any{
+		"app_id":      app.ID.String(),
+		"app_name":    app.Name,
+		"owner_id":    claims.UserID,
+		"token_id":    claims.TokenID,
+		"path":        path,
+		"client_ip":   clientIP,
+		"status_code": statusCode,
+		"latency_ms":  latency.Milliseconds(),
+	}).Info("Developer API request.")
+}
+
+// writeRPCResult writes the result of an RPC function as the response.
+func (api *AppAPI) writeRPCResult(w http.ResponseWriter, result string, err error) {
+	if err != nil {
+		code, httpCode := appAPIErrorCodes(err)
+		if httpCode == http.StatusInternalServerError {
+			api.logger.WithField("error", err).Error("Developer API request failed.")
+			_ = RESTError(w, APIErrorMessage{Code: code, Message: "Internal error"}, httpCode)
+			return
+		}
+		_ = RESTError(w, APIErrorMessage{Code: code, Message: err.Error()}, httpCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(result))
+}
+
+// appAPIErrorCodes returns the API error code and HTTP status code for the error.
+func appAPIErrorCodes(err error) (int, int) {
+	var grpcCode codes.Code
+
+	var runtimeErr *runtime.Error
+	if errors.As(err, &runtimeErr) {
+		grpcCode = codes.Code(runtimeErr.Code)
+	} else if s, ok := status.FromError(err); ok {
+		grpcCode = s.Code()
+	} else {
+		grpcCode = codes.Internal
+	}
+
+	switch grpcCode {
+	case codes.Unauthenticated:
+		return ErrCodeInvalidAuthenticationToken, http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return ErrCodeMissingAccess, http.StatusForbidden
+	case codes.NotFound:
+		return ErrCodeGeneralError, http.StatusNotFound
+	case codes.InvalidArgument:
+		return ErrCodeInvalidFormBody, http.StatusBadRequest
+	case codes.ResourceExhausted:
+		return ErrCodeGeneralError, http.StatusTooManyRequests
+	default:
+		return ErrCodeGeneralError, grpcgw.HTTPStatusFromCode(grpcCode)
+	}
+}
+
+// appAPIResponseWriter captures the status code of the response for auditing.
+type appAPIResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
 
+func (w *appAPIResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
 }
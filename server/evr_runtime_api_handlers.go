@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+type AppAPIMatchesResponse struct {
+	Timestamp string        `json:"timestamp"`
+	Matches   []*MatchLabel `json:"matches"`
+}
+
+// matchesHandler lists the public matches.
+func (api *AppAPI) matchesHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	qparts := []string{
+		"+label.lobby_type:public",
+	}
+
+	if mode := r.URL.Query().Get("mode"); mode != "" {
+		qparts = append(qparts, "+label.mode:"+Query.Escape(mode))
+	}
+
+	if groupID := r.URL.Query().Get("group_id"); groupID != "" {
+		qparts = append(qparts, "+label.group_id:"+Query.Escape(groupID))
+	}
+
+	minSize := 1
+	matches, err := api.nk.MatchList(ctx, 1000, true, "", &minSize, nil, strings.Join(qparts, " "))
+	if err != nil {
+		api.writeRPCResult(w, "", err)
+		return
+	}
+
+	response := AppAPIMatchesResponse{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Matches:   make([]*MatchLabel, 0, len(matches)),
+	}
+
+	for _, match := range matches {
+		label := &MatchLabel{}
+		if err := json.Unmarshal([]byte(match.GetLabel().GetValue()), label); err != nil {
+			api.logger.WithField("error", err).Warn("Failed to unmarshal match label.")
+			continue
+		}
+		if !label.IsPublic() {
+			continue
+		}
+		response.Matches = append(response.Matches, label.PublicView())
+	}
+
+	data, err := json.Marshal(response)
+	api.writeRPCResult(w, string(data), err)
+}
+
+// playerStatisticsHandler returns a player's statistics for a guild.
+func (api *AppAPI) playerStatisticsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	request := PlayerStatisticsRequest{
+		UserID:    q.Get("user_id"),
+		GroupID:   q.Get("group_id"),
+		GuildID:   q.Get("guild_id"),
+		DiscordID: q.Get("discord_id"),
+		Mode:      evr.ToSymbol(q.Get("mode")),
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		api.writeRPCResult(w, "", err)
+		return
+	}
+
+	result, err := PlayerStatisticsRPC(ctx, api.logger, api.db, api.nk, string(payload))
+	api.writeRPCResult(w, result, err)
+}
+
+// leaderboardRecordsHandler returns a page of leaderboard records.
+func (api *AppAPI) leaderboardRecordsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	request := LeaderboardRecordsListRequest{
+		LeaderboardID: q.Get("leaderboard_id"),
+		GuildID:       q.Get("guild_id"),
+		GroupID:       q.Get("group_id"),
+		Mode:          evr.ToSymbol(q.Get("game_mode")),
+		StatName:      q.Get("stat_name"),
+		ResetSchedule: evr.ResetSchedule(q.Get("reset_schedule")),
+		Cursor:        q.Get("cursor"),
+	}
+
+	if v := q.Get("from_rank"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			api.writeRPCResult(w, "", runtime.NewError("invalid from_rank", StatusInvalidArgument))
+			return
+		}
+		request.FromRank = n
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			api.writeRPCResult(w, "", runtime.NewError("invalid limit", StatusInvalidArgument))
+			return
+		}
+		request.Limit = n
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		api.writeRPCResult(w, "", err)
+		return
+	}
+
+	result, err := api.rpcHandler.LeaderboardRecordsListRPC(ctx, api.logger, api.db, api.nk, string(payload))
+	api.writeRPCResult(w, result, err)
+}
+
+type AppAPIGuildResponse struct {
+	GroupID     string `json:"group_id"`
+	GuildID     string `json:"guild_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AvatarURL   string `json:"avatar_url"`
+	MemberCount int    `json:"member_count"`
+}
+
+// guildHandler returns the public information of a guild.
+func (api *AppAPI) guildHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		groupID = r.URL.Query().Get("group_id")
+	)
+
+	if guildID := r.URL.Query().Get("guild_id"); groupID == "" && guildID != "" {
+		if groupID, err = GetGroupIDByGuildID(ctx, api.db, guildID); err != nil {
+			api.writeRPCResult(w, "", err)
+			return
+		}
+	}
+
+	if groupID == "" {
+		api.writeRPCResult(w, "", runtime.NewError("guild_id or group_id is required", StatusInvalidArgument))
+		return
+	}
+
+	gg, err := GuildGroupLoad(ctx, api.nk, groupID)
+	if err != nil {
+		api.writeRPCResult(w, "", runtime.NewError("guild group not found", StatusNotFound))
+		return
+	}
+
+	response := AppAPIGuildResponse{
+		GroupID:     gg.IDStr(),
+		GuildID:     gg.GuildID,
+		Name:        gg.Name(),
+		Description: gg.Description(),
+		AvatarURL:   gg.Group.GetAvatarUrl(),
+		MemberCount: gg.Size(),
+	}
+
+	data, err := json.Marshal(response)
+	api.writeRPCResult(w, string(data), err)
+}
+
+type AppAPIGuildAppealsResponse struct {
+	GroupID string               `json:"group_id"`
+	Appeals []*EnforcementAppeal `json:"appeals"`
+}
+
+// guildAppealsHandler lists a guild's unresolved enforcement appeals. The application owner must be an enforcer of the guild.
+func (api *AppAPI) guildAppealsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		groupID = r.URL.Query().Get("group_id")
+	)
+
+	if guildID := r.URL.Query().Get("guild_id"); groupID == "" && guildID != "" {
+		if groupID, err = GetGroupIDByGuildID(ctx, api.db, guildID); err != nil {
+			api.writeRPCResult(w, "", err)
+			return
+		}
+	}
+
+	if groupID == "" {
+		api.writeRPCResult(w, "", runtime.NewError("guild_id or group_id is required", StatusInvalidArgument))
+		return
+	}
+
+	gg, err := GuildGroupLoad(ctx, api.nk, groupID)
+	if err != nil {
+		api.writeRPCResult(w, "", runtime.NewError("guild group not found", StatusNotFound))
+		return
+	}
+
+	ownerID, _ := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !gg.IsEnforcer(ownerID) {
+		api.writeRPCResult(w, "", runtime.NewError("the application owner is not an enforcer of the guild", StatusPermissionDenied))
+		return
+	}
+
+	appeals, err := EnforcementAppealsList(ctx, api.nk, groupID, EnforcementAppealStateOpen, EnforcementAppealStateUnderReview)
+	if err != nil {
+		api.writeRPCResult(w, "", err)
+		return
+	}
+
+	data, err := json.Marshal(AppAPIGuildAppealsResponse{
+		GroupID: groupID,
+		Appeals: appeals,
+	})
+	api.writeRPCResult(w, string(data), err)
+}
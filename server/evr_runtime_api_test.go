@@ -0,0 +1,260 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeveloperApplicationTokenRoundTrip(t *testing.T) {
+	sessionKey := "test-signing-key"
+	ownerID := uuid.Must(uuid.NewV4())
+	app := DeveloperApplication{
+		ID:     uuid.Must(uuid.NewV4()),
+		Name:   "test",
+		Scopes: []string{DeveloperScopeMatchesRead, DeveloperScopeStatsRead},
+	}
+
+	keys := &DeveloperSigningKeys{}
+	token := app.RotateToken(ownerID.String(), keys.Current(sessionKey), []string{DeveloperScopeMatchesRead}, 0, time.Now())
+
+	secretFn := func(keyID string) ([]byte, bool) {
+		return keys.Secret(keyID, sessionKey, time.Now())
+	}
+
+	claims, ok := parseApplicationToken(secretFn, token)
+	if !ok {
+		t.Fatal("failed to parse application token")
+	}
+	if claims.ApplicationID != app.ID.String() {
+		t.Errorf("unexpected application ID: %s", claims.ApplicationID)
+	}
+	if claims.UserID != ownerID.String() {
+		t.Errorf("unexpected owner ID: %s", claims.UserID)
+	}
+	if claims.TokenID == "" || claims.TokenID != app.TokenID {
+		t.Errorf("unexpected token ID: %s", claims.TokenID)
+	}
+	if claims.ExpiresAt <= time.Now().Unix() {
+		t.Error("token is already expired")
+	}
+	if !claims.HasScope(DeveloperScopeMatchesRead) || claims.HasScope(DeveloperScopeStatsRead) {
+		t.Errorf("unexpected token scopes: %v", claims.Scopes)
+	}
+
+	if _, ok := parseApplicationToken(func(string) ([]byte, bool) { return []byte("wrong-key"), true }, token); ok {
+		t.Error("expected token signed with another key to be rejected")
+	}
+}
+
+func TestDeveloperApplicationTokenRotation(t *testing.T) {
+	now := time.Now()
+	ownerID := uuid.Must(uuid.NewV4()).String()
+	key := DeveloperSigningKey{Secret: "test-signing-key"}
+	app := DeveloperApplication{ID: uuid.Must(uuid.NewV4())}
+
+	first := app.RotateToken(ownerID, key, nil, time.Hour, now)
+	firstID := app.TokenID
+
+	second := app.RotateToken(ownerID, key, nil, time.Hour, now)
+	if first == second || firstID == app.TokenID {
+		t.Fatal("expected a new token")
+	}
+
+	if !app.IsTokenValid(app.TokenID, second, now) {
+		t.Error("expected the current token to be valid")
+	}
+	if !app.IsTokenValid(firstID, first, now.Add(30*time.Minute)) {
+		t.Error("expected the previous token to be valid during the grace period")
+	}
+	if app.IsTokenValid(firstID, first, now.Add(2*time.Hour)) {
+		t.Error("expected the previous token to be invalid after the grace period")
+	}
+
+	// Revoking the previous token ends its grace period.
+	if _, ok := app.RevokeToken(firstID); !ok {
+		t.Fatal("expected the previous token to be revoked")
+	}
+	if app.IsTokenValid(firstID, first, now) {
+		t.Error("expected the revoked token to be invalid")
+	}
+
+	// Revoking the current token leaves the application without a token.
+	currentID := app.TokenID
+	if _, ok := app.RevokeToken(currentID); !ok {
+		t.Fatal("expected the current token to be revoked")
+	}
+	if app.IsTokenValid(currentID, second, now) {
+		t.Error("expected the revoked token to be invalid")
+	}
+	if _, ok := app.RevokeToken(currentID); ok {
+		t.Error("expected the token to already be revoked")
+	}
+}
+
+func TestDeveloperApplicationLegacyTokenRotation(t *testing.T) {
+	now := time.Now()
+	ownerID := uuid.Must(uuid.NewV4()).String()
+	key := DeveloperSigningKey{Secret: "test-signing-key"}
+	app := DeveloperApplication{ID: uuid.Must(uuid.NewV4())}
+
+	// Tokens issued before token IDs were recorded only have the ID in their claims.
+	legacyID := uuid.Must(uuid.NewV4()).String()
+	app.Token, _ = generateDeveloperTokenWithExpiry("", key.Secret, app.ID.String(), legacyID, now.Unix(), ownerID, "", nil, nil, now.Add(time.Hour*24))
+
+	legacy := app.Token
+	if !app.IsTokenValid(legacyID, legacy, now) {
+		t.Fatal("expected the legacy token to be valid")
+	}
+
+	app.RotateToken(ownerID, key, nil, time.Hour, now)
+
+	if !app.IsTokenValid(legacyID, legacy, now.Add(30*time.Minute)) {
+		t.Error("expected the legacy token to be valid during the grace period")
+	}
+	if app.IsTokenValid(legacyID, legacy, now.Add(2*time.Hour)) {
+		t.Error("expected the legacy token to be invalid after the grace period")
+	}
+}
+
+func TestDeveloperTokenRevocations(t *testing.T) {
+	now := time.Now()
+	r := &DeveloperTokenRevocations{}
+
+	r.Revoke("expired", now.Add(-time.Minute), now)
+	r.Revoke("active", now.Add(time.Hour), now)
+
+	if !r.IsRevoked("active") {
+		t.Error("expected the token to be revoked")
+	}
+	if r.IsRevoked("other") {
+		t.Error("unexpected revoked token")
+	}
+
+	// Expired entries are pruned on the next revocation.
+	r.Revoke("another", now.Add(time.Hour), now)
+	if _, ok := r.Tokens["expired"]; ok {
+		t.Error("expected the expired entry to be pruned")
+	}
+}
+
+func TestDeveloperSigningKeyRotation(t *testing.T) {
+	now := time.Now()
+	sessionKey := "session-key"
+	keys := &DeveloperSigningKeys{}
+
+	if _, ok := keys.Secret("", sessionKey, now); !ok {
+		t.Fatal("expected the session key to be accepted before rotation")
+	}
+
+	first, err := keys.Rotate(time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Current(sessionKey).ID != first.ID {
+		t.Error("expected the new key to be current")
+	}
+
+	second, err := keys.Rotate(time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keyID := range []string{"", first.ID, second.ID} {
+		if _, ok := keys.Secret(keyID, sessionKey, now.Add(30*time.Minute)); !ok {
+			t.Errorf("expected key %q to be accepted during the grace period", keyID)
+		}
+	}
+	for _, keyID := range []string{"", first.ID} {
+		if _, ok := keys.Secret(keyID, sessionKey, now.Add(2*time.Hour)); ok {
+			t.Errorf("expected key %q to be rejected after the grace period", keyID)
+		}
+	}
+	if _, ok := keys.Secret(second.ID, sessionKey, now.Add(2*time.Hour)); !ok {
+		t.Error("expected the current key to be accepted")
+	}
+}
+
+func TestDeveloperApplicationScopes(t *testing.T) {
+	app := DeveloperApplication{
+		Scopes: []string{DeveloperScopeMatchesRead, DeveloperScopeStatsRead},
+	}
+
+	if !app.HasScope(DeveloperScopeMatchesRead) {
+		t.Errorf("expected %s scope", DeveloperScopeMatchesRead)
+	}
+	if app.HasScope(DeveloperScopeGuildsRead) {
+		t.Errorf("unexpected %s scope", DeveloperScopeGuildsRead)
+	}
+	if got := app.RequestsPerMinute(); got != DefaultDeveloperRateLimitPerMinute {
+		t.Errorf("RequestsPerMinute() = %d, want %d", got, DefaultDeveloperRateLimitPerMinute)
+	}
+
+	// Applications stored before scopes existed keep their read access.
+	apps := DeveloperApplications{}
+	if err := json.Unmarshal([]byte(`{"Applications":[{"id":"8f6a3c1e-0d4b-4f57-9a0e-2b6c1d7e9f10","name":"legacy"}]}`), &apps); err != nil {
+		t.Fatalf("failed to unmarshal applications: %v", err)
+	}
+	if len(apps.Applications) != 1 {
+		t.Fatalf("expected 1 application, got %d", len(apps.Applications))
+	}
+	legacy := apps.Applications[0]
+	if !legacy.HasScope(DeveloperScopeGuildsRead) {
+		t.Errorf("expected %s scope", DeveloperScopeGuildsRead)
+	}
+	if legacy.HasScope(DeveloperScopeGuildModerate) {
+		t.Errorf("unexpected %s scope", DeveloperScopeGuildModerate)
+	}
+
+	// An explicitly empty grant has no scopes.
+	if (DeveloperApplication{Scopes: []string{}}).HasScope(DeveloperScopeMatchesRead) {
+		t.Errorf("unexpected %s scope", DeveloperScopeMatchesRead)
+	}
+}
+
+func TestAppAPIRateLimit(t *testing.T) {
+	api := &AppAPI{rateLimiters: &MapOf[string, *rate.Limiter]{}}
+	app := &DeveloperApplication{
+		ID:                 uuid.Must(uuid.NewV4()),
+		RateLimitPerMinute: 12,
+	}
+
+	// The burst is a tenth of a minute's requests.
+	for i := 0; i < 2; i++ {
+		if !api.allow(app) {
+			t.Fatalf("request %d was rate limited", i)
+		}
+	}
+	if api.allow(app) {
+		t.Error("expected request to be rate limited")
+	}
+}
+
+func TestAppAPIErrorCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantHTTP int
+	}{
+		{"runtime not found", runtime.NewError("not found", StatusNotFound), http.StatusNotFound},
+		{"runtime invalid argument", runtime.NewError("bad", StatusInvalidArgument), http.StatusBadRequest},
+		{"runtime unauthenticated", runtime.NewError("bad token", StatusUnauthenticated), http.StatusUnauthorized},
+		{"grpc permission denied", status.Error(codes.PermissionDenied, "denied"), http.StatusForbidden},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := appAPIErrorCodes(tt.err); got != tt.wantHTTP {
+				t.Errorf("appAPIErrorCodes() = %d, want %d", got, tt.wantHTTP)
+			}
+		})
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"crypto"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -17,16 +20,18 @@ const (
 	StorageIndexDeveloperAppTokens = "developerApplicationTokens"
 )
 
-var _ = Storable(&DeveloperApplications{})
+var _ = IndexedVersionedStorable(&DeveloperApplications{})
 
 type DeveloperApplications struct {
 	Applications []DeveloperApplication `json:"Applications"`
+	version      string
 }
 
-func (DeveloperApplications) StorageMeta() StorageMeta {
+func (a DeveloperApplications) StorageMeta() StorageMeta {
 	return StorageMeta{
 		Collection: StorageCollectionDeveloper,
 		Key:        StorageKeyApplications,
+		Version:    a.version,
 	}
 }
 
@@ -35,39 +40,219 @@ func (DeveloperApplications) StorageIndex() *StorageIndexMeta {
 		Name:       StorageIndexDeveloperAppTokens,
 		Collection: StorageCollectionDeveloper,
 		Key:        StorageKeyApplications,
-		Fields:     []string{"value.applications.token"},
+		Fields:     []string{"value.Applications.token"},
 		MaxEntries: 10000,
 		IndexOnly:  true,
 	}
 }
 
+func (a *DeveloperApplications) SetStorageVersion(userID, version string) {
+	a.version = version
+}
+
+// Get returns the application with the given ID, or nil if it does not exist.
+func (a *DeveloperApplications) Get(applicationID uuid.UUID) *DeveloperApplication {
+	for i := range a.Applications {
+		if a.Applications[i].ID == applicationID {
+			return &a.Applications[i]
+		}
+	}
+	return nil
+}
+
+const (
+	DeveloperScopeMatchesRead      = "matches:read"
+	DeveloperScopeStatsRead        = "stats:read"
+	DeveloperScopeLeaderboardsRead = "leaderboards:read"
+	DeveloperScopeGuildsRead       = "guilds:read"
+	DeveloperScopeGuildModerate    = "guild:moderate"
+
+	DefaultDeveloperRateLimitPerMinute = 60
+
+	DeveloperApplicationTokenLifetime = time.Hour * 24 * 90 // Revoked tokens stay on the revocation list until they would have expired
+	DefaultDeveloperTokenGracePeriod  = time.Hour * 24
+	MaximumDeveloperTokenGracePeriod  = time.Hour * 24 * 7
+	MaximumDeveloperPreviousTokens    = 5
+)
+
+// DeveloperScopes are all of the scopes that may be granted to an application.
+var DeveloperScopes = []string{
+	DeveloperScopeMatchesRead,
+	DeveloperScopeStatsRead,
+	DeveloperScopeLeaderboardsRead,
+	DeveloperScopeGuildsRead,
+	DeveloperScopeGuildModerate,
+}
+
+// DeveloperDefaultScopes are granted to applications that were created before scopes were introduced.
+var DeveloperDefaultScopes = []string{
+	DeveloperScopeMatchesRead,
+	DeveloperScopeStatsRead,
+	DeveloperScopeLeaderboardsRead,
+	DeveloperScopeGuildsRead,
+}
+
 type DeveloperApplication struct {
-	ID            uuid.UUID `json:"id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	Token         string    `json:"token"`
-	TokenIssuedAt time.Time `json:"token_issued_at"`
+	ID                 uuid.UUID                   `json:"id"`
+	Name               string                      `json:"name"`
+	Description        string                      `json:"description"`
+	Token              string                      `json:"token"`
+	TokenID            string                      `json:"token_id,omitempty"`
+	TokenIssuedAt      time.Time                   `json:"token_issued_at"`
+	TokenScopes        []string                    `json:"token_scopes,omitempty"`          // The scopes of the current token (a subset of Scopes)
+	PreviousTokens     []DeveloperApplicationToken `json:"previous_tokens,omitempty"`       // Rotated tokens that are still within their grace period
+	Scopes             []string                    `json:"scopes"`                          // The API scopes granted to the application
+	RateLimitPerMinute int                         `json:"rate_limit_per_minute,omitempty"` // The number of requests allowed per minute (0 = default)
+}
+
+// DeveloperApplicationToken is a rotated token that remains valid until it expires.
+type DeveloperApplicationToken struct {
+	TokenID   string    `json:"token_id"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
-func NewDeveloperApplicationToken(app DeveloperApplication, signingKey string) string {
-	tokenID := uuid.Must(uuid.NewV4()).String()
+// GrantedScopes returns the scopes granted to the application. Applications that have never had scopes set have the default scopes.
+func (a DeveloperApplication) GrantedScopes() []string {
+	if a.Scopes == nil {
+		return DeveloperDefaultScopes
+	}
+	return a.Scopes
+}
+
+func (a DeveloperApplication) HasScope(scope string) bool {
+	return slices.Contains(a.GrantedScopes(), scope)
+}
+
+func (a DeveloperApplication) RequestsPerMinute() int {
+	if a.RateLimitPerMinute <= 0 {
+		return DefaultDeveloperRateLimitPerMinute
+	}
+	return a.RateLimitPerMinute
+}
+
+// IsTokenValid reports whether the token ID is the current token, or a rotated token within its grace period.
+func (a DeveloperApplication) IsTokenValid(tokenID, token string, now time.Time) bool {
+	if a.TokenID == "" {
+		// Tokens issued before token IDs were recorded are matched on the token itself.
+		return a.Token != "" && a.Token == token
+	}
+	if tokenID == a.TokenID {
+		return true
+	}
+	for _, t := range a.PreviousTokens {
+		if t.TokenID == tokenID && now.Before(t.ExpiresAt) {
+			return true
+		}
+	}
+	return false
+}
 
-	token, _ := generateDeveloperTokenWithExpiry(signingKey, app.ID.String(), tokenID, app.TokenIssuedAt.Unix(), "", "", nil, time.Now().Add(time.Hour*24*365*10))
+// RotateToken issues a new token to the application. The current token remains valid for the grace period.
+func (a *DeveloperApplication) RotateToken(ownerID string, key DeveloperSigningKey, scopes []string, grace time.Duration, now time.Time) string {
+	previous := make([]DeveloperApplicationToken, 0, len(a.PreviousTokens)+1)
+	for _, t := range a.PreviousTokens {
+		if now.Before(t.ExpiresAt) {
+			previous = append(previous, t)
+		}
+	}
+
+	currentID := a.TokenID
+	if currentID == "" && a.Token != "" {
+		// Tokens issued before token IDs were recorded still carry their ID in the claims.
+		claims := &ApplicationTokenClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(a.Token, claims); err == nil {
+			currentID = claims.TokenID
+		}
+	}
+
+	if currentID != "" && grace > 0 {
+		previous = append(previous, DeveloperApplicationToken{
+			TokenID:   currentID,
+			Scopes:    a.TokenScopes,
+			IssuedAt:  a.TokenIssuedAt,
+			ExpiresAt: now.Add(grace),
+		})
+	}
+
+	// Keep only the most recent tokens.
+	if len(previous) > MaximumDeveloperPreviousTokens {
+		previous = previous[len(previous)-MaximumDeveloperPreviousTokens:]
+	}
+
+	a.PreviousTokens = previous
+	a.TokenID = uuid.Must(uuid.NewV4()).String()
+	a.TokenIssuedAt = now
+	a.TokenScopes = scopes
+	a.Token = NewDeveloperApplicationToken(*a, ownerID, key)
+
+	return a.Token
+}
+
+// RevokeToken removes the token from the application, returning the time it would have expired.
+func (a *DeveloperApplication) RevokeToken(tokenID string) (time.Time, bool) {
+	if tokenID != "" && tokenID == a.TokenID {
+		expiresAt := a.TokenIssuedAt.Add(DeveloperApplicationTokenLifetime)
+		a.Token = ""
+		a.TokenID = ""
+		a.TokenScopes = nil
+		return expiresAt, true
+	}
+	for i, t := range a.PreviousTokens {
+		if t.TokenID == tokenID {
+			a.PreviousTokens = slices.Delete(a.PreviousTokens, i, i+1)
+			return t.ExpiresAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NewDeveloperApplicationToken signs the application's current token with the signing key.
+func NewDeveloperApplicationToken(app DeveloperApplication, ownerID string, key DeveloperSigningKey) string {
+	token, _ := generateDeveloperTokenWithExpiry(key.ID, key.Secret, app.ID.String(), app.TokenID, app.TokenIssuedAt.Unix(), ownerID, "", app.TokenScopes, nil, app.TokenIssuedAt.Add(DeveloperApplicationTokenLifetime))
 	return token
 }
 
-func ApplicationTokenAuthenticate(ctx context.Context, nk runtime.NakamaModule, token string) (string, error) {
-	idx := DeveloperApplications{}.StorageIndex()
-	query := fmt.Sprintf("+value.applications.token:%s", Query.Escape(token))
-	objs, _, err := nk.StorageIndexList(ctx, SystemUserID, idx.Name, query, 1, nil, "")
+// ApplicationTokenAuthenticate validates the token, and returns the application it was issued to, along with the token's claims.
+func ApplicationTokenAuthenticate(ctx context.Context, nk runtime.NakamaModule, sessionKey, token string) (*DeveloperApplication, *ApplicationTokenClaims, error) {
+	now := time.Now().UTC()
+
+	keys, err := LoadDeveloperSigningKeys(ctx, nk)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
-	if len(objs.Objects) == 0 {
-		return "", nil
+
+	claims, ok := parseApplicationToken(func(keyID string) ([]byte, bool) {
+		return keys.Secret(keyID, sessionKey, now)
+	}, token)
+	if !ok || claims.ExpiresAt < now.Unix() {
+		return nil, nil, runtime.NewError("invalid token", StatusUnauthenticated)
+	}
+
+	apps := &DeveloperApplications{}
+	if err := StorageRead(ctx, nk, claims.UserID, apps, false); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil, runtime.NewError("unknown application", StatusUnauthenticated)
+		}
+		return nil, nil, err
 	}
 
-	return objs.Objects[0].UserId, nil
+	// The token must be one that is currently issued to the application.
+	app := apps.Get(uuid.FromStringOrNil(claims.ApplicationID))
+	if app == nil || !app.IsTokenValid(claims.TokenID, token, now) {
+		return nil, nil, runtime.NewError("unknown application", StatusUnauthenticated)
+	}
+
+	revocations, err := LoadDeveloperTokenRevocations(ctx, nk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revocations.IsRevoked(claims.TokenID) {
+		return nil, nil, runtime.NewError("token has been revoked", StatusUnauthenticated)
+	}
+
+	return app, claims, nil
 }
 
 var _ = jwt.Claims(&ApplicationTokenClaims{})
@@ -77,6 +262,7 @@ type ApplicationTokenClaims struct {
 	UserID        string            `json:"uid,omitempty"`
 	ApplicationID string            `json:"aid,omitempty"`
 	Username      string            `json:"usn,omitempty"`
+	Scopes        []string          `json:"scp,omitempty"`
 	Vars          map[string]string `json:"vrs,omitempty"`
 	ExpiresAt     int64             `json:"exp,omitempty"`
 	IssuedAt      int64             `json:"iat,omitempty"`
@@ -106,78 +292,83 @@ func (stc *ApplicationTokenClaims) GetExpirationTime() (*jwt.NumericDate, error)
 	return jwt.NewNumericDate(time.Unix(stc.ExpiresAt, 0)), nil
 }
 
+// The optional claims return nil when they are not set, matching jwt.RegisteredClaims.
+
 func (stc *ApplicationTokenClaims) GetIssuedAt() (*jwt.NumericDate, error) {
 	if stc.IssuedAt == 0 {
-		return nil, jwt.ErrTokenUsedBeforeIssued
+		return nil, nil
 	}
 	return jwt.NewNumericDate(time.Unix(stc.IssuedAt, 0)), nil
 }
 
 func (stc *ApplicationTokenClaims) GetNotBefore() (*jwt.NumericDate, error) {
 	if stc.NotBefore == 0 {
-		return nil, jwt.ErrTokenNotValidYet
+		return nil, nil
 	}
 	return jwt.NewNumericDate(time.Unix(stc.NotBefore, 0)), nil
 }
 
 func (stc *ApplicationTokenClaims) GetIssuer() (string, error) {
-	if stc.Issuer == "" {
-		return "", jwt.ErrTokenInvalidIssuer
-	}
 	return stc.Issuer, nil
 }
 
 func (stc *ApplicationTokenClaims) GetSubject() (string, error) {
-	if stc.Subject == "" {
-		return "", jwt.ErrTokenInvalidSubject
-	}
 	return stc.Subject, nil
 }
 
 func (stc *ApplicationTokenClaims) GetAudience() (jwt.ClaimStrings, error) {
-	if len(stc.Audience) == 0 {
-		return nil, jwt.ErrTokenInvalidAudience
-	}
 	return stc.Audience, nil
 }
 
-func parseApplicationToken(hmacSecretByte []byte, tokenString string) (applicationID uuid.UUID, userID uuid.UUID, username string, vars map[string]string, exp int64, tokenID string, issuedAt int64, ok bool) {
+// HasScope reports whether the token grants the scope. Tokens issued without scopes have all of the application's scopes.
+func (stc *ApplicationTokenClaims) HasScope(scope string) bool {
+	return len(stc.Scopes) == 0 || slices.Contains(stc.Scopes, scope)
+}
+
+// parseApplicationToken verifies the token with the secret of the signing key named in its header.
+func parseApplicationToken(secretFn func(keyID string) ([]byte, bool), tokenString string) (*ApplicationTokenClaims, bool) {
 	jwtToken, err := jwt.ParseWithClaims(tokenString, &ApplicationTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if s, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || s.Hash != crypto.SHA256 {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return hmacSecretByte, nil
+		keyID, _ := token.Header["kid"].(string)
+		secret, ok := secretFn(keyID)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %s", keyID)
+		}
+		return secret, nil
 	})
 	if err != nil {
-		return
+		return nil, false
 	}
 	claims, ok := jwtToken.Claims.(*ApplicationTokenClaims)
 	if !ok || !jwtToken.Valid {
-		return
+		return nil, false
 	}
-	applicationID, err = uuid.FromString(claims.ApplicationID)
-	if err != nil {
-		return
+	if _, err := uuid.FromString(claims.ApplicationID); err != nil {
+		return nil, false
 	}
-
-	userID, err = uuid.FromString(claims.UserID)
-	if err != nil {
-		return
+	if _, err := uuid.FromString(claims.UserID); err != nil {
+		return nil, false
 	}
-	return applicationID, userID, claims.Username, claims.Vars, claims.ExpiresAt, claims.TokenID, claims.IssuedAt, true
+	return claims, true
 }
 
-func generateDeveloperTokenWithExpiry(signingKey, applicationID string, tokenID string, tokenIssuedAt int64, userID, username string, vars map[string]string, expiry time.Time) (string, int64) {
+func generateDeveloperTokenWithExpiry(keyID, signingKey, applicationID string, tokenID string, tokenIssuedAt int64, userID, username string, scopes []string, vars map[string]string, expiry time.Time) (string, int64) {
 	exp := expiry.UTC().Unix()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &ApplicationTokenClaims{
 		TokenID:       tokenID,
 		ApplicationID: applicationID,
 		UserID:        userID,
 		Username:      username,
+		Scopes:        scopes,
 		Vars:          vars,
 		ExpiresAt:     exp,
 		IssuedAt:      tokenIssuedAt,
 	})
+	if keyID != "" {
+		token.Header["kid"] = keyID
+	}
 	signedToken, _ := token.SignedString([]byte(signingKey))
 	return signedToken, exp
 }
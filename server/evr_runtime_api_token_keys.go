@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageKeyDeveloperSigningKeys = "signingKeys"
+)
+
+var _ = VersionedStorable(&DeveloperSigningKeys{})
+
+// DeveloperSigningKey is a secret used to sign developer application tokens.
+type DeveloperSigningKey struct {
+	ID        string    `json:"id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Set when the key is rotated out (zero while it is the current key)
+}
+
+// DeveloperSigningKeys is the set of keys used to sign developer application tokens.
+// Until the first rotation, tokens are signed with the session encryption key.
+type DeveloperSigningKeys struct {
+	Keys            []DeveloperSigningKey `json:"keys"`
+	LegacyExpiresAt time.Time             `json:"legacy_expires_at,omitempty"` // When tokens signed with the session encryption key stop being accepted
+	version         string
+}
+
+func (k DeveloperSigningKeys) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionDeveloper,
+		Key:             StorageKeyDeveloperSigningKeys,
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         k.version,
+	}
+}
+
+func (k *DeveloperSigningKeys) SetStorageVersion(userID, version string) {
+	k.version = version
+}
+
+// Current returns the key that new tokens are signed with. If no key has been created, the session key is returned.
+func (k *DeveloperSigningKeys) Current(sessionKey string) DeveloperSigningKey {
+	for i := len(k.Keys) - 1; i >= 0; i-- {
+		if k.Keys[i].ExpiresAt.IsZero() {
+			return k.Keys[i]
+		}
+	}
+	return DeveloperSigningKey{Secret: sessionKey}
+}
+
+// Secret returns the secret of the key, if it is the current key or a rotated key within its grace period.
+func (k *DeveloperSigningKeys) Secret(keyID, sessionKey string, now time.Time) ([]byte, bool) {
+	if keyID == "" {
+		if !k.LegacyExpiresAt.IsZero() && !now.Before(k.LegacyExpiresAt) {
+			return nil, false
+		}
+		return []byte(sessionKey), true
+	}
+	for _, key := range k.Keys {
+		if key.ID == keyID {
+			if !key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt) {
+				return nil, false
+			}
+			return []byte(key.Secret), true
+		}
+	}
+	return nil, false
+}
+
+// Rotate creates a new current key. Tokens signed with the previous key are accepted for the grace period.
+func (k *DeveloperSigningKeys) Rotate(grace time.Duration, now time.Time) (DeveloperSigningKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return DeveloperSigningKey{}, err
+	}
+
+	if k.LegacyExpiresAt.IsZero() {
+		k.LegacyExpiresAt = now.Add(grace)
+	}
+
+	keys := make([]DeveloperSigningKey, 0, len(k.Keys)+1)
+	for _, key := range k.Keys {
+		if key.ExpiresAt.IsZero() {
+			key.ExpiresAt = now.Add(grace)
+		}
+		// Drop the keys that have expired.
+		if now.Before(key.ExpiresAt) {
+			keys = append(keys, key)
+		}
+	}
+
+	key := DeveloperSigningKey{
+		ID:        uuid.Must(uuid.NewV4()).String(),
+		Secret:    base64.RawURLEncoding.EncodeToString(secret),
+		CreatedAt: now,
+	}
+	k.Keys = append(keys, key)
+
+	return key, nil
+}
+
+// LoadDeveloperSigningKeys reads the signing keys. An empty set is returned if none have been created.
+func LoadDeveloperSigningKeys(ctx context.Context, nk runtime.NakamaModule) (*DeveloperSigningKeys, error) {
+	keys := &DeveloperSigningKeys{}
+	if err := StorageRead(ctx, nk, SystemUserID, keys, false); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return keys, nil
+		}
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StorageKeyDeveloperTokenRevocations = "revokedTokens"
+)
+
+var _ = VersionedStorable(&DeveloperTokenRevocations{})
+
+// DeveloperTokenRevocations is the list of revoked developer application tokens.
+type DeveloperTokenRevocations struct {
+	Tokens  map[string]time.Time `json:"tokens"` // Token ID -> when the token would have expired
+	version string
+}
+
+func (r DeveloperTokenRevocations) StorageMeta() StorageMeta {
+	return StorageMeta{
+		Collection:      StorageCollectionDeveloper,
+		Key:             StorageKeyDeveloperTokenRevocations,
+		PermissionRead:  runtime.STORAGE_PERMISSION_NO_READ,
+		PermissionWrite: runtime.STORAGE_PERMISSION_NO_WRITE,
+		Version:         r.version,
+	}
+}
+
+func (r *DeveloperTokenRevocations) SetStorageVersion(userID, version string) {
+	r.version = version
+}
+
+func (r *DeveloperTokenRevocations) IsRevoked(tokenID string) bool {
+	_, ok := r.Tokens[tokenID]
+	return ok
+}
+
+// Revoke adds the token to the list, and removes the tokens that have since expired.
+func (r *DeveloperTokenRevocations) Revoke(tokenID string, expiresAt, now time.Time) {
+	if r.Tokens == nil {
+		r.Tokens = make(map[string]time.Time)
+	}
+	for id, exp := range r.Tokens {
+		if !now.Before(exp) {
+			delete(r.Tokens, id)
+		}
+	}
+	r.Tokens[tokenID] = expiresAt
+}
+
+// LoadDeveloperTokenRevocations reads the revocation list. An empty list is returned if none exists.
+func LoadDeveloperTokenRevocations(ctx context.Context, nk runtime.NakamaModule) (*DeveloperTokenRevocations, error) {
+	revocations := &DeveloperTokenRevocations{}
+	if err := StorageRead(ctx, nk, SystemUserID, revocations, false); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return revocations, nil
+		}
+		return nil, err
+	}
+	return revocations, nil
+}
+
+// RevokeDeveloperToken adds the token to the revocation list, retrying if the list is modified concurrently.
+func RevokeDeveloperToken(ctx context.Context, nk runtime.NakamaModule, tokenID string, expiresAt time.Time) error {
+	var err error
+	for range 3 {
+		var revocations *DeveloperTokenRevocations
+		if revocations, err = LoadDeveloperTokenRevocations(ctx, nk); err != nil {
+			return err
+		}
+		if revocations.version == "" {
+			revocations.version = "*" // Only create the list if it does not exist.
+		}
+
+		revocations.Revoke(tokenID, expiresAt, time.Now().UTC())
+
+		var data []byte
+		if data, err = json.Marshal(revocations); err != nil {
+			return err
+		}
+		meta := revocations.StorageMeta()
+
+		if _, err = nk.StorageWrite(ctx, []*runtime.StorageWrite{{
+			Collection:      meta.Collection,
+			Key:             meta.Key,
+			UserID:          SystemUserID,
+			Value:           string(data),
+			Version:         meta.Version,
+			PermissionRead:  meta.PermissionRead,
+			PermissionWrite: meta.PermissionWrite,
+		}}); err == nil {
+			return nil
+		} else if !errors.Is(err, runtime.ErrStorageRejectedVersion) {
+			return fmt.Errorf("failed to write the token revocation list: %w", err)
+		}
+	}
+	return errors.Join(errors.New("failed to update the token revocation list"), err)
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/heroiclabs/nakama-common/api"
 	"github.com/heroiclabs/nakama-common/runtime"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 const (
@@ -27,6 +26,7 @@ const (
 	EventMatchData              = "match_data"
 	matchDataDatabaseName       = "nevr"
 	matchDataCollectionName     = "match_data"
+	matchDataMaxPending         = 1000
 )
 
 type EventDispatch struct {
@@ -36,17 +36,21 @@ type EventDispatch struct {
 	logger runtime.Logger
 	nk     runtime.NakamaModule
 	db     *sql.DB
-	mongo  *mongo.Client
 	dg     *discordgo.Session
 
+	matchDataSink     MatchDataSink
+	matchDataFallback MatchDataSink
+	matchDataPending  []*MatchDataJournal
+
 	queue                chan *api.Event
+	matchHistoryQueue    chan []*MatchDataJournal
 	matchJournals        map[MatchID]*MatchDataJournal
 	cache                *sync.Map
 	playerAuthorizations map[string]map[string]struct{} // map[sessionID]map[groupID]struct{}
 	vrmlVerifier         *VRMLVerifier
 }
 
-func NewEventDispatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, initializer runtime.Initializer, mongoClient *mongo.Client, dg *discordgo.Session) (*EventDispatch, error) {
+func NewEventDispatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, initializer runtime.Initializer, matchDataSink, matchDataFallback MatchDataSink, dg *discordgo.Session) (*EventDispatch, error) {
 
 	vrmlVerifier, err := NewVRMLVerifier(ctx, logger, db, nk, initializer, dg)
 	if err != nil {
@@ -57,11 +61,14 @@ func NewEventDispatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk
 		ctx:    ctx,
 		logger: logger,
 		db:     db,
-		mongo:  mongoClient,
 		nk:     nk,
 		dg:     dg,
 
+		matchDataSink:     matchDataSink,
+		matchDataFallback: matchDataFallback,
+
 		queue:                make(chan *api.Event, 100),
+		matchHistoryQueue:    make(chan []*MatchDataJournal, 16),
 		matchJournals:        make(map[MatchID]*MatchDataJournal),
 		cache:                &sync.Map{},
 		vrmlVerifier:         vrmlVerifier,
@@ -73,13 +80,27 @@ func NewEventDispatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk
 			select {
 			case <-ctx.Done():
 				return
+			case journals := <-dispatch.matchHistoryQueue:
+				recordMatchHistory(ctx, logger, nk, journals)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				if dispatch.matchDataSink != nil {
+					_ = dispatch.matchDataSink.Close()
+				}
+				return
 			case evt := <-dispatch.queue:
 
 				dispatch.processEvent(ctx, logger, evt)
 
 			case <-time.After(30 * time.Second):
 
-				inserts := make([]any, 0, len(dispatch.matchJournals))
+				inserts := make([]*MatchDataJournal, 0, len(dispatch.matchJournals))
 
 				for k, v := range dispatch.matchJournals {
 					if time.Since(v.UpdatedAt) > 1*time.Minute {
@@ -87,16 +108,17 @@ func NewEventDispatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk
 						inserts = append(inserts, v)
 					}
 				}
-				if len(inserts) == 0 {
-					continue
-				}
-				if dispatch.mongo != nil {
-					// Push to mongo
-					collection := dispatch.mongo.Database(matchDataDatabaseName).Collection(matchDataCollectionName)
-					if _, err := collection.InsertMany(ctx, inserts); err != nil {
-						logger.Error("failed to insert match data: %v", err)
+
+				if len(inserts) > 0 {
+					// The match history is written by its own goroutine, so that storage I/O does not hold up the events.
+					select {
+					case dispatch.matchHistoryQueue <- inserts:
+					default:
+						logger.WithField("count", len(inserts)).Warn("Match history queue full, dropping match history.")
 					}
 				}
+
+				dispatch.flushMatchData(ctx, logger, inserts)
 			}
 		}
 	}()
@@ -105,6 +127,39 @@ func NewEventDispatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk
 
 }
 
+// flushMatchData writes the completed journals, along with any that previously failed, to the match data sink.
+func (h *EventDispatch) flushMatchData(ctx context.Context, logger runtime.Logger, journals []*MatchDataJournal) {
+	if h.matchDataSink == nil {
+		if len(journals) > 0 {
+			h.nk.MetricsCounterAdd("match_data_dropped_count", nil, int64(len(journals)))
+			logger.WithField("count", len(journals)).Warn("No match data sink is configured, dropping match data.")
+		}
+		return
+	}
+
+	journals = append(h.matchDataPending, journals...)
+	h.matchDataPending = nil
+	if len(journals) == 0 {
+		return
+	}
+
+	failed, err := writeMatchData(ctx, h.matchDataSink, h.matchDataFallback, journals)
+	if err != nil {
+		logger.WithFields(map[string]any{
+			"sink":     h.matchDataSink.Name(),
+			"count":    len(journals),
+			"retrying": len(failed),
+			"error":    err,
+		}).Error("Failed to write match data.")
+	}
+
+	if n := len(failed) - matchDataMaxPending; n > 0 {
+		logger.WithField("count", n).Error("Discarding match data, too many pending journals.")
+		failed = failed[n:]
+	}
+	h.matchDataPending = failed
+}
+
 func (h *EventDispatch) eventFn(ctx context.Context, logger runtime.Logger, evt *api.Event) {
 	logger.WithField("event", evt.Name).Debug("received event")
 	select {
@@ -426,6 +481,7 @@ func (h *EventDispatch) handleMatchEvent(ctx context.Context, logger runtime.Log
 
 	j.Events = append(j.Events, &MatchDataJournalEntry{
 		CreatedAt: time.Now().UTC(),
+		Type:      evt.Properties["type"],
 		Data:      data,
 	})
 	j.UpdatedAt = time.Now().UTC()
@@ -6,8 +6,18 @@ import (
 	"github.com/heroiclabs/nakama/v3/server/evr"
 )
 
+const (
+	MatchDataTypeStarted     = "started"
+	MatchDataTypePlayerJoin  = "player_join"
+	MatchDataTypePlayerLeave = "player_leave"
+	MatchDataTypeRemoteLogs  = "remote_logs"
+
+	MatchDataTypeStatisticsUpdate = "statistics_update"
+)
+
 type MatchDataJournalEntry struct {
 	CreatedAt time.Time `json:"created_at"`
+	Type      string    `json:"type,omitempty"`
 	Data      any       `json:"data"`
 }
 
@@ -27,6 +37,24 @@ func NewMatchDataJournal(matchID MatchID) *MatchDataJournal {
 	}
 }
 
+// MatchDataType returns the journal entry type of the match data.
+func MatchDataType(data any) string {
+	switch data.(type) {
+	case MatchDataStarted, *MatchDataStarted:
+		return MatchDataTypeStarted
+	case MatchDataPlayerJoin, *MatchDataPlayerJoin:
+		return MatchDataTypePlayerJoin
+	case MatchDataPlayerLeave, *MatchDataPlayerLeave:
+		return MatchDataTypePlayerLeave
+	case MatchDataRemoteLogSet, *MatchDataRemoteLogSet:
+		return MatchDataTypeRemoteLogs
+	case MatchDataStatisticsUpdate, *MatchDataStatisticsUpdate:
+		return MatchDataTypeStatisticsUpdate
+	default:
+		return ""
+	}
+}
+
 type MatchDataStarted struct {
 	State *MatchLabel `json:"state"`
 }
@@ -43,7 +71,19 @@ type MatchDataPlayerLeave struct {
 }
 
 type MatchDataRemoteLogSet struct {
-	UserID    string          `json:"sender_user_id"`
-	SessionID string          `json:"session_id"`
-	Logs      []evr.RemoteLog `json:"logs"`
+	UserID      string          `json:"sender_user_id"`
+	SessionID   string          `json:"session_id"`
+	BuildNumber evr.BuildNumber `json:"build_number,omitempty"` // The sender's client build
+	Logs        []evr.RemoteLog `json:"logs"`
+}
+
+// MatchDataStatisticsUpdate is a player's statistics update from the game server, with the statistics it was
+// applied to. It is kept so the player's leaderboard entries can be recomputed.
+type MatchDataStatisticsUpdate struct {
+	UserID      string         `json:"user_id"`
+	DisplayName string         `json:"display_name"`
+	GroupID     string         `json:"group_id"`
+	Mode        evr.Symbol     `json:"mode"`
+	Previous    evr.Statistics `json:"previous"`
+	Update      evr.Statistics `json:"update"`
 }
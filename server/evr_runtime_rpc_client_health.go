@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// ClientHealthRPC returns the client health report of every node, by build, with the regressions between builds. It is
+// only available to the global developers and operators.
+func ClientHealthRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+	if ok, err := CanViewClientHealth(ctx, db, callerID); err != nil {
+		return "", runtime.NewError("Error checking group membership", StatusInternalError)
+	} else if !ok {
+		return "", runtime.NewError("You must be a global developer or operator", StatusPermissionDenied)
+	}
+
+	filter := ClientHealthFilter{}
+	if err := parseRequest(ctx, payload, &filter); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	report, err := globalClientHealthRegistry.Report(ctx, filter)
+	if err != nil {
+		return "", runtime.NewError(err.Error(), StatusInternalError)
+	}
+	return report.String(), nil
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+type ConfigResourceRequest struct {
+	Type string `json:"type"`
+	ID   string `json:"id"` // Defaults to the type
+	ConfigResourceScope
+	Resource    map[string]interface{} `json:"resource"`     // For publishing
+	Notes       string                 `json:"notes"`        // For publishing
+	Version     int                    `json:"version"`      // For rolling back
+	FromVersion int                    `json:"from_version"` // For diffing
+	ToVersion   int                    `json:"to_version"`   // For diffing; defaults to the current revision
+}
+
+type ConfigResourceResponse struct {
+	Resource *ConfigResource        `json:"resource,omitempty"`
+	Changes  []ConfigResourceChange `json:"changes,omitempty"`
+}
+
+func (r ConfigResourceResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+type ConfigExperimentRequest struct {
+	Type    string   `json:"type"`
+	Buckets []string `json:"buckets"` // Empty to end the experiment
+}
+
+type ConfigExperimentResponse struct {
+	Experiment *ConfigExperiment `json:"experiment"`
+}
+
+func (r ConfigExperimentResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// checkGlobalOperator returns the caller's user ID, or a runtime error unless the caller is a global operator.
+func checkGlobalOperator(ctx context.Context, db *sql.DB) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+	if ok, err := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalOperators); err != nil {
+		return "", runtime.NewError("Error checking group membership", StatusInternalError)
+	} else if !ok {
+		return "", runtime.NewError("You must be a global operator", StatusPermissionDenied)
+	}
+	return callerID, nil
+}
+
+func parseConfigResourceRequest(ctx context.Context, db *sql.DB, payload string) (string, *ConfigResourceRequest, error) {
+	callerID, err := checkGlobalOperator(ctx, db)
+	if err != nil {
+		return "", nil, err
+	}
+
+	request := &ConfigResourceRequest{}
+	if err := parseRequest(ctx, payload, request); err != nil {
+		return "", nil, runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+	if request.Type == "" {
+		return "", nil, runtime.NewError("type is required", StatusInvalidArgument)
+	}
+	if request.ID == "" {
+		request.ID = request.Type
+	}
+	return callerID, request, nil
+}
+
+func configResourceError(err error) error {
+	switch {
+	case errors.Is(err, ErrConfigResourceInvalid):
+		return runtime.NewError(err.Error(), StatusInvalidArgument)
+	case errors.Is(err, ErrConfigResourceRevisionMissing):
+		return runtime.NewError(err.Error(), StatusNotFound)
+	}
+	return runtime.NewError(err.Error(), StatusInternalError)
+}
+
+// ConfigResourcePublishRPC validates and publishes a new revision of a config resource for a scope.
+func ConfigResourcePublishRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, request, err := parseConfigResourceRequest(ctx, db, payload)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := ConfigResourcePublish(ctx, nk, callerID, request.Type, request.ID, request.ConfigResourceScope, request.Resource, request.Notes)
+	if err != nil {
+		return "", configResourceError(err)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"publisher_id": callerID,
+		"key":          r.StorageMeta().Key,
+		"version":      r.Current().Version,
+	}).Info("Published config resource")
+
+	return ConfigResourceResponse{Resource: r}.String(), nil
+}
+
+// ConfigResourceHistoryRPC returns the revisions of a config resource for a scope.
+func ConfigResourceHistoryRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	_, request, err := parseConfigResourceRequest(ctx, db, payload)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := ConfigResourceLoad(ctx, nk, request.Type, request.ID, request.ConfigResourceScope)
+	if err != nil {
+		return "", configResourceError(err)
+	} else if r.Current() == nil {
+		return "", runtime.NewError("Config resource not found", StatusNotFound)
+	}
+
+	return ConfigResourceResponse{Resource: r}.String(), nil
+}
+
+// ConfigResourceDiffRPC returns the changes between two revisions of a config resource for a scope.
+func ConfigResourceDiffRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	_, request, err := parseConfigResourceRequest(ctx, db, payload)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := ConfigResourceLoad(ctx, nk, request.Type, request.ID, request.ConfigResourceScope)
+	if err != nil {
+		return "", configResourceError(err)
+	}
+
+	from := r.Revision(request.FromVersion)
+	to := r.Current()
+	if request.ToVersion != 0 {
+		to = r.Revision(request.ToVersion)
+	}
+	if from == nil || to == nil {
+		return "", runtime.NewError("Revision not found", StatusNotFound)
+	}
+
+	return ConfigResourceResponse{Changes: ConfigResourceDiff(from.Resource, to.Resource)}.String(), nil
+}
+
+// ConfigResourceRollbackRPC publishes a previous revision of a config resource as its new revision.
+func ConfigResourceRollbackRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, request, err := parseConfigResourceRequest(ctx, db, payload)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := ConfigResourceRollback(ctx, nk, callerID, request.Type, request.ID, request.ConfigResourceScope, request.Version)
+	if err != nil {
+		return "", configResourceError(err)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"publisher_id": callerID,
+		"key":          r.StorageMeta().Key,
+		"from_version": request.Version,
+		"version":      r.Current().Version,
+	}).Info("Rolled back config resource")
+
+	return ConfigResourceResponse{Resource: r}.String(), nil
+}
+
+// ConfigExperimentRPC sets the experiment buckets for a config type.
+func ConfigExperimentRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	if _, err := checkGlobalOperator(ctx, db); err != nil {
+		return "", err
+	}
+
+	request := ConfigExperimentRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+	if request.Type == "" {
+		return "", runtime.NewError("type is required", StatusInvalidArgument)
+	}
+
+	experiment, err := ConfigExperimentLoad(ctx, nk, request.Type)
+	if err != nil {
+		return "", runtime.NewError(err.Error(), StatusInternalError)
+	}
+	experiment.Buckets = request.Buckets
+	if _, err := StorageWrite(ctx, nk, SystemUserID, experiment); err != nil {
+		return "", runtime.NewError(err.Error(), StatusInternalError)
+	}
+	configResourceCache.Clear()
+
+	return ConfigExperimentResponse{Experiment: experiment}.String(), nil
+}
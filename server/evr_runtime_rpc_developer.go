@@ -0,0 +1,426 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeveloperApplicationView is an application as shown to its owner.
+type DeveloperApplicationView struct {
+	ID                 uuid.UUID                   `json:"id"`
+	Name               string                      `json:"name"`
+	Description        string                      `json:"description"`
+	Scopes             []string                    `json:"scopes"`
+	RateLimitPerMinute int                         `json:"rate_limit_per_minute"`
+	TokenID            string                      `json:"token_id,omitempty"`
+	TokenIssuedAt      time.Time                   `json:"token_issued_at"`
+	TokenScopes        []string                    `json:"token_scopes,omitempty"`
+	PreviousTokens     []DeveloperApplicationToken `json:"previous_tokens,omitempty"`
+}
+
+func NewDeveloperApplicationView(app DeveloperApplication) DeveloperApplicationView {
+	return DeveloperApplicationView{
+		ID:                 app.ID,
+		Name:               app.Name,
+		Description:        app.Description,
+		Scopes:             app.GrantedScopes(),
+		RateLimitPerMinute: app.RequestsPerMinute(),
+		TokenID:            app.TokenID,
+		TokenIssuedAt:      app.TokenIssuedAt,
+		TokenScopes:        app.TokenScopes,
+		PreviousTokens:     app.PreviousTokens,
+	}
+}
+
+type DeveloperApplicationsRPCRequest struct {
+	UserID string `json:"user_id"` // The owner of the applications (global developers only)
+}
+
+type DeveloperApplicationsRPCResponse struct {
+	Applications []DeveloperApplicationView `json:"applications"`
+}
+
+func (r DeveloperApplicationsRPCResponse) String() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// DeveloperApplicationsRPC lists the caller's developer applications. Tokens are only returned when they are issued.
+func DeveloperApplicationsRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := DeveloperApplicationsRPCRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	ownerID, err := developerApplicationsOwnerID(ctx, db, request.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	apps := &DeveloperApplications{}
+	if err := StorageRead(ctx, nk, ownerID, apps, false); err != nil && status.Code(err) != codes.NotFound {
+		return "", runtime.NewError(fmt.Sprintf("Error reading applications: %s", err.Error()), StatusInternalError)
+	}
+
+	response := DeveloperApplicationsRPCResponse{
+		Applications: make([]DeveloperApplicationView, 0, len(apps.Applications)),
+	}
+	for _, app := range apps.Applications {
+		response.Applications = append(response.Applications, NewDeveloperApplicationView(app))
+	}
+
+	return response.String(), nil
+}
+
+type DeveloperApplicationTokenRotateRPCRequest struct {
+	UserID             string    `json:"user_id"` // The owner of the application (global developers only)
+	ApplicationID      uuid.UUID `json:"application_id"`
+	Scopes             []string  `json:"scopes"`                         // The scopes of the new token (default: all of the application's scopes)
+	GracePeriodSeconds *int      `json:"grace_period_seconds,omitempty"` // How long the current token remains valid (default: 24 hours)
+}
+
+type DeveloperApplicationTokenRotateRPCResponse struct {
+	ApplicationID  uuid.UUID                   `json:"application_id"`
+	TokenID        string                      `json:"token_id"`
+	Token          string                      `json:"token"`
+	Scopes         []string                    `json:"scopes"`
+	PreviousTokens []DeveloperApplicationToken `json:"previous_tokens,omitempty"`
+}
+
+func (r DeveloperApplicationTokenRotateRPCResponse) String() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// DeveloperApplicationTokenRotateRPC issues a new token to an application. The current token remains valid for the grace period.
+func DeveloperApplicationTokenRotateRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := DeveloperApplicationTokenRotateRPCRequest{}
+	if err := json.Unmarshal([]byte(payload), &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	grace, err := developerGracePeriod(request.GracePeriodSeconds)
+	if err != nil {
+		return "", err
+	}
+
+	ownerID, err := developerApplicationsOwnerID(ctx, db, request.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	apps, app, err := loadDeveloperApplication(ctx, nk, ownerID, request.ApplicationID)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := request.Scopes
+	if len(scopes) == 0 {
+		scopes = slices.Clone(app.GrantedScopes())
+	}
+	for _, s := range scopes {
+		if !app.HasScope(s) {
+			return "", runtime.NewError(fmt.Sprintf("Scope not granted to the application: %s", s), StatusInvalidArgument)
+		}
+	}
+
+	keys, err := LoadDeveloperSigningKeys(ctx, nk)
+	if err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error reading signing keys: %s", err.Error()), StatusInternalError)
+	}
+	key := keys.Current(developerSessionKey(nk))
+
+	token := app.RotateToken(ownerID, key, scopes, grace, time.Now().UTC())
+
+	if _, err := StorageWrite(ctx, nk, ownerID, apps); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error writing applications: %s", err.Error()), StatusInternalError)
+	}
+
+	logger.WithFields(map[string]any{
+		"caller_id": ctx.Value(runtime.RUNTIME_CTX_USER_ID),
+		"owner_id":  ownerID,
+		"app_id":    app.ID.String(),
+		"token_id":  app.TokenID,
+		"scopes":    scopes,
+		"grace":     grace.String(),
+	}).Info("Developer application token rotated.")
+
+	response := DeveloperApplicationTokenRotateRPCResponse{
+		ApplicationID:  app.ID,
+		TokenID:        app.TokenID,
+		Token:          token,
+		Scopes:         scopes,
+		PreviousTokens: app.PreviousTokens,
+	}
+
+	return response.String(), nil
+}
+
+type DeveloperApplicationTokenRevokeRPCRequest struct {
+	UserID        string    `json:"user_id"` // The owner of the application (global developers only)
+	ApplicationID uuid.UUID `json:"application_id"`
+	TokenID       string    `json:"token_id"` // The token to revoke (default: the current token)
+}
+
+type DeveloperApplicationTokenRevokeRPCResponse struct {
+	ApplicationID uuid.UUID `json:"application_id"`
+	TokenID       string    `json:"token_id"`
+}
+
+func (r DeveloperApplicationTokenRevokeRPCResponse) String() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// DeveloperApplicationTokenRevokeRPC revokes one of an application's tokens immediately.
+func DeveloperApplicationTokenRevokeRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := DeveloperApplicationTokenRevokeRPCRequest{}
+	if err := json.Unmarshal([]byte(payload), &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	ownerID, err := developerApplicationsOwnerID(ctx, db, request.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	apps, app, err := loadDeveloperApplication(ctx, nk, ownerID, request.ApplicationID)
+	if err != nil {
+		return "", err
+	}
+
+	tokenID := request.TokenID
+	if tokenID == "" {
+		tokenID = app.TokenID
+	}
+
+	expiresAt, ok := app.RevokeToken(tokenID)
+	if !ok {
+		return "", runtime.NewError("Token not found", StatusNotFound)
+	}
+
+	if _, err := StorageWrite(ctx, nk, ownerID, apps); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error writing applications: %s", err.Error()), StatusInternalError)
+	}
+
+	if err := RevokeDeveloperToken(ctx, nk, tokenID, expiresAt); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error revoking token: %s", err.Error()), StatusInternalError)
+	}
+
+	logger.WithFields(map[string]any{
+		"caller_id": ctx.Value(runtime.RUNTIME_CTX_USER_ID),
+		"owner_id":  ownerID,
+		"app_id":    app.ID.String(),
+		"token_id":  tokenID,
+	}).Info("Developer application token revoked.")
+
+	response := DeveloperApplicationTokenRevokeRPCResponse{
+		ApplicationID: app.ID,
+		TokenID:       tokenID,
+	}
+
+	return response.String(), nil
+}
+
+type DeveloperApplicationScopesSetRPCRequest struct {
+	UserID        string    `json:"user_id"` // The owner of the application
+	ApplicationID uuid.UUID `json:"application_id"`
+	Scopes        []string  `json:"scopes"` // The scopes granted to the application (replaces the current scopes)
+}
+
+type DeveloperApplicationScopesSetRPCResponse struct {
+	ApplicationID uuid.UUID `json:"application_id"`
+	Scopes        []string  `json:"scopes"`
+}
+
+func (r DeveloperApplicationScopesSetRPCResponse) String() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// DeveloperApplicationScopesSetRPC sets the scopes granted to an application. Only global developers may grant scopes.
+func DeveloperApplicationScopesSetRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := DeveloperApplicationScopesSetRPCRequest{}
+	if err := json.Unmarshal([]byte(payload), &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	callerID, _ := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if isGlobalDeveloper, _ := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalDevelopers); !isGlobalDeveloper {
+		return "", runtime.NewError("You do not have permission to grant scopes", StatusPermissionDenied)
+	}
+
+	if request.UserID == "" {
+		return "", runtime.NewError("User ID is required", StatusInvalidArgument)
+	}
+
+	ownerID, err := developerApplicationsOwnerID(ctx, db, request.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := make([]string, 0, len(request.Scopes))
+	for _, s := range request.Scopes {
+		if !slices.Contains(DeveloperScopes, s) {
+			return "", runtime.NewError(fmt.Sprintf("Unknown scope: %s", s), StatusInvalidArgument)
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+
+	apps, app, err := loadDeveloperApplication(ctx, nk, ownerID, request.ApplicationID)
+	if err != nil {
+		return "", err
+	}
+
+	app.Scopes = scopes
+
+	if _, err := StorageWrite(ctx, nk, ownerID, apps); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error writing applications: %s", err.Error()), StatusInternalError)
+	}
+
+	logger.WithFields(map[string]any{
+		"caller_id": callerID,
+		"owner_id":  ownerID,
+		"app_id":    app.ID.String(),
+		"scopes":    scopes,
+	}).Info("Developer application scopes set.")
+
+	response := DeveloperApplicationScopesSetRPCResponse{
+		ApplicationID: app.ID,
+		Scopes:        scopes,
+	}
+
+	return response.String(), nil
+}
+
+type DeveloperSigningKeyRotateRPCRequest struct {
+	GracePeriodSeconds *int `json:"grace_period_seconds,omitempty"` // How long tokens signed with the current key remain valid (default: 24 hours)
+}
+
+type DeveloperSigningKeyRotateRPCResponse struct {
+	KeyID     string    `json:"key_id"`
+	CreatedAt time.Time `json:"created_at"`
+	GraceEnds time.Time `json:"grace_ends"`
+}
+
+func (r DeveloperSigningKeyRotateRPCResponse) String() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// DeveloperSigningKeyRotateRPC replaces the key used to sign application tokens.
+// Tokens signed with the previous key are accepted until the grace period ends; owners rotate their application tokens to have them signed with the new key.
+func DeveloperSigningKeyRotateRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := DeveloperSigningKeyRotateRPCRequest{}
+	if err := json.Unmarshal([]byte(payload), &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	callerID, _ := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if isGlobalDeveloper, _ := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalDevelopers); !isGlobalDeveloper {
+		return "", runtime.NewError("You do not have permission to rotate the signing key", StatusPermissionDenied)
+	}
+
+	grace, err := developerGracePeriod(request.GracePeriodSeconds)
+	if err != nil {
+		return "", err
+	}
+
+	keys, err := LoadDeveloperSigningKeys(ctx, nk)
+	if err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error reading signing keys: %s", err.Error()), StatusInternalError)
+	}
+	if keys.version == "" {
+		keys.version = "*" // Only create the keys if they do not exist.
+	}
+
+	now := time.Now().UTC()
+	key, err := keys.Rotate(grace, now)
+	if err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error generating signing key: %s", err.Error()), StatusInternalError)
+	}
+
+	if _, err := StorageWrite(ctx, nk, SystemUserID, keys); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error writing signing keys: %s", err.Error()), StatusInternalError)
+	}
+
+	logger.WithFields(map[string]any{
+		"caller_id": callerID,
+		"key_id":    key.ID,
+		"grace":     grace.String(),
+	}).Info("Developer signing key rotated.")
+
+	response := DeveloperSigningKeyRotateRPCResponse{
+		KeyID:     key.ID,
+		CreatedAt: key.CreatedAt,
+		GraceEnds: now.Add(grace),
+	}
+
+	return response.String(), nil
+}
+
+// developerApplicationsOwnerID returns the caller's user ID, or the requested user ID if the caller is a global developer.
+func developerApplicationsOwnerID(ctx context.Context, db *sql.DB, userID string) (string, error) {
+	callerID, _ := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if callerID == "" {
+		return "", runtime.NewError("Unauthenticated", StatusUnauthenticated)
+	}
+
+	if userID == "" || userID == callerID {
+		return callerID, nil
+	}
+
+	if isGlobalDeveloper, _ := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalDevelopers); !isGlobalDeveloper {
+		return "", runtime.NewError("You do not have permission to manage another user's applications", StatusPermissionDenied)
+	}
+
+	if _, err := uuid.FromString(userID); err != nil {
+		return "", runtime.NewError("Invalid user ID", StatusInvalidArgument)
+	}
+
+	return userID, nil
+}
+
+// loadDeveloperApplication reads the owner's applications, and returns them along with the requested application.
+func loadDeveloperApplication(ctx context.Context, nk runtime.NakamaModule, ownerID string, applicationID uuid.UUID) (*DeveloperApplications, *DeveloperApplication, error) {
+	apps := &DeveloperApplications{}
+	if err := StorageRead(ctx, nk, ownerID, apps, false); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil, runtime.NewError("Application not found", StatusNotFound)
+		}
+		return nil, nil, runtime.NewError(fmt.Sprintf("Error reading applications: %s", err.Error()), StatusInternalError)
+	}
+
+	app := apps.Get(applicationID)
+	if app == nil {
+		return nil, nil, runtime.NewError("Application not found", StatusNotFound)
+	}
+
+	return apps, app, nil
+}
+
+func developerGracePeriod(seconds *int) (time.Duration, error) {
+	if seconds == nil {
+		return DefaultDeveloperTokenGracePeriod, nil
+	}
+	grace := time.Duration(*seconds) * time.Second
+	if grace < 0 || grace > MaximumDeveloperTokenGracePeriod {
+		return 0, runtime.NewError(fmt.Sprintf("Grace period must be between 0 and %d seconds", int(MaximumDeveloperTokenGracePeriod.Seconds())), StatusInvalidArgument)
+	}
+	return grace, nil
+}
+
+func developerSessionKey(nk runtime.NakamaModule) string {
+	return nk.(*RuntimeGoNakamaModule).config.GetSession().EncryptionKey
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+type DocumentPublishRequest struct {
+	Type     string `json:"type"`
+	Language string `json:"lang"`
+	GroupID  string `json:"group_id"` // Only the community values may be published by a guild
+	Text     string `json:"text"`
+	Link     string `json:"link"`
+}
+
+type DocumentPublishResponse struct {
+	Document *RegistryDocument `json:"document"`
+}
+
+func (r DocumentPublishResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DocumentPublishRPC publishes a new version of a document. Global documents may only be published by global
+// operators; a guild's community values may also be published by its enforcers.
+func DocumentPublishRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	request := DocumentPublishRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	if !slices.Contains(DocumentTypes, request.Type) {
+		return "", runtime.NewError(fmt.Sprintf("type must be one of %v", DocumentTypes), StatusInvalidArgument)
+	}
+	if request.Text == "" {
+		return "", runtime.NewError("text is required", StatusInvalidArgument)
+	}
+	if request.Language == "" {
+		request.Language = DocumentDefaultLanguage
+	}
+
+	if request.GroupID == "" {
+		if ok, err := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalOperators); err != nil {
+			return "", runtime.NewError("Error checking group membership", StatusInternalError)
+		} else if !ok {
+			return "", runtime.NewError("You must be a global operator", StatusPermissionDenied)
+		}
+	} else {
+		if request.Type != DocumentTypeCommunityValues {
+			return "", runtime.NewError("Only the community values may be published by a guild", StatusInvalidArgument)
+		}
+		if err := checkGuildEnforcer(ctx, db, nk, callerID, request.GroupID); err != nil {
+			return "", err
+		}
+	}
+
+	document := &RegistryDocument{
+		Type:     request.Type,
+		Language: request.Language,
+		GroupID:  request.GroupID,
+		Text:     request.Text,
+		Link:     request.Link,
+	}
+	if err := DocumentPublish(ctx, nk, callerID, document); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error publishing document: %s", err.Error()), StatusInternalError)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"publisher_id": callerID,
+		"type":         document.Type,
+		"lang":         document.Language,
+		"group_id":     document.GroupID,
+		"version":      document.Version,
+	}).Info("Published document")
+
+	return DocumentPublishResponse{Document: document}.String(), nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+type EarlyQuitStatusRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type EarlyQuitStatusResponse struct {
+	UserID                 string                   `json:"user_id"`
+	EnableEarlyQuitPenalty bool                     `json:"enable_early_quit_penalty"`
+	Statuses               []EarlyQuitPenaltyStatus `json:"statuses"`
+}
+
+func (r EarlyQuitStatusResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// EarlyQuitStatusRPC returns the caller's early quit penalty status. Global operators may request the status of any player.
+func EarlyQuitStatusRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	request := EarlyQuitStatusRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	userID := callerID
+	if request.UserID != "" && request.UserID != callerID {
+		if ok, err := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalOperators); err != nil {
+			return "", runtime.NewError("Error checking group membership", StatusInternalError)
+		} else if !ok {
+			return "", runtime.NewError("You do not have permission to view this player's status", StatusPermissionDenied)
+		}
+		userID = request.UserID
+	}
+
+	statuses, err := EarlyQuitPenaltyStatuses(ctx, nk, userID)
+	if err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error loading early quit statistics: %s", err.Error()), StatusInternalError)
+	}
+
+	response := EarlyQuitStatusResponse{
+		UserID:   userID,
+		Statuses: statuses,
+	}
+	if settings := ServiceSettings(); settings != nil {
+		response.EnableEarlyQuitPenalty = settings.Matchmaking.EnableEarlyQuitPenalty
+	}
+
+	return response.String(), nil
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type EnforcementAppealFileRequest struct {
+	GroupID  string `json:"group_id"`
+	RecordID string `json:"record_id"`
+	Reason   string `json:"reason"`
+}
+
+type EnforcementAppealsListRequest struct {
+	GroupID string                   `json:"group_id"`
+	States  []EnforcementAppealState `json:"states"` // Defaults to the unresolved appeals
+}
+
+type EnforcementAppealsListResponse struct {
+	Appeals []*EnforcementAppeal `json:"appeals"`
+}
+
+func (r EnforcementAppealsListResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+type EnforcementAppealReviewRequest struct {
+	UserID        string                 `json:"user_id"`
+	RecordID      string                 `json:"record_id"`
+	State         EnforcementAppealState `json:"state"`
+	Notes         string                 `json:"notes"`
+	ReducedExpiry time.Time              `json:"reduced_expiry"` // Required when the state is reduced
+}
+
+type EnforcementAuditTrailRequest struct {
+	UserID   string `json:"user_id"`
+	RecordID string `json:"record_id"`
+}
+
+type EnforcementAppealResponse struct {
+	Appeal     *EnforcementAppeal     `json:"appeal"`
+	AuditTrail *EnforcementAuditTrail `json:"audit_trail,omitempty"`
+}
+
+func (r EnforcementAppealResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// checkGuildEnforcer returns a runtime error unless the user is an enforcer of the guild, or a global operator.
+func checkGuildEnforcer(ctx context.Context, db *sql.DB, nk runtime.NakamaModule, userID, groupID string) error {
+	if ok, err := CheckSystemGroupMembership(ctx, db, userID, GroupGlobalOperators); err != nil {
+		return runtime.NewError("Error checking group membership", StatusInternalError)
+	} else if ok {
+		return nil
+	}
+
+	gg, err := GuildGroupLoad(ctx, nk, groupID)
+	if err != nil {
+		return runtime.NewError("Guild group not found", StatusNotFound)
+	}
+	if !gg.IsEnforcer(userID) {
+		return runtime.NewError("You must be a guild enforcer", StatusPermissionDenied)
+	}
+	return nil
+}
+
+// guildAuditMessage sends the message to the guild's audit channel, if it has one.
+func (h *RPCHandler) guildAuditMessage(ctx context.Context, nk runtime.NakamaModule, groupID, message string) error {
+	if h.dg == nil {
+		return nil
+	}
+	gg, err := GuildGroupLoad(ctx, nk, groupID)
+	if err != nil {
+		return err
+	}
+	if gg.AuditChannelID == "" {
+		return nil
+	}
+	_, err = h.dg.ChannelMessageSendComplex(gg.AuditChannelID, &discordgo.MessageSend{
+		Content:         message,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	return err
+}
+
+func enforcementAppealError(err error) error {
+	switch {
+	case errors.Is(err, ErrEnforcementAppealExists):
+		return runtime.NewError(err.Error(), StatusAlreadyExists)
+	case errors.Is(err, ErrEnforcementAppealInvalidTransition):
+		return runtime.NewError(err.Error(), StatusFailedPrecondition)
+	}
+	switch status.Code(err) {
+	case codes.NotFound:
+		return runtime.NewError("Not found", StatusNotFound)
+	case codes.InvalidArgument:
+		return runtime.NewError(status.Convert(err).Message(), StatusInvalidArgument)
+	case codes.FailedPrecondition:
+		return runtime.NewError(status.Convert(err).Message(), StatusFailedPrecondition)
+	}
+	return runtime.NewError(err.Error(), StatusInternalError)
+}
+
+// EnforcementAppealFileRPC files an appeal of one of the caller's enforcement records.
+func (h *RPCHandler) EnforcementAppealFileRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	userID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || userID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	request := EnforcementAppealFileRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	if request.GroupID == "" || request.RecordID == "" {
+		return "", runtime.NewError("group_id and record_id are required", StatusInvalidArgument)
+	}
+	if request.Reason == "" || len(request.Reason) > 1000 {
+		return "", runtime.NewError("reason must be between 1 and 1000 characters", StatusInvalidArgument)
+	}
+
+	appeal, err := EnforcementAppealFile(ctx, nk, userID, request.GroupID, request.RecordID, request.Reason)
+	if err != nil {
+		return "", enforcementAppealError(err)
+	}
+
+	if err := h.guildAuditMessage(ctx, nk, request.GroupID, fmt.Sprintf("User `%s` appealed enforcement record `%s`: %s", userID, request.RecordID, request.Reason)); err != nil {
+		logger.WithField("error", err).Warn("Failed to send audit message")
+	}
+
+	return EnforcementAppealResponse{Appeal: appeal}.String(), nil
+}
+
+// EnforcementAppealsListRPC lists the guild's appeals for its enforcers.
+func EnforcementAppealsListRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	request := EnforcementAppealsListRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	if request.GroupID == "" {
+		return "", runtime.NewError("group_id is required", StatusInvalidArgument)
+	}
+
+	if err := checkGuildEnforcer(ctx, db, nk, callerID, request.GroupID); err != nil {
+		return "", err
+	}
+
+	if len(request.States) == 0 {
+		request.States = []EnforcementAppealState{EnforcementAppealStateOpen, EnforcementAppealStateUnderReview}
+	}
+
+	appeals, err := EnforcementAppealsList(ctx, nk, request.GroupID, request.States...)
+	if err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error listing appeals: %s", err.Error()), StatusInternalError)
+	}
+
+	return EnforcementAppealsListResponse{Appeals: appeals}.String(), nil
+}
+
+// EnforcementAppealReviewRPC moves an appeal to a new state, applying the outcome to the record.
+func (h *RPCHandler) EnforcementAppealReviewRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	request := EnforcementAppealReviewRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	if request.UserID == "" || request.RecordID == "" {
+		return "", runtime.NewError("user_id and record_id are required", StatusInvalidArgument)
+	}
+
+	appeal, err := EnforcementAppealLoad(ctx, nk, request.UserID, request.RecordID)
+	if err != nil {
+		return "", enforcementAppealError(err)
+	}
+
+	if err := checkGuildEnforcer(ctx, db, nk, callerID, appeal.GroupID); err != nil {
+		return "", err
+	}
+
+	if appeal, err = EnforcementAppealTransition(ctx, logger, nk, callerID, request.UserID, request.RecordID, request.State, request.Notes, request.ReducedExpiry); err != nil {
+		return "", enforcementAppealError(err)
+	}
+
+	if err := h.guildAuditMessage(ctx, nk, appeal.GroupID, fmt.Sprintf("User `%s` marked the appeal of user `%s` for record `%s` as %s.", callerID, request.UserID, request.RecordID, appeal.State.String())); err != nil {
+		logger.WithField("error", err).Warn("Failed to send audit message")
+	}
+
+	return EnforcementAppealResponse{Appeal: appeal}.String(), nil
+}
+
+// EnforcementAuditTrailRPC returns the appeal and audit trail of an enforcement record.
+func EnforcementAuditTrailRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	request := EnforcementAuditTrailRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	if request.UserID == "" || request.RecordID == "" {
+		return "", runtime.NewError("user_id and record_id are required", StatusInvalidArgument)
+	}
+
+	trail, err := EnforcementAuditTrailLoad(ctx, nk, request.UserID, request.RecordID)
+	if err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error loading audit trail: %s", err.Error()), StatusInternalError)
+	} else if trail.GroupID == "" {
+		return "", runtime.NewError("Audit trail not found", StatusNotFound)
+	}
+
+	if err := checkGuildEnforcer(ctx, db, nk, callerID, trail.GroupID); err != nil {
+		return "", err
+	}
+
+	response := EnforcementAppealResponse{AuditTrail: trail}
+	if appeal, err := EnforcementAppealLoad(ctx, nk, request.UserID, request.RecordID); err == nil {
+		response.Appeal = appeal
+	}
+
+	return response.String(), nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+type GameServerListRequest struct {
+	OperatorID string `json:"operator_id"` // Defaults to the caller; only global operators may list other operators' servers
+}
+
+type GameServerListResponse struct {
+	GameServers []*GameServerDashboardEntry `json:"game_servers"`
+}
+
+func (r GameServerListResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+type GameServerActionRequest struct {
+	SessionID string           `json:"session_id"`
+	Action    GameServerAction `json:"action"`
+}
+
+type GameServerActionResponse struct {
+	Success bool `json:"success"`
+}
+
+func (r GameServerActionResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// checkGameServerOperator returns the caller's user ID, or a runtime error unless the caller is the operator or a global operator.
+func checkGameServerOperator(ctx context.Context, db *sql.DB, operatorID string) (string, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+	if callerID == operatorID {
+		return callerID, nil
+	}
+	if ok, err := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalOperators); err != nil {
+		return "", runtime.NewError("Error checking group membership", StatusInternalError)
+	} else if !ok {
+		return "", runtime.NewError("You are not the operator of this game server", StatusPermissionDenied)
+	}
+	return callerID, nil
+}
+
+// GameServerListRPC returns the operator's game servers, with their current match and health.
+func GameServerListRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := GameServerListRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+	if request.OperatorID == "" {
+		request.OperatorID, _ = ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	}
+	if _, err := checkGameServerOperator(ctx, db, request.OperatorID); err != nil {
+		return "", err
+	}
+
+	entries, err := GameServerDashboardList(ctx, nk, request.OperatorID)
+	if err != nil {
+		return "", runtime.NewError(err.Error(), StatusInternalError)
+	}
+
+	return GameServerListResponse{GameServers: entries}.String(), nil
+}
+
+// GameServerActionRPC drains, undrains or restarts the parking match of a game server.
+func GameServerActionRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := GameServerActionRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+	sessionID := uuid.FromStringOrNil(request.SessionID)
+	if sessionID.IsNil() {
+		return "", runtime.NewError("A valid session_id is required", StatusInvalidArgument)
+	}
+
+	_, presence, err := GameServerBySessionID(nk, sessionID)
+	if err != nil {
+		if errors.Is(err, ErrGameServerPresenceNotFound) {
+			return "", runtime.NewError(err.Error(), StatusNotFound)
+		}
+		return "", runtime.NewError(err.Error(), StatusInternalError)
+	}
+
+	callerID, err := checkGameServerOperator(ctx, db, presence.GetUserId())
+	if err != nil {
+		return "", err
+	}
+
+	if err := GameServerActionApply(ctx, nk, sessionID, request.Action); err != nil {
+		return "", gameServerActionError(err)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"caller_id":   callerID,
+		"operator_id": presence.GetUserId(),
+		"sid":         sessionID.String(),
+		"action":      request.Action,
+	}).Info("Applied game server action")
+
+	return GameServerActionResponse{Success: true}.String(), nil
+}
+
+func gameServerActionError(err error) error {
+	switch {
+	case errors.Is(err, ErrGameServerInvalidAction):
+		return runtime.NewError(err.Error(), StatusInvalidArgument)
+	case errors.Is(err, ErrGameServerPresenceNotFound):
+		return runtime.NewError(err.Error(), StatusNotFound)
+	case errors.Is(err, ErrGameServerInMatch), errors.Is(err, ErrGameServerDraining):
+		return runtime.NewError(err.Error(), StatusFailedPrecondition)
+	}
+	return runtime.NewError(err.Error(), StatusInternalError)
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type IAPLedgerRequest struct {
+	EvrID      string `json:"evr_id"`
+	SKU        string `json:"sku"`
+	EchoPoints int64  `json:"echo_points"`
+	Reason     string `json:"reason"`
+}
+
+type IAPPurchaseRequest struct {
+	EvrID string `json:"evr_id"`
+	SKU   string `json:"sku"`
+}
+
+type IAPEntitlementsRequest struct {
+	EvrID string `json:"evr_id"`
+}
+
+type IAPEntitlementsResponse struct {
+	UserID       string           `json:"user_id"`
+	Entitlements *IAPEntitlements `json:"entitlements"`
+	Ledger       *IAPLedger       `json:"ledger,omitempty"`
+}
+
+func (r IAPEntitlementsResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// iapOperatorTarget checks that the caller is a global operator, and returns the caller and the user ID of the EVR ID.
+func iapOperatorTarget(ctx context.Context, db *sql.DB, evrIDStr string) (string, string, evr.EvrId, error) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", "", evr.EvrId{}, runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	if ok, err := CheckSystemGroupMembership(ctx, db, callerID, GroupGlobalOperators); err != nil {
+		return "", "", evr.EvrId{}, runtime.NewError("Error checking group membership", StatusInternalError)
+	} else if !ok {
+		return "", "", evr.EvrId{}, runtime.NewError("You must be a global operator", StatusPermissionDenied)
+	}
+
+	evrID, err := evr.ParseEvrId(evrIDStr)
+	if err != nil || !evrID.Valid() {
+		return "", "", evr.EvrId{}, runtime.NewError("A valid evr_id is required", StatusInvalidArgument)
+	}
+
+	userID, err := GetUserIDByDeviceID(ctx, db, evrID.String())
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return "", "", evr.EvrId{}, runtime.NewError("Account not found", StatusNotFound)
+		}
+		return "", "", evr.EvrId{}, runtime.NewError(err.Error(), StatusInternalError)
+	}
+
+	return callerID, userID, *evrID, nil
+}
+
+func iapLedgerError(err error) error {
+	switch {
+	case errors.Is(err, ErrIAPSKUOwned), errors.Is(err, ErrIAPSKUNotOwned), errors.Is(err, ErrIAPInsufficientBalance):
+		return runtime.NewError(err.Error(), StatusFailedPrecondition)
+	case errors.Is(err, ErrIAPEmptyEntry), errors.Is(err, ErrIAPInvalidAction):
+		return runtime.NewError(err.Error(), StatusInvalidArgument)
+	}
+	if status.Code(err) == codes.InvalidArgument {
+		return runtime.NewError(status.Convert(err).Message(), StatusInvalidArgument)
+	}
+	return runtime.NewError(err.Error(), StatusInternalError)
+}
+
+// IAPLedgerRPCFactory returns an RPC that grants or revokes a SKU and/or echo points for the user of an EVR ID.
+func IAPLedgerRPCFactory(action IAPLedgerAction) func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		request := IAPLedgerRequest{}
+		if err := parseRequest(ctx, payload, &request); err != nil {
+			return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+		}
+
+		callerID, userID, evrID, err := iapOperatorTarget(ctx, db, request.EvrID)
+		if err != nil {
+			return "", err
+		}
+
+		if request.Reason == "" {
+			return "", runtime.NewError("A reason is required", StatusInvalidArgument)
+		}
+
+		entitlements, err := IAPLedgerApply(ctx, nk, userID, IAPLedgerEntry{
+			Time:        time.Now().UTC(),
+			Action:      action,
+			EvrID:       evrID,
+			SKU:         request.SKU,
+			EchoPoints:  request.EchoPoints,
+			ActorUserID: callerID,
+			Reason:      request.Reason,
+		})
+		if err != nil {
+			return "", iapLedgerError(err)
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"actor_id":       callerID,
+			"user_id":        userID,
+			"evr_id":         evrID.String(),
+			"action":         action,
+			"sku":            request.SKU,
+			"echo_points":    request.EchoPoints,
+			"transaction_id": entitlements.TransactionID,
+		}).Info("Updated IAP entitlements")
+
+		return IAPEntitlementsResponse{UserID: userID, Entitlements: entitlements}.String(), nil
+	}
+}
+
+// IAPPurchaseRPC buys a SKU for the caller, debiting its price from the caller's echo points.
+// The price is taken from the store that is published for the caller's guild.
+func IAPPurchaseRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := IAPPurchaseRequest{}
+	if err := json.Unmarshal([]byte(payload), &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	if !ok || callerID == "" {
+		return "", runtime.NewError("User ID not found in context", StatusUnauthenticated)
+	}
+
+	evrID, err := evr.ParseEvrId(request.EvrID)
+	if err != nil || !evrID.Valid() {
+		return "", runtime.NewError("A valid evr_id is required", StatusInvalidArgument)
+	}
+	if userID, err := GetUserIDByDeviceID(ctx, db, evrID.String()); err != nil || userID != callerID {
+		return "", runtime.NewError("The EVR ID is not linked to your account", StatusPermissionDenied)
+	}
+
+	if request.SKU == "" {
+		return "", runtime.NewError("A sku is required", StatusInvalidArgument)
+	}
+
+	metadata, err := AccountMetadataLoad(ctx, nk, callerID)
+	if err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error loading account metadata: %s", err.Error()), StatusInternalError)
+	}
+
+	price, found := int64(0), false
+	for _, configType := range iapStoreConfigTypes {
+		scope := ConfigResourceScope{GroupID: metadata.GetActiveGroupID().String()}
+		if experiment, err := ConfigExperimentLoadCached(ctx, nk, configType); err != nil {
+			logger.WithField("error", err).Warn("Failed to load config experiment")
+		} else {
+			scope.Bucket = experiment.Bucket(callerID)
+		}
+		store, err := ConfigResourceEffective(ctx, logger, nk, configType, configType, scope)
+		if err != nil {
+			return "", runtime.NewError(fmt.Sprintf("Error loading the store: %s", err.Error()), StatusInternalError)
+		}
+		if price, found = IAPStorePrice(store, request.SKU); found {
+			break
+		}
+	}
+	if !found {
+		return "", runtime.NewError("The SKU is not for sale", StatusNotFound)
+	}
+
+	entitlements, err := IAPLedgerApply(ctx, nk, callerID, IAPLedgerEntry{
+		Time:        time.Now().UTC(),
+		Action:      IAPLedgerActionPurchase,
+		EvrID:       *evrID,
+		SKU:         request.SKU,
+		EchoPoints:  price,
+		ActorUserID: callerID,
+	})
+	if err != nil {
+		return "", iapLedgerError(err)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"user_id":        callerID,
+		"evr_id":         evrID.String(),
+		"sku":            request.SKU,
+		"echo_points":    price,
+		"transaction_id": entitlements.TransactionID,
+	}).Info("Purchased IAP SKU")
+
+	return IAPEntitlementsResponse{UserID: callerID, Entitlements: entitlements}.String(), nil
+}
+
+// IAPEntitlementsRPC returns the reconciled entitlements and the ledger of the user of an EVR ID.
+func IAPEntitlementsRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request := IAPEntitlementsRequest{}
+	if err := parseRequest(ctx, payload, &request); err != nil {
+		return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+	}
+
+	_, userID, _, err := iapOperatorTarget(ctx, db, request.EvrID)
+	if err != nil {
+		return "", err
+	}
+
+	ledger, err := iapLedgerLoad(ctx, nk, userID)
+	if err != nil {
+		return "", runtime.NewError(err.Error(), StatusInternalError)
+	}
+	wallet, err := iapWalletLoad(ctx, nk, userID)
+	if err != nil {
+		return "", runtime.NewError(err.Error(), StatusInternalError)
+	}
+
+	return IAPEntitlementsResponse{
+		UserID:       userID,
+		Entitlements: newIAPEntitlements(ledger, wallet),
+		Ledger:       ledger,
+	}.String(), nil
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+type MatchTelemetryRPCRequest struct {
+	MatchID string `json:"match_id"`
+}
+
+// MatchTelemetryRPCFactory returns the RPC that aggregates the client telemetry of a match from the match data
+// journals. It is only available to the moderators of the match's guild.
+func MatchTelemetryRPCFactory(reader MatchDataReader) func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		request := MatchTelemetryRPCRequest{}
+		if err := parseRequest(ctx, payload, &request); err != nil {
+			return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+		}
+
+		matchUUID, err := ParseMatchUUID(request.MatchID)
+		if err != nil {
+			return "", runtime.NewError("Invalid match ID", StatusInvalidArgument)
+		}
+
+		journals, err := LoadMatchJournals(ctx, reader, matchUUID)
+		if err != nil {
+			return "", err
+		}
+		matchID := journals[0].MatchID
+
+		callerID, _ := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+		timeline := NewMatchTimeline(matchID, journals)
+		if !IsMatchModerator(ctx, db, nk, callerID, timeline.GroupID) {
+			return "", runtime.NewError("You do not have permission to view this match's telemetry", StatusPermissionDenied)
+		}
+
+		return NewMatchTelemetry(matchID, journals).String(), nil
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+type MatchTimelineRPCRequest struct {
+	MatchID string `json:"match_id"`
+}
+
+// MatchTimelineRPCFactory returns the RPC that reconstructs the timeline of a match from the match data journals.
+func MatchTimelineRPCFactory(reader MatchDataReader) func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		request := MatchTimelineRPCRequest{}
+		if err := parseRequest(ctx, payload, &request); err != nil {
+			return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+		}
+
+		matchUUID, err := ParseMatchUUID(request.MatchID)
+		if err != nil {
+			return "", runtime.NewError("Invalid match ID", StatusInvalidArgument)
+		}
+
+		callerID, _ := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+
+		timeline, err := LoadMatchTimeline(ctx, reader, matchUUID)
+		if err != nil {
+			return "", err
+		}
+
+		if !CanViewMatchTimeline(ctx, db, nk, callerID, timeline) {
+			return "", runtime.NewError("You do not have permission to view this match", StatusPermissionDenied)
+		}
+
+		return timeline.String(), nil
+	}
+}
+
+// ParseMatchUUID returns the match UUID in a match ID, a bare UUID, or a Spark link.
+func ParseMatchUUID(s string) (uuid.UUID, error) {
+	return uuid.FromString(MatchUUIDPattern.FindString(strings.ToLower(s)))
+}
+
+// LoadMatchJournals reads the journals of the match.
+func LoadMatchJournals(ctx context.Context, reader MatchDataReader, matchUUID uuid.UUID) ([]*MatchDataJournal, error) {
+	if reader == nil {
+		return nil, runtime.NewError("Match data is not available", StatusUnavailable)
+	}
+
+	journals, err := reader.Read(ctx, matchUUID)
+	if err != nil {
+		return nil, runtime.NewError(fmt.Sprintf("Error reading match data: %s", err.Error()), StatusInternalError)
+	}
+	if len(journals) == 0 {
+		return nil, runtime.NewError("Match not found", StatusNotFound)
+	}
+
+	return journals, nil
+}
+
+// LoadMatchTimeline reads the match's journals and reconstructs its timeline.
+func LoadMatchTimeline(ctx context.Context, reader MatchDataReader, matchUUID uuid.UUID) (*MatchTimeline, error) {
+	journals, err := LoadMatchJournals(ctx, reader, matchUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMatchTimeline(journals[0].MatchID, journals), nil
+}
+
+// CanViewMatchTimeline reports whether the user played in the match, is an enforcer of the match's guild, or is a global operator.
+func CanViewMatchTimeline(ctx context.Context, db *sql.DB, nk runtime.NakamaModule, userID string, timeline *MatchTimeline) bool {
+	if userID == "" {
+		return false
+	}
+
+	if timeline.HasPlayer(userID) {
+		return true
+	}
+
+	return IsMatchModerator(ctx, db, nk, userID, timeline.GroupID)
+}
+
+// IsMatchModerator reports whether the user is an enforcer of the match's guild, or is a global operator.
+func IsMatchModerator(ctx context.Context, db *sql.DB, nk runtime.NakamaModule, userID, groupID string) bool {
+	if userID == "" {
+		return false
+	}
+
+	if groupID != "" {
+		if gg, err := GuildGroupLoad(ctx, nk, groupID); err == nil && gg.IsEnforcer(userID) {
+			return true
+		}
+	}
+
+	for _, group := range []string{GroupGlobalDevelopers, GroupGlobalOperators} {
+		if ok, _ := CheckSystemGroupMembership(ctx, db, userID, group); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -111,7 +111,8 @@ func MatchmakerStateRPC(ctx context.Context, logger runtime.Logger, db *sql.DB,
 }
 
 type BuildMatchRequest struct {
-	Entries []*MatchmakerEntry `json:"entries"`
+	Entries    []*MatchmakerEntry `json:"entries"`
+	Alignments TeamAlignments     `json:"alignments"` // The team each user must be on, map[userID]TeamIndex
 }
 
 type BuildMatchResponse struct {
@@ -137,7 +138,7 @@ func BuildMatchRPC(ctx context.Context, logger runtime.Logger, db *sql.DB, nk ru
 
 	lobbyBuilder := NewLobbyBuilder(RuntimeLoggerToZapLogger(logger), nk, _nk.sessionRegistry, _nk.matchRegistry, _nk.tracker, _nk.metrics)
 
-	matchID, err := lobbyBuilder.buildMatch(lobbyBuilder.logger, request.Entries)
+	matchID, err := lobbyBuilder.buildMatch(lobbyBuilder.logger, request.Entries, request.Alignments)
 	if err != nil {
 		return "", err
 	}
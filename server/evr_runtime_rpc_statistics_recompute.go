@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+type StatisticsRecomputeAction string
+
+const (
+	StatisticsRecomputeActionBuild   StatisticsRecomputeAction = "build"   // Replay the journaled updates into the shadow boards, and compare them
+	StatisticsRecomputeActionCompare StatisticsRecomputeAction = "compare" // Compare the shadow boards with the live boards
+	StatisticsRecomputeActionSwap    StatisticsRecomputeAction = "swap"    // Replace the live boards with the shadow boards
+	StatisticsRecomputeActionDiscard StatisticsRecomputeAction = "discard" // Remove the shadow boards
+)
+
+type StatisticsRecomputeRequest struct {
+	Action  StatisticsRecomputeAction `json:"action"`
+	GroupID string                    `json:"group_id"`
+	Mode    string                    `json:"mode"`
+	Since   time.Time                 `json:"since,omitempty"` // The range of the journals to replay, when building
+	Until   time.Time                 `json:"until,omitempty"` // Defaults to now
+}
+
+type StatisticsRecomputeResponse struct {
+	Action  StatisticsRecomputeAction    `json:"action"`
+	GroupID string                       `json:"group_id"`
+	Mode    string                       `json:"mode"`
+	Updates int                          `json:"updates,omitempty"` // The number of updates replayed
+	Boards  []*StatisticsBoardComparison `json:"boards,omitempty"`
+	Swapped []string                     `json:"swapped,omitempty"`
+}
+
+func (r StatisticsRecomputeResponse) String() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// StatisticsRecomputeRPCFactory returns the RPC that rebuilds a guild's statistics boards for a mode from the
+// journaled statistics updates. The boards are built as shadow boards, which replace the live boards once they are
+// compared and swapped. Statistics updates are only journaled when enable_match_data_journal is set.
+func StatisticsRecomputeRPCFactory(scanner MatchDataScanner) func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		callerID, err := checkGlobalOperator(ctx, db)
+		if err != nil {
+			return "", err
+		}
+
+		request := StatisticsRecomputeRequest{}
+		if err := parseRequest(ctx, payload, &request); err != nil {
+			return "", runtime.NewError(fmt.Sprintf("Error unmarshalling request: %s", err.Error()), StatusInvalidArgument)
+		}
+		if uuid.FromStringOrNil(request.GroupID).IsNil() {
+			return "", runtime.NewError("A valid group_id is required", StatusInvalidArgument)
+		}
+		mode := evr.ToSymbol(request.Mode)
+		if !slices.Contains(ValidLeaderboardModes, mode) {
+			return "", runtime.NewError("Invalid mode", StatusInvalidArgument)
+		}
+
+		response := StatisticsRecomputeResponse{
+			Action:  request.Action,
+			GroupID: request.GroupID,
+			Mode:    mode.String(),
+		}
+
+		switch request.Action {
+		case StatisticsRecomputeActionBuild:
+			if scanner == nil {
+				return "", runtime.NewError("Match data cannot be scanned", StatusUnavailable)
+			}
+			if settings := ServiceSettings(); settings == nil || !settings.EnableMatchDataJournal {
+				return "", runtime.NewError("Match data journaling is disabled (enable_match_data_journal)", StatusFailedPrecondition)
+			}
+			if request.Until.IsZero() {
+				request.Until = time.Now().UTC()
+			}
+
+			r, err := StatisticsRecomputeBuild(ctx, scanner, request.GroupID, mode, request.Since, request.Until)
+			if err != nil {
+				return "", runtime.NewError(fmt.Sprintf("Error replaying statistics: %s", err.Error()), StatusInternalError)
+			}
+			if err := r.WriteShadow(ctx, nk); err != nil {
+				return "", runtime.NewError(err.Error(), StatusInternalError)
+			}
+			response.Updates = r.Updates
+			fallthrough
+
+		case StatisticsRecomputeActionCompare:
+			if response.Boards, err = StatisticsShadowCompare(ctx, nk, request.GroupID, mode); err != nil {
+				return "", runtime.NewError(err.Error(), StatusInternalError)
+			}
+
+		case StatisticsRecomputeActionSwap:
+			if response.Swapped, err = StatisticsShadowSwap(ctx, nk, request.GroupID, mode); err != nil {
+				if errors.Is(err, ErrStatisticsShadowNotFound) {
+					return "", runtime.NewError(err.Error(), StatusNotFound)
+				} else if errors.Is(err, ErrStatisticsShadowIncomplete) || errors.Is(err, ErrStatisticsShadowStale) {
+					return "", runtime.NewError(err.Error(), StatusFailedPrecondition)
+				}
+				return "", runtime.NewError(err.Error(), StatusInternalError)
+			}
+
+		case StatisticsRecomputeActionDiscard:
+			if _, err := StatisticsShadowDiscard(ctx, nk, request.GroupID, mode); err != nil {
+				return "", runtime.NewError(err.Error(), StatusInternalError)
+			}
+
+		default:
+			return "", runtime.NewError("Invalid action", StatusInvalidArgument)
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"caller_id": callerID,
+			"action":    request.Action,
+			"group_id":  request.GroupID,
+			"mode":      mode.String(),
+			"updates":   response.Updates,
+			"swapped":   len(response.Swapped),
+		}).Info("Recomputed statistics")
+
+		return response.String(), nil
+	}
+}
@@ -41,18 +41,27 @@ type SessionParameters struct {
 	serverRegions []string            // []string of the server regions
 	urlParameters map[string][]string // The URL parameters
 
-	account              *api.Account                    // The account
-	accountMetadata      *AccountMetadata                // The account metadata
-	matchmakingSettings  *MatchmakingSettings            // The matchmaking settings
-	displayNames         *DisplayNameHistory             // The display name history
-	guildGroups          map[string]*GuildGroup          // map[string]*GuildGroup
-	isEarlyQuitter       *atomic.Bool                    // The user is an early quitter
-	isGoldNameTag        *atomic.Bool                    // If this user should have a gold name tag
-	lastMatchmakingError *atomic.Error                   // The last matchmaking error
-	latencyHistory       *atomic.Pointer[LatencyHistory] // The latency history
+	account              *api.Account                             // The account
+	accountMetadata      *AccountMetadata                         // The account metadata
+	matchmakingSettings  *MatchmakingSettings                     // The matchmaking settings
+	displayNames         *DisplayNameHistory                      // The display name history
+	guildGroups          map[string]*GuildGroup                   // map[string]*GuildGroup
+	isEarlyQuitter       *atomic.Bool                             // The user is an early quitter
+	isGoldNameTag        *atomic.Bool                             // If this user should have a gold name tag
+	lastMatchmakingError *atomic.Error                            // The last matchmaking error
+	latencyHistory       *atomic.Pointer[LatencyHistory]          // The latency history
+	enforcementRecords   *atomic.Pointer[GuildEnforcementRecords] // The enforcement records of the guild of the last lobby authorization
+	isCommunityValuesDue *atomic.Bool                             // The last profile sent to the client required the community values
+	lobbyGameServer      *atomic.Pointer[LobbyGameServer]         // The game server of the lobby the session last joined
 
 }
 
+// LobbyGameServer is the game server of a lobby that a session joined.
+type LobbyGameServer struct {
+	MatchID MatchID
+	Address string // The external address of the game server
+}
+
 func (s *SessionParameters) MetricsTags() map[string]string {
 	return map[string]string{
 		"websocket_auth": fmt.Sprintf("%t", s.IsWebsocketAuthenticated),
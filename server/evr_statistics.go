@@ -107,14 +107,15 @@ func LeaderboardMetaFromID(id string) (LeaderboardMeta, error) {
 	}, nil
 }
 
-func MatchmakingRatingLoad(ctx context.Context, nk runtime.NakamaModule, userID, groupID string, mode evr.Symbol) (types.Rating, error) {
+func MatchmakingRatingLoad(ctx context.Context, nk runtime.NakamaModule, userID, groupID string, mode evr.Symbol, system string) (types.Rating, error) {
 	// Look for an existing account.
 
 	var sigma, mu float64
 
+	muStatID, sigmaStatID := RatingStatisticIDs(system)
 	structMap := map[string]*float64{
-		SkillRatingMuStatisticID:    &mu,
-		SkillRatingSigmaStatisticID: &sigma,
+		muStatID:    &mu,
+		sigmaStatID: &sigma,
 	}
 
 	for statName, ptr := range structMap {
@@ -141,14 +142,16 @@ func MatchmakingRatingLoad(ctx context.Context, nk runtime.NakamaModule, userID,
 	}), nil
 }
 
-func MatchmakingRatingStore(ctx context.Context, nk runtime.NakamaModule, userID, discordID, displayName, groupID string, mode evr.Symbol, r types.Rating) error {
+func MatchmakingRatingStore(ctx context.Context, nk runtime.NakamaModule, userID, discordID, displayName, groupID string, mode evr.Symbol, system string, r types.Rating) error {
 
+	muStatID, sigmaStatID := RatingStatisticIDs(system)
 	scores := map[string]float64{
-		StatisticBoardID(groupID, mode, SkillRatingSigmaStatisticID, "alltime"): r.Sigma,
-		StatisticBoardID(groupID, mode, SkillRatingMuStatisticID, "alltime"):    r.Mu,
+		StatisticBoardID(groupID, mode, sigmaStatID, "alltime"): r.Sigma,
+		StatisticBoardID(groupID, mode, muStatID, "alltime"):    r.Mu,
 	}
 	metadata := map[string]any{
 		"discord_id": discordID,
+		"rated_at":   time.Now().UTC().Unix(), // The rating decay uses this to measure inactivity
 	}
 	for id, value := range scores {
 		score, err := Float64ToScore(value)
@@ -2,12 +2,33 @@ package server
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/server/evr"
 )
 
+const (
+	statisticsQueueBatchSize     = 500
+	statisticsQueueFlushInterval = time.Second
+	statisticsQueueLease         = time.Minute // How long a claimed entry is hidden from other flushes
+	statisticsQueueMaxAttempts   = 10
+	statisticsQueueMaxDepth      = 200000              // Entries are rejected above this depth
+	statisticsQueueRetention     = 7 * 24 * time.Hour  // How long applied keys are kept to ignore duplicates
+	statisticsQueueDeadRetention = 30 * 24 * time.Hour // How long dead-lettered entries are kept for inspection
+	statisticsQueueDrainTimeout  = 10 * time.Second
+)
+
+var ErrStatisticsQueueFull = errors.New("statistics queue is full")
+
 type StatisticsQueueEntry struct {
 	BoardMeta   LeaderboardMeta
 	UserID      string
@@ -35,92 +56,320 @@ func (e StatisticsQueueEntry) Override() *int {
 	return &o
 }
 
+// IsValid reports whether the entry should be written: it has a score, and is for a mode with statistics boards.
+func (e StatisticsQueueEntry) IsValid() bool {
+	return e.Score >= 0 && e.Subscore >= 0 && (e.Score > 0 || e.Subscore > 0) && slices.Contains(ValidLeaderboardModes, e.BoardMeta.Mode)
+}
+
+// IdempotencyKey identifies the entry of a player's board in an update of a match, so that it is only applied once.
+// A match may send several updates for the same player; each has its own key.
+func (e StatisticsQueueEntry) IdempotencyKey(matchID MatchID, updateID string) string {
+	return matchID.UUID.String() + ":" + updateID + ":" + e.UserID + ":" + e.BoardMeta.ID()
+}
+
+// StatisticsUpdateID identifies a statistics update by its content, so that a resent update has the same ID.
+func StatisticsUpdateID(stats evr.Statistics) (string, error) {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal statistics: %w", err)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return strconv.FormatUint(h.Sum64(), 16), nil
+}
+
+// statisticsQueueBackoff returns the delay before an entry's next attempt.
+func statisticsQueueBackoff(attempts int) time.Duration {
+	return min(time.Duration(1<<min(attempts, 10))*time.Second, 15*time.Minute)
+}
+
+// StatisticsQueue is a write-ahead queue of leaderboard records, in the statistics_queue table. Entries survive
+// restarts, are written in batches, and are retried until they are applied. Applied keys are kept for a while, so
+// the same update's entries are not applied twice. Entries that fail every attempt are dead-lettered, and kept for
+// inspection.
 type StatisticsQueue struct {
 	logger runtime.Logger
-	ch     chan []*StatisticsQueueEntry
+	db     *sql.DB
+	nk     runtime.NakamaModule
+
+	depth   atomic.Int64
+	flushCh chan struct{}
+
+	ctxCancelFn context.CancelFunc
+	stoppedCh   chan struct{}
 }
 
-func NewStatisticsQueue(logger runtime.Logger, nk runtime.NakamaModule) *StatisticsQueue {
-	ch := make(chan []*StatisticsQueueEntry, 8*3*100) // three matches ending at the same time, 100 records per player
-	r := &StatisticsQueue{
+func NewStatisticsQueue(logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule) *StatisticsQueue {
+	ctx, ctxCancelFn := context.WithCancel(context.Background())
+	q := &StatisticsQueue{
 		logger: logger,
-		ch:     ch,
-	}
-
-	go func() {
-
-		ctx := context.Background()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case entries := <-ch:
-
-				for _, e := range entries {
-
-					if e.Score < 0 {
-						logger.WithFields(map[string]any{
-							"leaderboard_id": e.BoardMeta.ID(),
-							"score":          e.Score,
-							"subscore":       e.Subscore,
-						}).Warn("Negative score")
-						continue
-					} else if e.Score == 0 && e.Subscore == 0 {
-						continue
-					}
-
-					if !slices.Contains(ValidLeaderboardModes, e.BoardMeta.Mode) {
-						continue
-					}
-
-					if _, err := nk.LeaderboardRecordWrite(ctx, e.BoardMeta.ID(), e.UserID, e.DisplayName, e.Score, e.Subscore, map[string]any{}, e.Override()); err != nil {
-
-						// Try to create the leaderboard
-						if err = nk.LeaderboardCreate(ctx, e.BoardMeta.ID(), true, "desc", string(e.BoardMeta.Operator), ResetScheduleToCron(e.BoardMeta.ResetSchedule), map[string]any{}, true); err != nil {
-
-							logger.WithFields(map[string]any{
-								"leaderboard_id": e.BoardMeta.ID(),
-								"error":          err.Error(),
-							}).Error("Failed to create leaderboard")
-
-						} else {
-							logger.WithFields(map[string]any{
-								"leaderboard_id": e.BoardMeta.ID(),
-							}).Debug("Leaderboard created")
-
-							if _, err := nk.LeaderboardRecordWrite(ctx, e.BoardMeta.ID(), e.UserID, e.DisplayName, e.Score, e.Subscore, map[string]any{}, e.Override()); err != nil {
-								logger.WithFields(map[string]any{
-									"error":          err.Error(),
-									"leaderboard_id": e.BoardMeta.ID(),
-									"user_id":        e.UserID,
-									"score":          e.Score,
-									"subscore":       e.Subscore,
-								}).Error("Failed to write leaderboard record")
-							}
-
-						}
-					}
-				}
+		db:     db,
+		nk:     nk,
+
+		flushCh: make(chan struct{}, 1),
+
+		ctxCancelFn: ctxCancelFn,
+		stoppedCh:   make(chan struct{}),
+	}
+
+	go q.start(ctx)
+
+	return q
+}
+
+func (q *StatisticsQueue) start(ctx context.Context) {
+	defer close(q.stoppedCh)
+
+	flushTicker := time.NewTicker(statisticsQueueFlushInterval)
+	defer flushTicker.Stop()
+	purgeTicker := time.NewTicker(time.Hour)
+	defer purgeTicker.Stop()
+
+	q.updateMetrics(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Flush what is due before stopping; anything left is flushed after the restart.
+			ctx, cancel := context.WithTimeout(context.Background(), statisticsQueueDrainTimeout)
+			q.flushAll(ctx)
+			cancel()
+			return
+		case <-q.flushCh:
+		case <-flushTicker.C:
+		case <-purgeTicker.C:
+			if err := q.purge(ctx); err != nil {
+				q.logger.WithField("error", err).Warn("Failed to purge applied statistics")
 			}
+			continue
 		}
-	}()
+		q.flushAll(ctx)
+		q.updateMetrics(ctx)
+	}
+}
 
-	return r
+// Stop flushes the entries that are due, and stops the queue.
+func (q *StatisticsQueue) Stop() {
+	q.ctxCancelFn()
+	<-q.stoppedCh
 }
 
-func (r *StatisticsQueue) Add(entries []*StatisticsQueueEntry) error {
+// Add persists a player's entries from an update of a match. Entries already queued for the same update, player and
+// board are ignored.
+func (q *StatisticsQueue) Add(ctx context.Context, matchID MatchID, updateID string, entries []*StatisticsQueueEntry) error {
+	keys := make([]string, 0, len(entries))
+	values := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Score < 0 {
+			q.logger.WithFields(map[string]any{
+				"leaderboard_id": e.BoardMeta.ID(),
+				"score":          e.Score,
+				"subscore":       e.Subscore,
+			}).Warn("Negative score")
+			continue
+		} else if !e.IsValid() {
+			continue
+		}
 
-	select {
-	case r.ch <- entries:
-		r.logger.WithFields(map[string]any{
-			"count": len(entries),
-		}).Debug("Leaderboard record queued")
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal statistics entry: %w", err)
+		}
+		keys = append(keys, e.IdempotencyKey(matchID, updateID))
+		values = append(values, string(data))
+	}
+	if len(keys) == 0 {
 		return nil
+	}
+
+	// Reject the entries, rather than grow the queue without bound while the leaderboards are unavailable.
+	if q.depth.Load() >= statisticsQueueMaxDepth {
+		q.nk.MetricsCounterAdd("statistics_queue_rejected_count", nil, int64(len(keys)))
+		return ErrStatisticsQueueFull
+	}
+
+	query := `
+INSERT INTO statistics_queue (idempotency_key, entry)
+SELECT unnest($1::text[]), unnest($2::jsonb[])
+ON CONFLICT (idempotency_key) DO NOTHING`
+	result, err := q.db.ExecContext(ctx, query, keys, values)
+	if err != nil {
+		return fmt.Errorf("failed to queue statistics: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil {
+		q.depth.Add(n)
+		q.logger.WithFields(map[string]any{
+			"count":      n,
+			"duplicates": int64(len(keys)) - n,
+		}).Debug("Leaderboard records queued")
+	}
+
+	select {
+	case q.flushCh <- struct{}{}:
 	default:
-		r.logger.WithFields(map[string]any{
-			"count": len(entries),
-		}).Warn("Leaderboard record write queue full, dropping entry")
-		return fmt.Errorf("queue full")
 	}
+	return nil
+}
+
+// flushAll flushes batches until none are due, or the context is done.
+func (q *StatisticsQueue) flushAll(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := q.flush(ctx)
+		if err != nil {
+			q.logger.WithField("error", err).Warn("Failed to flush statistics queue")
+			return
+		}
+		if n < statisticsQueueBatchSize {
+			return
+		}
+	}
+}
+
+// flush claims a batch of due entries and writes them to their leaderboards, returning the number claimed.
+func (q *StatisticsQueue) flush(ctx context.Context) (int, error) {
+	query := `
+UPDATE statistics_queue
+SET attempts = attempts + 1, next_attempt_time = now() + $2::interval
+WHERE idempotency_key IN (
+	SELECT idempotency_key FROM statistics_queue
+	WHERE applied_time IS NULL AND dead_time IS NULL AND next_attempt_time <= now()
+	ORDER BY next_attempt_time
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING idempotency_key, entry, attempts, create_time`
+	rows, err := q.db.QueryContext(ctx, query, statisticsQueueBatchSize, statisticsQueueLease.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim statistics: %w", err)
+	}
+
+	type claim struct {
+		key       string
+		data      []byte
+		attempts  int
+		createdAt time.Time
+	}
+	claims := make([]claim, 0, statisticsQueueBatchSize)
+	for rows.Next() {
+		var c claim
+		if err := rows.Scan(&c.key, &c.data, &c.attempts, &c.createdAt); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to scan statistics: %w", err)
+		}
+		claims = append(claims, c)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to claim statistics: %w", err)
+	}
+
+	applied := make([]string, 0, len(claims))
+	for _, c := range claims {
+		fields := map[string]any{
+			"idempotency_key": c.key,
+			"attempts":        c.attempts,
+		}
+
+		// An entry claimed past its last attempt was claimed by a flush that did not finish.
+		if c.attempts > statisticsQueueMaxAttempts {
+			if err := q.deadLetter(ctx, c.key, "flush did not finish"); err != nil {
+				return len(claims), err
+			}
+			q.logger.WithFields(fields).Error("Dead-lettered leaderboard record")
+			continue
+		}
+
+		entry := &StatisticsQueueEntry{}
+		err := json.Unmarshal(c.data, entry)
+		if err == nil {
+			err = statisticsQueueWrite(ctx, q.nk, entry)
+		}
+		if err != nil {
+			q.nk.MetricsCounterAdd("statistics_queue_write_count", map[string]string{"result": "error"}, 1)
+			fields["error"] = err.Error()
+
+			if c.attempts >= statisticsQueueMaxAttempts {
+				if err := q.deadLetter(ctx, c.key, err.Error()); err != nil {
+					return len(claims), err
+				}
+				q.logger.WithFields(fields).Error("Dead-lettered leaderboard record")
+				continue
+			}
+
+			q.logger.WithFields(fields).Warn("Failed to write leaderboard record, retrying")
+			if _, err := q.db.ExecContext(ctx, "UPDATE statistics_queue SET last_error = $2, next_attempt_time = now() + $3::interval WHERE idempotency_key = $1", c.key, err.Error(), statisticsQueueBackoff(c.attempts).String()); err != nil {
+				return len(claims), fmt.Errorf("failed to reschedule statistics: %w", err)
+			}
+			continue
+		}
+
+		q.nk.MetricsCounterAdd("statistics_queue_write_count", map[string]string{"result": "ok"}, 1)
+		q.nk.MetricsTimerRecord("statistics_queue_lag", nil, time.Since(c.createdAt))
+		applied = append(applied, c.key)
+	}
+
+	if len(applied) > 0 {
+		if _, err := q.db.ExecContext(ctx, "UPDATE statistics_queue SET applied_time = now(), last_error = '' WHERE idempotency_key = ANY($1)", applied); err != nil {
+			return len(claims), fmt.Errorf("failed to mark statistics applied: %w", err)
+		}
+		q.depth.Add(-int64(len(applied)))
+	}
+
+	return len(claims), nil
+}
+
+// deadLetter stops retrying the entry. It is kept for inspection until it is purged.
+func (q *StatisticsQueue) deadLetter(ctx context.Context, key, reason string) error {
+	if _, err := q.db.ExecContext(ctx, "UPDATE statistics_queue SET dead_time = now(), last_error = $2 WHERE idempotency_key = $1", key, reason); err != nil {
+		return fmt.Errorf("failed to dead-letter statistics: %w", err)
+	}
+	q.depth.Add(-1)
+	q.nk.MetricsCounterAdd("statistics_queue_dead_letter_count", nil, 1)
+	return nil
+}
+
+// statisticsQueueWrite writes the entry to its leaderboard, creating the leaderboard if it does not exist.
+func statisticsQueueWrite(ctx context.Context, nk runtime.NakamaModule, e *StatisticsQueueEntry) error {
+	if _, err := nk.LeaderboardRecordWrite(ctx, e.BoardMeta.ID(), e.UserID, e.DisplayName, e.Score, e.Subscore, map[string]any{}, e.Override()); err == nil {
+		return nil
+	}
+
+	// Try to create the leaderboard
+	if err := nk.LeaderboardCreate(ctx, e.BoardMeta.ID(), true, "desc", string(e.BoardMeta.Operator), ResetScheduleToCron(e.BoardMeta.ResetSchedule), map[string]any{}, true); err != nil {
+		return fmt.Errorf("failed to create leaderboard: %w", err)
+	}
+
+	if _, err := nk.LeaderboardRecordWrite(ctx, e.BoardMeta.ID(), e.UserID, e.DisplayName, e.Score, e.Subscore, map[string]any{}, e.Override()); err != nil {
+		return fmt.Errorf("failed to write leaderboard record: %w", err)
+	}
+	return nil
+}
+
+// updateMetrics reloads the depth of the queue, the age of its oldest pending entry, and the number of dead letters.
+func (q *StatisticsQueue) updateMetrics(ctx context.Context) {
+	var (
+		depth int64
+		lag   float64
+		dead  int64
+	)
+	query := `
+SELECT
+	count(*) FILTER (WHERE dead_time IS NULL),
+	coalesce(extract(epoch FROM now() - min(create_time) FILTER (WHERE dead_time IS NULL)), 0),
+	count(*) FILTER (WHERE dead_time IS NOT NULL)
+FROM statistics_queue WHERE applied_time IS NULL`
+	if err := q.db.QueryRowContext(ctx, query).Scan(&depth, &lag, &dead); err != nil {
+		q.logger.WithField("error", err).Warn("Failed to get statistics queue depth")
+		return
+	}
+	q.depth.Store(depth)
+	q.nk.MetricsGaugeSet("statistics_queue_depth", nil, float64(depth))
+	q.nk.MetricsGaugeSet("statistics_queue_lag_seconds", nil, lag)
+	q.nk.MetricsGaugeSet("statistics_queue_dead_letter_depth", nil, float64(dead))
+}
+
+// purge removes the applied keys and the dead letters that are past their retention.
+func (q *StatisticsQueue) purge(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, "DELETE FROM statistics_queue WHERE applied_time < now() - $1::interval OR dead_time < now() - $2::interval", statisticsQueueRetention.String(), statisticsQueueDeadRetention.String())
+	return err
 }
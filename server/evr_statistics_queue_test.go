@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/heroiclabs/nakama/v3/server/evr"
@@ -387,3 +388,60 @@ func TestStatisticsJSONToEntries(t *testing.T) {
 	t.Logf("Generated entries: %+v", entries)
 
 }
+
+func TestStatisticsQueueEntry_Queued(t *testing.T) {
+	matchID := MatchIDFromStringOrNil("7b2f8a3e-4c1d-4e5f-9a6b-0c1d2e3f4a5b.node1")
+	entry := &StatisticsQueueEntry{
+		BoardMeta: LeaderboardMeta{
+			GroupID:       "group1",
+			Mode:          evr.ModeArenaPublic,
+			StatName:      "ArenaWins",
+			Operator:      OperatorIncrement,
+			ResetSchedule: evr.ResetScheduleWeekly,
+		},
+		UserID:      "user1",
+		DisplayName: "User One",
+		Score:       1,
+	}
+
+	if got, want := entry.IdempotencyKey(matchID, "1"), "7b2f8a3e-4c1d-4e5f-9a6b-0c1d2e3f4a5b:1:user1:"+entry.BoardMeta.ID(); got != want {
+		t.Errorf("IdempotencyKey() = %q, want %q", got, want)
+	}
+	if entry.IdempotencyKey(matchID, "1") == entry.IdempotencyKey(matchID, "2") {
+		t.Error("IdempotencyKey() is the same for different updates")
+	}
+
+	// Entries are stored as JSON, and must be written to the same board once reloaded.
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Failed to marshal entry: %v", err)
+	}
+	reloaded := &StatisticsQueueEntry{}
+	if err := json.Unmarshal(data, reloaded); err != nil {
+		t.Fatalf("Failed to unmarshal entry: %v", err)
+	}
+	if diff := cmp.Diff(entry, reloaded); diff != "" {
+		t.Errorf("Reloaded entry mismatch (-want +got):\n%s", diff)
+	}
+	if *reloaded.Override() != *entry.Override() {
+		t.Errorf("Override() = %d, want %d", *reloaded.Override(), *entry.Override())
+	}
+}
+
+func TestStatisticsQueueBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 2 * time.Second},
+		{4, 16 * time.Second},
+		{9, 512 * time.Second},
+		{10, 15 * time.Minute},
+		{100, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := statisticsQueueBackoff(tt.attempts); got != tt.want {
+			t.Errorf("statisticsQueueBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,531 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/heroiclabs/nakama/v3/internal/cronexpr"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+// The suffix of the shadow leaderboards that recomputed statistics are written to, until they are swapped in.
+const StatisticsShadowBoardSuffix = ":shadow"
+
+var (
+	ErrStatisticsShadowNotFound   = errors.New("no shadow leaderboards found")
+	ErrStatisticsShadowIncomplete = errors.New("live records predate the replayed journals")
+	ErrStatisticsShadowStale      = errors.New("live records were updated after the shadow was built")
+)
+
+func StatisticShadowBoardID(boardID string) string {
+	return boardID + StatisticsShadowBoardSuffix
+}
+
+// statisticsShadowMetadata is the metadata of a shadow leaderboard.
+type statisticsShadowMetadata struct {
+	ShadowOf      string            `json:"shadow_of"`
+	Operator      string            `json:"operator"`
+	ResetSchedule evr.ResetSchedule `json:"reset_schedule"`
+	Since         int64             `json:"since"` // Unix time of the first replayed journal
+	Built         int64             `json:"built"` // Unix time the shadow was built
+}
+
+type statisticsRecordKey struct {
+	BoardID string
+	Expiry  int64 // Unix time the record's period ends, or zero for all-time boards
+	UserID  string
+}
+
+type statisticsRecord struct {
+	Username  string
+	Score     int64
+	NumScore  int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// StatisticsRecompute rebuilds the statistics boards of a guild's mode by replaying the journaled updates in order.
+// Each player's statistics are totalled from the deltas of their updates, from the start of the replay; the
+// statistics the live boards held when an update was journaled are not used.
+type StatisticsRecompute struct {
+	GroupID string
+	Mode    evr.Symbol
+	Since   time.Time
+	Updates int
+	Boards  map[string]LeaderboardMeta
+
+	totals  map[string]evr.Statistics
+	records map[statisticsRecordKey]*statisticsRecord
+}
+
+func NewStatisticsRecompute(groupID string, mode evr.Symbol, since time.Time) *StatisticsRecompute {
+	return &StatisticsRecompute{
+		GroupID: groupID,
+		Mode:    mode,
+		Since:   since,
+		Boards:  make(map[string]LeaderboardMeta),
+		totals:  make(map[string]evr.Statistics),
+		records: make(map[statisticsRecordKey]*statisticsRecord),
+	}
+}
+
+// Add applies the update's delta to the player's replayed statistics. Updates of other guilds or modes are ignored.
+func (r *StatisticsRecompute) Add(ts time.Time, u *MatchDataStatisticsUpdate) error {
+	if u.GroupID != r.GroupID || u.Mode != r.Mode || u.Update == nil {
+		return nil
+	}
+
+	total := r.totals[u.UserID]
+	update := statisticsReplayUpdate(r.Mode, total, u.Previous, u.Update)
+	// The entries are calculated in place, so the next total is taken first.
+	r.totals[u.UserID] = statisticsMerge(r.Mode, total, update)
+
+	entries, err := StatisticsToEntries(u.UserID, u.DisplayName, u.GroupID, u.Mode, total, update)
+	if err != nil {
+		return err
+	}
+	r.Updates++
+
+	for _, e := range entries {
+		if !e.IsValid() {
+			continue
+		}
+		boardID := e.BoardMeta.ID()
+		r.Boards[boardID] = e.BoardMeta
+
+		key := statisticsRecordKey{
+			BoardID: boardID,
+			Expiry:  statisticsRecordExpiry(e.BoardMeta.ResetSchedule, ts),
+			UserID:  e.UserID,
+		}
+		rec, ok := r.records[key]
+		if !ok {
+			rec = &statisticsRecord{Score: e.Score, CreatedAt: ts}
+			r.records[key] = rec
+		} else {
+			switch e.BoardMeta.Operator {
+			case OperatorIncrement:
+				rec.Score += e.Score
+			case OperatorDecrement:
+				rec.Score = max(rec.Score-e.Score, 0)
+			case OperatorBest:
+				rec.Score = max(rec.Score, e.Score)
+			default:
+				rec.Score = e.Score
+			}
+		}
+		rec.Username = e.DisplayName
+		rec.NumScore++
+		rec.UpdatedAt = ts
+	}
+	return nil
+}
+
+// statisticsReplayUpdate returns the update's statistics, with each increment replaced by the player's replayed
+// total plus the increment's delta from the update's previous statistics.
+func statisticsReplayUpdate(mode evr.Symbol, total, prev, update evr.Statistics) evr.Statistics {
+	replayed := newModeStatistics(mode)
+	dst := reflect.ValueOf(replayed).Elem()
+	src := reflect.ValueOf(update).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if src.Field(i).IsNil() {
+			continue
+		}
+		stat := statisticCopy(src.Field(i))
+		switch stat.(type) {
+		case *evr.StatisticIntegerIncrement, *evr.StatisticFloatIncrement:
+			stat.SetValue(statisticValue(total, i) + stat.GetValue() - statisticValue(prev, i))
+		}
+		dst.Field(i).Set(reflect.ValueOf(stat))
+	}
+	return replayed
+}
+
+// statisticsMerge returns a copy of the total, with the update's statistics replacing the total's.
+func statisticsMerge(mode evr.Symbol, total, update evr.Statistics) evr.Statistics {
+	merged := newModeStatistics(mode)
+	dst := reflect.ValueOf(merged).Elem()
+	for _, s := range []evr.Statistics{total, update} {
+		if s == nil || reflect.ValueOf(s).IsNil() {
+			continue
+		}
+		src := reflect.ValueOf(s).Elem()
+		for i := 0; i < src.NumField(); i++ {
+			if !src.Field(i).IsNil() {
+				dst.Field(i).Set(reflect.ValueOf(statisticCopy(src.Field(i))))
+			}
+		}
+	}
+	return merged
+}
+
+func statisticCopy(field reflect.Value) evr.Statistic {
+	c := reflect.New(field.Type().Elem())
+	c.Elem().Set(field.Elem())
+	return c.Interface().(evr.Statistic)
+}
+
+// statisticValue returns the value of the statistic in the i'th field, or zero if it is not set.
+func statisticValue(s evr.Statistics, i int) float64 {
+	if s == nil || reflect.ValueOf(s).IsNil() {
+		return 0
+	}
+	field := reflect.ValueOf(s).Elem().Field(i)
+	if field.IsNil() {
+		return 0
+	}
+	return field.Interface().(evr.Statistic).GetValue()
+}
+
+// statisticsRecordExpiry returns the end of the board's period that includes the time.
+func statisticsRecordExpiry(resetSchedule evr.ResetSchedule, ts time.Time) int64 {
+	cron := ResetScheduleToCron(resetSchedule)
+	if cron == "" {
+		return 0
+	}
+	return cronexpr.MustParse(cron).Next(ts.UTC()).UTC().Unix()
+}
+
+// decodeMatchDataStatisticsUpdate decodes a journaled statistics update into the statistics of its mode.
+func decodeMatchDataStatisticsUpdate(data any) (*MatchDataStatisticsUpdate, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	raw := struct {
+		UserID      string          `json:"user_id"`
+		DisplayName string          `json:"display_name"`
+		GroupID     string          `json:"group_id"`
+		Mode        evr.Symbol      `json:"mode"`
+		Previous    json.RawMessage `json:"previous"`
+		Update      json.RawMessage `json:"update"`
+	}{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	u := &MatchDataStatisticsUpdate{
+		UserID:      raw.UserID,
+		DisplayName: raw.DisplayName,
+		GroupID:     raw.GroupID,
+		Mode:        raw.Mode,
+	}
+	for _, f := range []struct {
+		data json.RawMessage
+		dst  *evr.Statistics
+	}{{raw.Previous, &u.Previous}, {raw.Update, &u.Update}} {
+		if len(f.data) == 0 || string(f.data) == "null" {
+			continue
+		}
+		stats := newModeStatistics(raw.Mode)
+		if err := json.Unmarshal(f.data, stats); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal statistics: %w", err)
+		}
+		*f.dst = stats
+	}
+	return u, nil
+}
+
+func newModeStatistics(mode evr.Symbol) evr.Statistics {
+	switch mode {
+	case evr.ModeArenaPublic:
+		return &evr.ArenaStatistics{}
+	case evr.ModeCombatPublic:
+		return &evr.CombatStatistics{}
+	default:
+		return &evr.GenericStats{}
+	}
+}
+
+// StatisticsRecomputeBuild replays the guild's mode's statistics updates journaled in the time range.
+func StatisticsRecomputeBuild(ctx context.Context, scanner MatchDataScanner, groupID string, mode evr.Symbol, since, until time.Time) (*StatisticsRecompute, error) {
+	r := NewStatisticsRecompute(groupID, mode, since)
+	err := scanner.Scan(ctx, MatchDataTypeStatisticsUpdate, since, until, func(j *MatchDataJournal) error {
+		for _, e := range j.Events {
+			if e.Type != MatchDataTypeStatisticsUpdate {
+				continue
+			}
+			u, err := decodeMatchDataStatisticsUpdate(e.Data)
+			if err != nil {
+				return fmt.Errorf("failed to decode statistics update in match %s: %w", j.MatchID, err)
+			}
+			if err := r.Add(e.CreatedAt, u); err != nil {
+				return fmt.Errorf("failed to replay statistics update in match %s: %w", j.MatchID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// WriteShadow replaces the shadow leaderboards with the recomputed records. Records can only be written to the
+// current period of a board, so the records of the periods that have ended are not written.
+func (r *StatisticsRecompute) WriteShadow(ctx context.Context, nk runtime.NakamaModule) error {
+	now := time.Now().UTC()
+	override := 2 // Set
+	for boardID, meta := range r.Boards {
+		shadowID := StatisticShadowBoardID(boardID)
+		if err := nk.LeaderboardDelete(ctx, shadowID); err != nil {
+			return fmt.Errorf("failed to clear shadow leaderboard: %w", err)
+		}
+		metadata := map[string]any{
+			"shadow_of":      boardID,
+			"operator":       string(meta.Operator),
+			"reset_schedule": meta.ResetSchedule,
+			"since":          r.Since.Unix(),
+			"built":          now.Unix(),
+		}
+		if err := nk.LeaderboardCreate(ctx, shadowID, true, "desc", string(meta.Operator), ResetScheduleToCron(meta.ResetSchedule), metadata, true); err != nil {
+			return fmt.Errorf("failed to create shadow leaderboard: %w", err)
+		}
+	}
+	for k, rec := range r.records {
+		if k.Expiry != statisticsRecordExpiry(r.Boards[k.BoardID].ResetSchedule, now) {
+			continue
+		}
+		if _, err := nk.LeaderboardRecordWrite(ctx, StatisticShadowBoardID(k.BoardID), k.UserID, rec.Username, rec.Score, 0, nil, &override); err != nil {
+			return fmt.Errorf("failed to write shadow leaderboard record: %w", err)
+		}
+	}
+	return nil
+}
+
+// StatisticsBoardComparison compares the current period of a live board with its shadow.
+type StatisticsBoardComparison struct {
+	BoardID       string  `json:"board_id"`
+	LiveRecords   int64   `json:"live_records"`
+	ShadowRecords int64   `json:"shadow_records"`
+	Changed       int64   `json:"changed"`   // Records with a different score, or only on one of the boards
+	MaxDelta      float64 `json:"max_delta"` // The largest change of a record's value
+}
+
+// statisticsShadowBoards returns the shadows of the guild's mode's boards, by the ID of their live board.
+func statisticsShadowBoards(ctx context.Context, nk runtime.NakamaModule, groupID string, mode evr.Symbol) (map[string]*statisticsShadowMetadata, error) {
+	shadowIDs := make([]string, 0)
+	statsType := reflect.TypeOf(newModeStatistics(mode)).Elem()
+	for i := 0; i < statsType.NumField(); i++ {
+		statName := strings.SplitN(statsType.Field(i).Tag.Get("json"), ",", 2)[0]
+		for _, r := range []evr.ResetSchedule{evr.ResetScheduleDaily, evr.ResetScheduleWeekly, evr.ResetScheduleAllTime} {
+			shadowIDs = append(shadowIDs, StatisticShadowBoardID(StatisticBoardID(groupID, mode, statName, r)))
+		}
+	}
+
+	leaderboards, err := nk.LeaderboardsGetId(ctx, shadowIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shadow leaderboards: %w", err)
+	}
+	shadows := make(map[string]*statisticsShadowMetadata, len(leaderboards))
+	for _, l := range leaderboards {
+		meta := &statisticsShadowMetadata{}
+		if err := json.Unmarshal([]byte(l.Metadata), meta); err != nil || meta.ShadowOf == "" {
+			return nil, fmt.Errorf("invalid shadow leaderboard metadata: %s", l.Id)
+		}
+		shadows[meta.ShadowOf] = meta
+	}
+	return shadows, nil
+}
+
+// statisticsBoardRecords returns the records of the current period of the board, by owner ID.
+func statisticsBoardRecords(ctx context.Context, nk runtime.NakamaModule, boardID string) (map[string]*api.LeaderboardRecord, error) {
+	records := make(map[string]*api.LeaderboardRecord)
+	cursor := ""
+	for {
+		list, _, next, _, err := nk.LeaderboardRecordsList(ctx, boardID, nil, 10000, cursor, 0)
+		if err != nil {
+			if errors.Is(err, ErrLeaderboardNotFound) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("failed to list leaderboard %s: %w", boardID, err)
+		}
+		for _, rec := range list {
+			records[rec.OwnerId] = rec
+		}
+		if next == "" {
+			return records, nil
+		}
+		cursor = next
+	}
+}
+
+// StatisticsShadowCompare compares each of the guild's mode's shadow boards with its live board.
+func StatisticsShadowCompare(ctx context.Context, nk runtime.NakamaModule, groupID string, mode evr.Symbol) ([]*StatisticsBoardComparison, error) {
+	shadows, err := statisticsShadowBoards(ctx, nk, groupID, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisons := make([]*StatisticsBoardComparison, 0, len(shadows))
+	for boardID := range shadows {
+		live, err := statisticsBoardRecords(ctx, nk, boardID)
+		if err != nil {
+			return nil, err
+		}
+		shadow, err := statisticsBoardRecords(ctx, nk, StatisticShadowBoardID(boardID))
+		if err != nil {
+			return nil, err
+		}
+
+		c := &StatisticsBoardComparison{
+			BoardID:       boardID,
+			LiveRecords:   int64(len(live)),
+			ShadowRecords: int64(len(shadow)),
+		}
+		var maxDelta int64
+		for ownerID, l := range live {
+			s, ok := shadow[ownerID]
+			if !ok {
+				c.Changed++
+				maxDelta = max(maxDelta, l.Score)
+			} else if s.Score != l.Score {
+				c.Changed++
+				maxDelta = max(maxDelta, s.Score-l.Score, l.Score-s.Score)
+			}
+		}
+		for ownerID, s := range shadow {
+			if _, ok := live[ownerID]; !ok {
+				c.Changed++
+				maxDelta = max(maxDelta, s.Score)
+			}
+		}
+		c.MaxDelta = ScoreToFloat64(maxDelta)
+		comparisons = append(comparisons, c)
+	}
+	slices.SortFunc(comparisons, func(a, b *StatisticsBoardComparison) int {
+		return strings.Compare(a.BoardID, b.BoardID)
+	})
+	return comparisons, nil
+}
+
+// StatisticsShadowSwap replaces the records of the current period of the guild's mode's live boards with those of
+// their shadows in one transaction, and removes the shadows. The swap is refused if any of the live records was
+// created before the replayed journals, since the shadows do not include it, or was updated after the shadows were
+// built, since the shadows would overwrite the update.
+func StatisticsShadowSwap(ctx context.Context, nk runtime.NakamaModule, groupID string, mode evr.Symbol) ([]string, error) {
+	_nk, ok := nk.(*RuntimeGoNakamaModule)
+	if !ok {
+		return nil, errors.New("failed to cast nakama module")
+	}
+
+	shadows, err := statisticsShadowBoards(ctx, nk, groupID, mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(shadows) == 0 {
+		return nil, ErrStatisticsShadowNotFound
+	}
+
+	type swap struct {
+		boardID string
+		meta    *statisticsShadowMetadata
+		expiry  int64
+		shadow  map[string]*api.LeaderboardRecord
+	}
+	now := time.Now().UTC()
+	swaps := make([]*swap, 0, len(shadows))
+	for boardID, meta := range shadows {
+		s := &swap{boardID: boardID, meta: meta, expiry: statisticsRecordExpiry(meta.ResetSchedule, now)}
+		if s.shadow, err = statisticsBoardRecords(ctx, nk, StatisticShadowBoardID(boardID)); err != nil {
+			return nil, err
+		}
+		// Create the live board if it does not exist yet, the same as its shadow.
+		if err := nk.LeaderboardCreate(ctx, boardID, true, "desc", meta.Operator, ResetScheduleToCron(meta.ResetSchedule), map[string]any{}, true); err != nil {
+			return nil, fmt.Errorf("failed to create leaderboard: %w", err)
+		}
+		swaps = append(swaps, s)
+	}
+
+	if err := ExecuteInTx(ctx, _nk.db, func(tx *sql.Tx) error {
+		for _, s := range swaps {
+			expiry := time.Unix(s.expiry, 0).UTC()
+
+			// Lock the live records, so they can not be updated until the swap is committed.
+			rows, err := tx.QueryContext(ctx, "SELECT create_time, update_time FROM leaderboard_record WHERE leaderboard_id = $1 AND expiry_time = $2 FOR UPDATE", s.boardID, expiry)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var createTime, updateTime time.Time
+				if err := rows.Scan(&createTime, &updateTime); err != nil {
+					_ = rows.Close()
+					return err
+				}
+				if createTime.Unix() < s.meta.Since {
+					_ = rows.Close()
+					return fmt.Errorf("%w: %s", ErrStatisticsShadowIncomplete, s.boardID)
+				}
+				if updateTime.Unix() >= s.meta.Built {
+					_ = rows.Close()
+					return fmt.Errorf("%w: %s", ErrStatisticsShadowStale, s.boardID)
+				}
+			}
+			_ = rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, "DELETE FROM leaderboard_record WHERE leaderboard_id = $1 AND expiry_time = $2", s.boardID, expiry); err != nil {
+				return err
+			}
+			query := `
+INSERT INTO leaderboard_record (leaderboard_id, owner_id, username, score, subscore, num_score, max_num_score, metadata, expiry_time)
+SELECT $1, owner_id, username, score, subscore, num_score, max_num_score, metadata, expiry_time
+FROM leaderboard_record WHERE leaderboard_id = $2 AND expiry_time = $3`
+			if _, err := tx.ExecContext(ctx, query, s.boardID, StatisticShadowBoardID(s.boardID), expiry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, ErrStatisticsShadowIncomplete) || errors.Is(err, ErrStatisticsShadowStale) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to swap the shadow leaderboards: %w", err)
+	}
+
+	boardIDs := make([]string, 0, len(swaps))
+	for _, s := range swaps {
+		// The ranks of the live board are rebuilt from the records that were swapped in.
+		_nk.leaderboardRankCache.DeleteLeaderboard(s.boardID, s.expiry)
+		if l := _nk.leaderboardCache.Get(s.boardID); l != nil {
+			for ownerID, rec := range s.shadow {
+				_nk.leaderboardRankCache.Insert(s.boardID, l.SortOrder, rec.Score, rec.Subscore, rec.NumScore, s.expiry, uuid.FromStringOrNil(ownerID), l.EnableRanks)
+			}
+		}
+		if err := nk.LeaderboardDelete(ctx, StatisticShadowBoardID(s.boardID)); err != nil {
+			return boardIDs, fmt.Errorf("failed to delete shadow leaderboard: %w", err)
+		}
+		boardIDs = append(boardIDs, s.boardID)
+	}
+	slices.Sort(boardIDs)
+	return boardIDs, nil
+}
+
+// StatisticsShadowDiscard removes the guild's mode's shadow boards.
+func StatisticsShadowDiscard(ctx context.Context, nk runtime.NakamaModule, groupID string, mode evr.Symbol) ([]string, error) {
+	shadows, err := statisticsShadowBoards(ctx, nk, groupID, mode)
+	if err != nil {
+		return nil, err
+	}
+	boardIDs := make([]string, 0, len(shadows))
+	for boardID := range shadows {
+		if err := nk.LeaderboardDelete(ctx, StatisticShadowBoardID(boardID)); err != nil {
+			return nil, fmt.Errorf("failed to delete shadow leaderboard: %w", err)
+		}
+		boardIDs = append(boardIDs, boardID)
+	}
+	slices.Sort(boardIDs)
+	return boardIDs, nil
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/heroiclabs/nakama/v3/server/evr"
+)
+
+func testStatisticsUpdateJournal(t *testing.T, ts time.Time, groupID string, prevWins, wins int64) *MatchDataJournal {
+	t.Helper()
+
+	update := MatchDataStatisticsUpdate{
+		UserID:      "user1",
+		DisplayName: "User One",
+		GroupID:     groupID,
+		Mode:        evr.ModeArenaPublic,
+		Previous: &evr.ArenaStatistics{
+			ArenaWins: &evr.StatisticIntegerIncrement{IntegerStatistic: evr.IntegerStatistic{Count: 1, Value: prevWins}},
+		},
+		Update: &evr.ArenaStatistics{
+			ArenaWins: &evr.StatisticIntegerIncrement{IntegerStatistic: evr.IntegerStatistic{Count: 1, Value: wins}},
+		},
+	}
+
+	// Journal entries are decoded as maps, as they are when journaled from the match data events.
+	b, err := json.Marshal(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]any{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	j := NewMatchDataJournal(MatchID{UUID: uuid.Must(uuid.NewV4()), Node: "testnode"})
+	j.CreatedAt = ts
+	j.Events = append(j.Events, &MatchDataJournalEntry{CreatedAt: ts, Type: MatchDataTypeStatisticsUpdate, Data: data})
+	return j
+}
+
+func TestStatisticsRecomputeBuild(t *testing.T) {
+	groupID := uuid.Must(uuid.NewV4()).String()
+	otherGroupID := uuid.Must(uuid.NewV4()).String()
+
+	day1 := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	sink, err := NewMatchDataFileSink(t.TempDir(), DefaultMatchDataFileMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	journals := []*MatchDataJournal{
+		testStatisticsUpdateJournal(t, day1, groupID, 5, 6),
+		testStatisticsUpdateJournal(t, day2, groupID, 6, 8),
+		testStatisticsUpdateJournal(t, day2, otherGroupID, 1, 2),
+		testStatisticsUpdateJournal(t, day2.Add(48*time.Hour), groupID, 8, 9), // Outside of the range
+	}
+	if err := sink.Write(context.Background(), journals); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := StatisticsRecomputeBuild(context.Background(), sink, groupID, evr.ModeArenaPublic, day1, day2.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Updates != 2 {
+		t.Errorf("expected 2 updates, got %d", r.Updates)
+	}
+
+	score := func(wins float64) int64 {
+		s, _ := Float64ToScore(wins)
+		return s
+	}
+	allTimeID := StatisticBoardID(groupID, evr.ModeArenaPublic, "ArenaWins", evr.ResetScheduleAllTime)
+	dailyID := StatisticBoardID(groupID, evr.ModeArenaPublic, "ArenaWins", evr.ResetScheduleDaily)
+
+	tests := []struct {
+		key      statisticsRecordKey
+		score    int64
+		numScore int64
+	}{
+		{statisticsRecordKey{allTimeID, 0, "user1"}, score(3), 2},
+		{statisticsRecordKey{dailyID, statisticsRecordExpiry(evr.ResetScheduleDaily, day1), "user1"}, score(1), 1},
+		{statisticsRecordKey{dailyID, statisticsRecordExpiry(evr.ResetScheduleDaily, day2), "user1"}, score(2), 1},
+	}
+	for _, tt := range tests {
+		rec, ok := r.records[tt.key]
+		if !ok {
+			t.Errorf("missing record %v", tt.key)
+			continue
+		}
+		if rec.Score != tt.score || rec.NumScore != tt.numScore {
+			t.Errorf("record %v: expected score %d (%d scores), got %d (%d scores)", tt.key, tt.score, tt.numScore, rec.Score, rec.NumScore)
+		}
+	}
+
+	if _, ok := r.Boards[allTimeID]; !ok {
+		t.Errorf("expected board %s", allTimeID)
+	}
+	for id := range r.Boards {
+		if meta, err := LeaderboardMetaFromID(id); err != nil || meta.GroupID != groupID {
+			t.Errorf("unexpected board %s", id)
+		}
+	}
+}
+
+func TestStatisticShadowBoardID(t *testing.T) {
+	boardID := StatisticBoardID(uuid.Must(uuid.NewV4()).String(), evr.ModeArenaPublic, "ArenaWins", evr.ResetScheduleWeekly)
+
+	// Shadow boards must not be mistaken for statistics boards.
+	if _, err := LeaderboardMetaFromID(StatisticShadowBoardID(boardID)); err == nil {
+		t.Errorf("expected the shadow board ID to be invalid as a statistics board ID")
+	}
+}
+
+func TestStatisticsRecomputeReplaysDeltas(t *testing.T) {
+	groupID := uuid.Must(uuid.NewV4()).String()
+	ts := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+
+	stats := func(wins, losses int64) *evr.ArenaStatistics {
+		return &evr.ArenaStatistics{
+			ArenaWins:   &evr.StatisticIntegerIncrement{IntegerStatistic: evr.IntegerStatistic{Count: 1, Value: wins}},
+			ArenaLosses: &evr.StatisticIntegerIncrement{IntegerStatistic: evr.IntegerStatistic{Count: 1, Value: losses}},
+		}
+	}
+
+	// The second update was journaled against stale live statistics; only its delta is replayed.
+	r := NewStatisticsRecompute(groupID, evr.ModeArenaPublic, ts)
+	for _, u := range []*MatchDataStatisticsUpdate{
+		{UserID: "user1", GroupID: groupID, Mode: evr.ModeArenaPublic, Previous: stats(5, 10), Update: stats(6, 10)},
+		{UserID: "user1", GroupID: groupID, Mode: evr.ModeArenaPublic, Previous: stats(50, 10), Update: stats(51, 10)},
+	} {
+		if err := r.Add(ts, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for statName, want := range map[string]float64{"ArenaWins": 2, "ArenaWinPercentage": 100} {
+		key := statisticsRecordKey{StatisticBoardID(groupID, evr.ModeArenaPublic, statName, evr.ResetScheduleAllTime), 0, "user1"}
+		rec, ok := r.records[key]
+		if !ok {
+			t.Errorf("missing record %v", key)
+			continue
+		}
+		if got := ScoreToFloat64(rec.Score); got != want {
+			t.Errorf("%s: expected %v, got %v", statName, want, got)
+		}
+	}
+}
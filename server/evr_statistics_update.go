@@ -5,9 +5,10 @@ import (
 	"fmt"
 
 	"github.com/heroiclabs/nakama/v3/server/evr"
+	"go.uber.org/zap"
 )
 
-func (p *EvrPipeline) updatePlayerStats(ctx context.Context, userID, groupID, displayName string, update evr.ServerProfileUpdate, mode evr.Symbol) error {
+func (p *EvrPipeline) updatePlayerStats(ctx context.Context, matchID MatchID, userID, groupID, displayName string, update evr.ServerProfileUpdate, mode evr.Symbol) error {
 	var stats evr.Statistics
 
 	// Select the correct statistics based on the mode
@@ -42,10 +43,27 @@ func (p *EvrPipeline) updatePlayerStats(ctx context.Context, userID, groupID, di
 		prevStats = evr.NewServerProfile().Statistics[g]
 	}
 
+	// Journal the update before it is applied, so the entries can be recomputed from it.
+	if err := MatchDataEvent(ctx, p.nk, matchID, MatchDataStatisticsUpdate{
+		UserID:      userID,
+		DisplayName: displayName,
+		GroupID:     groupID,
+		Mode:        mode,
+		Previous:    prevStats,
+		Update:      stats,
+	}); err != nil {
+		p.logger.Warn("Failed to journal statistics update", zap.String("mid", matchID.String()), zap.Error(err))
+	}
+
 	entries, err := StatisticsToEntries(userID, displayName, groupID, mode, prevStats, stats)
 	if err != nil {
 		return fmt.Errorf("failed to convert statistics to entries: %w", err)
 	}
 
-	return p.statisticsQueue.Add(entries)
+	updateID, err := StatisticsUpdateID(stats)
+	if err != nil {
+		return err
+	}
+
+	return p.statisticsQueue.Add(ctx, matchID, updateID, entries)
 }
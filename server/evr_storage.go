@@ -112,24 +112,33 @@ func StorageRead(ctx context.Context, nk runtime.NakamaModule, userID string, ds
 	return nil
 }
 
-func StorageWrite(ctx context.Context, nk runtime.NakamaModule, userID string, src Storable) (string, error) {
+// StorageWriteOp returns the write of the object, for writing several objects in one call to nk.StorageWrite.
+func StorageWriteOp(userID string, src Storable) (*runtime.StorageWrite, error) {
 	meta := src.StorageMeta()
 	data, err := json.Marshal(src)
 	if err != nil {
-		return "", status.Errorf(codes.Internal, "failed to marshal %s/%s: %s", userID, meta.String(), err.Error())
+		return nil, status.Errorf(codes.Internal, "failed to marshal %s/%s: %s", userID, meta.String(), err.Error())
 	}
 
-	acks, err := nk.StorageWrite(ctx, []*runtime.StorageWrite{
-		{
-			Collection:      meta.Collection,
-			Key:             meta.Key,
-			UserID:          userID,
-			Value:           string(data),
-			Version:         meta.Version,
-			PermissionRead:  meta.PermissionRead,
-			PermissionWrite: meta.PermissionWrite,
-		},
-	})
+	return &runtime.StorageWrite{
+		Collection:      meta.Collection,
+		Key:             meta.Key,
+		UserID:          userID,
+		Value:           string(data),
+		Version:         meta.Version,
+		PermissionRead:  meta.PermissionRead,
+		PermissionWrite: meta.PermissionWrite,
+	}, nil
+}
+
+func StorageWrite(ctx context.Context, nk runtime.NakamaModule, userID string, src Storable) (string, error) {
+	meta := src.StorageMeta()
+	op, err := StorageWriteOp(userID, src)
+	if err != nil {
+		return "", err
+	}
+
+	acks, err := nk.StorageWrite(ctx, []*runtime.StorageWrite{op})
 	if err != nil {
 		return "", status.Errorf(codes.Internal, "failed to write %s/%s: %v", userID, meta.String(), err.Error())
 	}
@@ -205,6 +205,10 @@ func NewSessionWS(logger *zap.Logger, config Config, format SessionFormat, sessi
 		isEarlyQuitter: atomic.NewBool(false),
 		isGoldNameTag:  atomic.NewBool(false),
 		latencyHistory: atomic.NewPointer[LatencyHistory](nil),
+
+		enforcementRecords:   atomic.NewPointer[GuildEnforcementRecords](nil),
+		isCommunityValuesDue: atomic.NewBool(false),
+		lobbyGameServer:      atomic.NewPointer[LobbyGameServer](nil),
 	}
 
 	ctx = context.WithValue(ctx, ctxSessionParametersKey{}, atomic.NewPointer(&params))